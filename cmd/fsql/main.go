@@ -1,38 +1,171 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/kshvmdn/fsql"
 	"github.com/kshvmdn/fsql/meta"
 	"github.com/kshvmdn/fsql/terminal"
+
+	xterminal "golang.org/x/crypto/ssh/terminal"
 )
 
 var options struct {
-	version bool
+	version    bool
+	absolute   bool
+	format     string
+	color      string
+	delimiter  string
+	literal    bool
+	sizeUnit   string
+	timeFormat string
+	print0     bool
+	template   string
+	jobs       int
+	verbose    bool
+	follow     bool
+	xdev       bool
+	maxDepth   int
+	max        int
+	binary     bool
+	count      bool
+	quiet      bool
+	dupes      bool
+	collate    string
+	total      bool
+	explain    bool
+	execBatch  bool
+	execAbort  bool
+	file       string
 }
 
-func readInput() string {
+// readInput returns the query, which is either read from the file passed to
+// -f, read from stdin (if the only argument is `-`), or made up of the
+// arguments.
+func readInput() (string, error) {
+	if options.file != "" {
+		if len(flag.Args()) > 0 {
+			return "", errors.New("cannot use both -f and a query argument")
+		}
+		f, err := os.Open(options.file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return fsql.ReadQuery(f)
+	}
+
+	if len(flag.Args()) == 1 && flag.Args()[0] == "-" {
+		return fsql.ReadQuery(os.Stdin)
+	}
+
 	if len(flag.Args()) > 1 {
-		return strings.Join(flag.Args(), " ")
+		return strings.Join(flag.Args(), " "), nil
 	}
 
-	return flag.Args()[0]
+	return flag.Args()[0], nil
+}
+
+// useColor returns true iff the output should be colored, where color is
+// `always`, `never`, or `auto`, in which case the output is colored iff stdout
+// is a terminal and the NO_COLOR environment variable isn't set (see
+// https://no-color.org).
+func useColor(color string) (bool, error) {
+	switch strings.ToLower(color) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return false, nil
+		}
+		return xterminal.IsTerminal(int(os.Stdout.Fd())), nil
+	}
+	return false, fmt.Errorf("invalid color %s: expected always, never, or auto", color)
+}
+
+// parseDelimiter returns the rune represented by delimiter, which is either a
+// single character or `tab`.
+func parseDelimiter(delimiter string) (rune, error) {
+	if strings.ToLower(delimiter) == "tab" || delimiter == "\\t" {
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return 0, fmt.Errorf("invalid delimiter %s: expected a single character or tab", delimiter)
+	}
+	r, _ := utf8.DecodeRuneInString(delimiter)
+	return r, nil
 }
 
 func main() {
 	flag.Usage = func() {
-		fmt.Printf("usage: %s [options] [query]\n", os.Args[0])
+		fmt.Printf("usage: %s [options] [query | -]\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 
 	flag.BoolVar(&options.version, "version", false, "print version and exit")
 	flag.BoolVar(&options.version, "v", false,
 		"print version and exit (shorthand)")
+	flag.StringVar(&options.format, "format", "table",
+		"output format (table, json, csv, nul, shell, or template)")
+	flag.StringVar(&options.color, "color", "auto",
+		"color names by file type in table output (always, never, or auto)")
+	flag.StringVar(&options.sizeUnit, "size-unit", "",
+		"show sizes in this unit unless they're modified, e.g. mb or human (as for FORMAT(size, ...))")
+	flag.StringVar(&options.timeFormat, "time-format", "",
+		"show times in this format unless they're modified, e.g. iso or a Go layout (as for FORMAT(time, ...))")
+	flag.BoolVar(&options.literal, "literal", false,
+		"show names as is in table output, without escaping control characters such as newlines")
+	flag.StringVar(&options.delimiter, "delimiter", ",",
+		"field delimiter for csv output (a single character or tab)")
+	flag.BoolVar(&options.print0, "print0", false,
+		"terminate each result with a NUL character (same as -format nul)")
+	flag.StringVar(&options.template, "template", "",
+		"Go template used to show each result, e.g. '{{.name}} ({{.size}})'")
+	flag.IntVar(&options.jobs, "jobs", 1,
+		"number of goroutines used to search each directory (0 for one per CPU)")
+	flag.BoolVar(&options.verbose, "verbose", false,
+		"show the error of each file which couldn't be read")
+	flag.BoolVar(&options.follow, "L", false,
+		"follow symbolic links")
+	flag.IntVar(&options.maxDepth, "maxdepth", 0,
+		"maximum depth of the files found in each source, e.g. 1 for its direct children (0 for no limit)")
+	flag.BoolVar(&options.xdev, "xdev", false,
+		"don't descend into directories on other filesystems")
+	flag.BoolVar(&options.absolute, "absolute", false,
+		"show path and FULLPATH(name) as absolute paths")
+	flag.BoolVar(&options.binary, "binary", false,
+		"search the contents of binary files in conditions on content (and count their lines and words)")
+	flag.IntVar(&options.max, "max", 0,
+		"stop after this many results, with a notice on stderr (0 for no limit)")
+	flag.StringVar(&options.collate, "collate", "",
+		"order strings in ORDER BY by the collation of this language, e.g. en (default byte order)")
+	flag.BoolVar(&options.count, "count", false,
+		"print only the number of results (exit status 1 if there are none)")
+	flag.BoolVar(&options.quiet, "quiet", false,
+		"print nothing, exit with status 0 if there are results and 1 otherwise")
+	flag.BoolVar(&options.quiet, "q", false,
+		"print nothing, exit with status 0 if there are results and 1 otherwise (shorthand)")
+	flag.BoolVar(&options.total, "total", false,
+		"print the total size of the results on stderr")
+	flag.BoolVar(&options.dupes, "dupes", false,
+		"print only the files which are duplicates of each other, grouped together")
+	flag.BoolVar(&options.explain, "explain", false,
+		"print the parsed query instead of running it")
+	flag.BoolVar(&options.execBatch, "exec-batch", false,
+		"run the EXEC command for many results at once, with {} replaced by each of their paths")
+	flag.BoolVar(&options.execAbort, "exec-abort", false,
+		"stop at the first EXEC command which fails")
+	flag.StringVar(&options.file, "f", "",
+		"read the query from a file")
 	flag.Parse()
 
 	if options.version {
@@ -40,14 +173,62 @@ func main() {
 		os.Exit(0)
 	}
 
-	if len(flag.Args()) == 0 {
+	if len(flag.Args()) == 0 && options.file == "" {
 		if err := terminal.Start(); err != nil {
 			log.Fatal(err.Error())
 		}
 		os.Exit(0)
 	}
 
-	if err := fsql.Run(readInput()); err != nil {
+	delimiter, err := parseDelimiter(options.delimiter)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	color, err := useColor(options.color)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	opts := fsql.Options{
+		Format:         options.format,
+		Delimiter:      delimiter,
+		Color:          color,
+		Literal:        options.literal,
+		SizeUnit:       options.sizeUnit,
+		TimeFormat:     options.timeFormat,
+		Template:       options.template,
+		Jobs:           options.jobs,
+		Verbose:        options.verbose,
+		FollowSymlinks: options.follow,
+		SameDevice:     options.xdev,
+		MaxDepth:       options.maxDepth,
+		Max:            options.max,
+		ScanBinary:     options.binary,
+		Absolute:       options.absolute,
+		Count:          options.count,
+		Collate:        options.collate,
+		Total:          options.total,
+		Quiet:          options.quiet,
+		Dupes:          options.dupes,
+		Explain:        options.explain,
+		ExecBatch:      options.execBatch,
+		ExecAbort:      options.execAbort,
+	}
+	if opts.Jobs == 0 {
+		opts.Jobs = runtime.GOMAXPROCS(0)
+	}
+	if options.print0 {
+		opts.Format = "nul"
+	}
+	if opts.Template != "" && opts.Format == "table" {
+		opts.Format = "template"
+	}
+	input, err := readInput()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if err := fsql.RunWithOptions(input, opts); err == fsql.ErrNoMatches {
+		os.Exit(1)
+	} else if err != nil {
 		log.Fatal(err.Error())
 	}
 }
@@ -0,0 +1,103 @@
+package fsql
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kshvmdn/fsql/query"
+	"github.com/kshvmdn/fsql/transform"
+)
+
+// dupeGroups returns the groups of rows whose files have identical contents,
+// each in the order its files were found, and ordered by their first file.
+// Files are bucketed by size first, so only files which are the same size as
+// another are hashed. Empty files, and files other than regular files (e.g.
+// directories), are never duplicates.
+func dupeGroups(rows []*row) ([][]*row, error) {
+	sizes := make(map[int64][]int)
+	for i, r := range rows {
+		if r.info.Mode().IsRegular() && r.info.Size() > 0 {
+			sizes[r.info.Size()] = append(sizes[r.info.Size()], i)
+		}
+	}
+
+	hashes := make(map[string][]int)
+	for size, bucket := range sizes {
+		if len(bucket) < 2 {
+			continue
+		}
+		for _, i := range bucket {
+			sum, err := transform.ComputeHash(rows[i].info, rows[i].path,
+				transform.FindHash("SHA256")())
+			if err != nil {
+				return nil, err
+			}
+			key := fmt.Sprintf("%d:%v", size, sum)
+			hashes[key] = append(hashes[key], i)
+		}
+	}
+
+	indices := make([][]int, 0)
+	for _, group := range hashes {
+		if len(group) > 1 {
+			sort.Ints(group)
+			indices = append(indices, group)
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i][0] < indices[j][0] })
+
+	groups := make([][]*row, len(indices))
+	for i, group := range indices {
+		groups[i] = make([]*row, len(group))
+		for j, k := range group {
+			groups[i][j] = rows[k]
+		}
+	}
+	return groups, nil
+}
+
+// truncateGroups returns the first max rows of groups, in order, or all of
+// them if max is 0.
+func truncateGroups(groups [][]*row, max int) [][]*row {
+	if max <= 0 {
+		return groups
+	}
+	for i, group := range groups {
+		if len(group) >= max {
+			return append(groups[:i:i], group[:max])
+		}
+		max -= len(group)
+	}
+	return groups
+}
+
+// writeGroups writes groups with write. In the `table` format, each group is
+// written separately, followed by a blank line (aside from the last), like
+// `fdupes`; in the other formats, they're written as a single list of rows.
+func writeGroups(w io.Writer, q *query.Query, groups [][]*row, write writer,
+	opts Options) error {
+	if opts.Format != "" && opts.Format != "table" {
+		return write(w, q, flatten(groups), opts)
+	}
+	for i, group := range groups {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := write(w, q, group, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flatten returns the rows of groups, in order.
+func flatten(groups [][]*row) []*row {
+	rows := make([]*row, 0)
+	for _, group := range groups {
+		rows = append(rows, group...)
+	}
+	return rows
+}
@@ -1,9 +1,14 @@
 package evaluate
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kshvmdn/fsql/tokenizer"
@@ -15,21 +20,20 @@ func cmpAlpha(o *Opts, a, b interface{}) (result bool, err error) {
 	switch o.Operator {
 	case tokenizer.Equals:
 		result = a.(string) == b.(string)
+	case tokenizer.IEquals:
+		result = strings.EqualFold(a.(string), b.(string))
 	case tokenizer.NotEquals:
 		result = a.(string) != b.(string)
-	case tokenizer.Like:
-		aStr, bStr := a.(string), b.(string)
-		if strings.HasPrefix(bStr, "%") && strings.HasSuffix(bStr, "%") {
-			result = strings.Contains(aStr, bStr[1:len(bStr)-1])
-		} else if strings.HasPrefix(bStr, "%") {
-			result = strings.HasSuffix(aStr, bStr[1:])
-		} else if strings.HasSuffix(bStr, "%") {
-			result = strings.HasPrefix(aStr, bStr[:len(bStr)-1])
-		} else {
-			result = strings.Contains(aStr, bStr)
-		}
+	case tokenizer.Like, tokenizer.ILike:
+		re := likePattern(b.(string), o.Operator == tokenizer.ILike)
+		result = re.MatchString(a.(string))
 	case tokenizer.RLike:
-		result = regexp.MustCompile(b.(string)).MatchString(a.(string))
+		var re *regexp.Regexp
+		if re, err = regexpPattern(b.(string)); err == nil {
+			result = re.MatchString(a.(string))
+		}
+	case tokenizer.Glob:
+		result, err = filepath.Match(b.(string), a.(string))
 	case tokenizer.In:
 		switch t := b.(type) {
 		case map[interface{}]bool:
@@ -55,6 +59,74 @@ func cmpAlpha(o *Opts, a, b interface{}) (result bool, err error) {
 	return result, err
 }
 
+// patterns caches the compiled regular expression for each LIKE / ILIKE /
+// RLIKE pattern, so that patterns aren't recompiled for every file.
+var patterns = struct {
+	sync.Mutex
+	cache map[string]*regexp.Regexp
+}{cache: make(map[string]*regexp.Regexp)}
+
+// likePattern returns the regular expression equivalent to the SQL LIKE
+// pattern: `%` matches zero or more characters, `_` matches a single
+// character, and a backslash escapes the following character. The pattern
+// must match the full value. If fold is true, the match is case-insensitive.
+func likePattern(pattern string, fold bool) *regexp.Regexp {
+	key := fmt.Sprintf("like:%t:%s", fold, pattern)
+
+	patterns.Lock()
+	defer patterns.Unlock()
+	if re, ok := patterns.cache[key]; ok {
+		return re
+	}
+
+	var buf bytes.Buffer
+	if fold {
+		buf.WriteString("(?is)")
+	} else {
+		buf.WriteString("(?s)")
+	}
+	buf.WriteString("^")
+	for escaped, runes := false, []rune(pattern); len(runes) > 0; runes = runes[1:] {
+		switch r := runes[0]; {
+		case escaped:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			buf.WriteString(".*")
+		case r == '_':
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	buf.WriteString("$")
+
+	// The pattern is fully escaped, so compilation can't fail.
+	re := regexp.MustCompile(buf.String())
+	patterns.cache[key] = re
+	return re
+}
+
+// regexpPattern returns the compiled regular expression for the RLIKE pattern.
+func regexpPattern(pattern string) (*regexp.Regexp, error) {
+	key := "rlike:" + pattern
+
+	patterns.Lock()
+	defer patterns.Unlock()
+	if re, ok := patterns.cache[key]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.cache[key] = re
+	return re, nil
+}
+
 // cmpNumeric performs numeric comparison on a and b.
 func cmpNumeric(o *Opts, a, b interface{}) (result bool, err error) {
 	switch o.Operator {
@@ -105,28 +177,120 @@ func cmpTime(o *Opts, a, b interface{}) (result bool, err error) {
 	return result, err
 }
 
-// cmpMode performs mode comparison with info and typ.
-func cmpMode(o *Opts) (result bool, err error) {
-	if o.Operator != tokenizer.Is {
-		return false, &ErrUnsupportedOperator{o.Attribute, o.Operator}
-	}
-	switch strings.ToUpper(o.Value.(string)) {
-	case "DIR":
-		result = o.File.Mode().IsDir()
-	case "REG":
-		result = o.File.Mode().IsRegular()
+// cmpBool compares the boolean attribute a with the condition's value, which
+// is any value accepted by strconv.ParseBool (e.g. `true` or `0`).
+func cmpBool(o *Opts, a bool) (result bool, err error) {
+	s, ok := o.Value.(string)
+	if !ok {
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+
+	switch o.Operator {
+	case tokenizer.Equals:
+		result = a == b
+	case tokenizer.NotEquals:
+		result = a != b
 	default:
-		result = false
+		err = &ErrUnsupportedOperator{o.Attribute, o.Operator}
 	}
 	return result, err
 }
 
+// cmpMode compares the mode of the current file with the provided value. IS
+// tests the file's type (`DIR` or `REG`). Otherwise, the value holds
+// permission bits in octal (e.g. `0755`), which are compared with the file's
+// permission bits as a bitmask: `=` / `<>` compare them exactly, `>=` is true
+// iff the file has every bit of the value (e.g. `mode >= 0100` for files the
+// owner may execute), and `<=` iff the file has no bits besides those of the
+// value. `>` / `<` additionally require the bits to differ.
+func cmpMode(o *Opts) (result bool, err error) {
+	if o.Operator == tokenizer.Is {
+		switch strings.ToUpper(o.Value.(string)) {
+		case "DIR":
+			result = o.File.Mode().IsDir()
+		case "REG":
+			result = o.File.Mode().IsRegular()
+		default:
+			result = false
+		}
+		return result, nil
+	}
+
+	perm := o.File.Mode().Perm()
+	switch t := o.Value.(type) {
+	case string:
+		switch o.Operator {
+		case tokenizer.Equals, tokenizer.NotEquals, tokenizer.GreaterThanEquals,
+			tokenizer.GreaterThan, tokenizer.LessThanEquals, tokenizer.LessThan:
+		default:
+			return false, &ErrUnsupportedOperator{o.Attribute, o.Operator}
+		}
+		value, err := parsePerm(t)
+		if err != nil {
+			return false, err
+		}
+		switch o.Operator {
+		case tokenizer.Equals:
+			result = perm == value
+		case tokenizer.NotEquals:
+			result = perm != value
+		case tokenizer.GreaterThanEquals:
+			result = perm&value == value
+		case tokenizer.GreaterThan:
+			result = perm&value == value && perm != value
+		case tokenizer.LessThanEquals:
+			result = perm&^value == 0
+		case tokenizer.LessThan:
+			result = perm&^value == 0 && perm != value
+		}
+	case []string:
+		if o.Operator != tokenizer.In {
+			return false, &ErrUnsupportedOperator{o.Attribute, o.Operator}
+		}
+		for _, el := range t {
+			value, err := parsePerm(el)
+			if err != nil {
+				return false, err
+			}
+			if perm == value {
+				result = true
+			}
+		}
+	case map[interface{}]bool:
+		// The modes of a subquery's results, which include the file's type.
+		if o.Operator != tokenizer.In {
+			return false, &ErrUnsupportedOperator{o.Attribute, o.Operator}
+		}
+		result = t[o.File.Mode()]
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return result, nil
+}
+
+// parsePerm parses permission bits written in octal (e.g. `0755` or `755`).
+func parsePerm(value string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || perm > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("invalid mode %s: expected octal permission bits (e.g. 0755)",
+			value)
+	}
+	return os.FileMode(perm), nil
+}
+
 // cmpHash computes the hash of the current file and compares it with the
 // provided value.
 func cmpHash(o *Opts) (result bool, err error) {
 	hashType := "SHA1"
 	if len(o.Modifiers) > 0 {
 		hashType = o.Modifiers[0].Name
+		if strings.ToUpper(hashType) == "HASH" && len(o.Modifiers[0].Arguments) > 0 {
+			hashType = o.Modifiers[0].Arguments[0]
+		}
 	}
 
 	hashFunc := transform.FindHash(hashType)
@@ -141,6 +305,8 @@ func cmpHash(o *Opts) (result bool, err error) {
 	switch o.Operator {
 	case tokenizer.Equals:
 		result = h == o.Value
+	case tokenizer.IEquals:
+		result = strings.EqualFold(fmt.Sprint(h), fmt.Sprint(o.Value))
 	case tokenizer.NotEquals:
 		result = h != o.Value
 	default:
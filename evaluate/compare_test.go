@@ -1,8 +1,11 @@
 package evaluate
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp/syntax"
 	"testing"
 	"time"
 
@@ -40,6 +43,19 @@ func TestCmpAlpha(t *testing.T) {
 			expected: Expected{result: false, err: nil},
 		},
 
+		{
+			input:    Input{o: Opts{Operator: tokenizer.IEquals}, a: "README.md", b: "readme.MD"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.IEquals}, a: "a", b: "b"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.IEquals}, a: "ß", b: "SS"},
+			expected: Expected{result: false, err: nil},
+		},
+
 		{
 			input:    Input{o: Opts{Operator: tokenizer.NotEquals}, a: "a", b: "a"},
 			expected: Expected{result: false, err: nil},
@@ -85,6 +101,54 @@ func TestCmpAlpha(t *testing.T) {
 			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "a", b: "b"},
 			expected: Expected{result: false, err: nil},
 		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "abc", b: "b"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "abc", b: "a_c"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "ac", b: "a_c"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "", b: "%"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "main.go", b: "%.go"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "main_go", b: "%.go"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "a(b)+", b: "a(%)+"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "50%", b: "%\\%"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "50", b: "%\\%"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Like}, a: "ABC", b: "a%"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.ILike}, a: "ABC", b: "a%"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.ILike}, a: "abc", b: "A_C"},
+			expected: Expected{result: true, err: nil},
+		},
 
 		{
 			input:    Input{o: Opts{Operator: tokenizer.RLike}, a: "a", b: ".*a.*"},
@@ -106,6 +170,37 @@ func TestCmpAlpha(t *testing.T) {
 			input:    Input{o: Opts{Operator: tokenizer.RLike}, a: "aaa", b: "\\s+"},
 			expected: Expected{result: false, err: nil},
 		},
+		{
+			input: Input{o: Opts{Operator: tokenizer.RLike}, a: "a", b: "[a"},
+			expected: Expected{
+				err: &syntax.Error{Code: syntax.ErrMissingBracket, Expr: "[a"},
+			},
+		},
+
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Glob}, a: "main.go", b: "*.go"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Glob}, a: "main.go", b: "m?in.[a-h]o"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Glob}, a: "main.go", b: "*.g"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Glob}, a: "foo/main.go", b: "*.go"},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Glob}, a: "foo/main.go", b: "*/*.go"},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Glob}, a: "a", b: "[a"},
+			expected: Expected{err: filepath.ErrBadPattern},
+		},
 
 		{
 			input:    Input{o: Opts{Operator: tokenizer.In}, a: "a", b: map[interface{}]bool{"a": true}},
@@ -367,8 +462,85 @@ func TestCmpMode(t *testing.T) {
 		expected Expected
 	}
 
-	// TODO: Complete these cases.
-	cases := []Case{}
+	file := modeInfo(0754)
+	dir := modeInfo(os.ModeDir | 0755)
+
+	cases := []Case{
+		{
+			input:    Input{o: Opts{File: dir, Operator: tokenizer.Is, Value: "dir"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.Is, Value: "DIR"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.Is, Value: "reg"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.Equals, Value: "0754"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: dir, Operator: tokenizer.Equals, Value: "755"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.NotEquals, Value: "0755"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.GreaterThanEquals, Value: "0700"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.GreaterThanEquals, Value: "0001"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.GreaterThan, Value: "0754"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.LessThanEquals, Value: "0755"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.LessThanEquals, Value: "0700"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{File: file, Operator: tokenizer.LessThan, Value: "0777"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input: Input{o: Opts{File: file, Operator: tokenizer.In,
+				Value: []string{"0644", "0754"}}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input: Input{o: Opts{File: dir, Operator: tokenizer.In,
+				Value: map[interface{}]bool{os.FileMode(0755): true}}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input: Input{o: Opts{File: dir, Operator: tokenizer.In,
+				Value: map[interface{}]bool{os.ModeDir | 0755: true}}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input: Input{o: Opts{File: file, Operator: tokenizer.Equals, Value: "0999"}},
+			expected: Expected{result: false,
+				err: errors.New("invalid mode 0999: expected octal permission bits (e.g. 0755)")},
+		},
+		{
+			input: Input{o: Opts{File: file, Attribute: "mode", Operator: tokenizer.Like,
+				Value: "07%"}},
+			expected: Expected{result: false,
+				err: &ErrUnsupportedOperator{"mode", tokenizer.Like}},
+		},
+	}
 
 	for _, c := range cases {
 		actual, err := cmpMode(&c.input.o)
@@ -386,6 +558,16 @@ func TestCmpMode(t *testing.T) {
 	}
 }
 
+// modeInfo is an os.FileInfo which only has a mode.
+type modeInfo os.FileMode
+
+func (m modeInfo) Name() string       { return "file" }
+func (m modeInfo) Size() int64        { return 0 }
+func (m modeInfo) Mode() os.FileMode  { return os.FileMode(m) }
+func (m modeInfo) ModTime() time.Time { return time.Time{} }
+func (m modeInfo) IsDir() bool        { return os.FileMode(m).IsDir() }
+func (m modeInfo) Sys() interface{}   { return nil }
+
 func TestCmpHash(t *testing.T) {
 	type Input struct {
 		o    Opts
@@ -403,8 +585,52 @@ func TestCmpHash(t *testing.T) {
 		expected Expected
 	}
 
-	// TODO: Complete these cases.
-	cases := []Case{}
+	info, err := os.Stat("../testdata/baz")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	sha1Sum := "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	sha256Sum := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	cases := []Case{
+		{
+			input: Input{o: Opts{Path: "../testdata/baz", File: info,
+				Operator: tokenizer.Equals, Value: sha1Sum}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input: Input{o: Opts{Path: "../testdata/baz", File: info,
+				Operator: tokenizer.NotEquals, Value: sha1Sum}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input: Input{o: Opts{Path: "../testdata/baz", File: info,
+				Modifiers: []Modifier{{Name: "SHA256"}},
+				Operator:  tokenizer.Equals, Value: sha256Sum}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input: Input{o: Opts{Path: "../testdata/baz", File: info,
+				Modifiers: []Modifier{{Name: "HASH", Arguments: []string{"sha256"}}},
+				Operator:  tokenizer.Equals, Value: sha256Sum}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input: Input{o: Opts{Path: "../testdata/baz", File: info,
+				Modifiers: []Modifier{{Name: "HASH", Arguments: []string{"md5"}}},
+				Operator:  tokenizer.Equals, Value: sha256Sum}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input: Input{o: Opts{Path: "../testdata/baz", File: info,
+				Attribute: "hash", Operator: tokenizer.Like, Value: sha1Sum}},
+			expected: Expected{
+				result: false,
+				err:    &ErrUnsupportedOperator{"hash", tokenizer.Like},
+			},
+		},
+	}
 
 	for _, c := range cases {
 		actual, err := cmpHash(&c.input.o)
@@ -421,3 +647,74 @@ func TestCmpHash(t *testing.T) {
 		}
 	}
 }
+
+func TestCmpBool(t *testing.T) {
+	type Input struct {
+		o Opts
+		a bool
+	}
+
+	type Expected struct {
+		result bool
+		err    error
+	}
+
+	type Case struct {
+		input    Input
+		expected Expected
+	}
+
+	cases := []Case{
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Equals, Value: "true"}, a: true},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Equals, Value: "1"}, a: true},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.Equals, Value: "false"}, a: true},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.NotEquals, Value: "false"}, a: true},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			input:    Input{o: Opts{Operator: tokenizer.NotEquals, Value: "false"}, a: false},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			input: Input{o: Opts{Attribute: "is_symlink", Operator: tokenizer.Equals,
+				Value: "maybe"}, a: true},
+			expected: Expected{
+				result: false,
+				err:    &ErrUnsupportedType{"is_symlink", "maybe"},
+			},
+		},
+		{
+			input: Input{o: Opts{Attribute: "is_symlink", Operator: tokenizer.GreaterThan,
+				Value: "true"}, a: true},
+			expected: Expected{
+				result: false,
+				err:    &ErrUnsupportedOperator{"is_symlink", tokenizer.GreaterThan},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := cmpBool(&c.input.o, c.input.a)
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.result, actual) {
+				t.Fatalf("%v, %v, %v\nExpected: %v\n     Got: %v",
+					c.input.o.Operator, c.input.o.Value, c.input.a, c.expected.result, actual)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
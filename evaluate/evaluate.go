@@ -1,21 +1,39 @@
 package evaluate
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/kshvmdn/fsql/tokenizer"
+	"github.com/kshvmdn/fsql/transform"
 )
 
 // Opts represents a set of options used in the evaluate functions.
 type Opts struct {
 	Path      string
 	File      os.FileInfo
+	Depth     int64
 	Attribute string
 	Modifiers []Modifier
 	Operator  tokenizer.TokenType
 	Value     interface{}
+
+	// Binary is true iff the contents of binary files are compared for the
+	// `content` attribute (otherwise binary files never match).
+	Binary bool
+
+	// Now is the time which relative time values (e.g. `-7d` or `today`) are
+	// relative to, i.e. when the query started. Defaults to the current time.
+	Now time.Time
+
+	// Modified is the value of the attribute with its modifiers applied, if
+	// the condition compares it rather than the attribute itself (e.g.
+	// `LENGTH(name) > 40`), otherwise nil.
+	Modified interface{}
 }
 
 // Modifier represents an attribute modifier.
@@ -26,12 +44,53 @@ type Modifier struct {
 
 // Evaluate runs the respective evaluate function for the provided options.
 func Evaluate(o *Opts) (bool, error) {
+	if o.Operator == tokenizer.Between {
+		return evaluateBetween(o)
+	}
+	if o.Modified != nil {
+		return evaluateModified(o)
+	}
+
 	switch o.Attribute {
 	case "name":
 		return evaluateName(o)
+	case "extension":
+		return evaluateExtension(o)
 	case "size":
 		return evaluateSize(o)
-	case "time":
+	case "depth":
+		return evaluateDepth(o)
+	case "owner", "group":
+		return evaluateOwner(o)
+	case "uid", "gid":
+		return evaluateOwnerID(o)
+	case "inode", "nlink", "device":
+		return evaluateInode(o)
+	case "is_dir":
+		return cmpBool(o, o.File.IsDir())
+	case "is_file":
+		return cmpBool(o, o.File.Mode().IsRegular())
+	case "is_symlink":
+		return cmpBool(o, transform.IsSymlink(o.Path, o.File))
+	case "is_hidden":
+		return cmpBool(o, transform.IsHidden(o.File))
+	case "is_empty":
+		return evaluateEmpty(o)
+	case "is_executable":
+		return cmpBool(o, transform.IsExecutable(o.File))
+	case "has_xattr":
+		return evaluateXattr(o)
+	case "symlink_target":
+		return evaluateSymlinkTarget(o)
+	case "path", "abspath":
+		return evaluatePath(o)
+	case "content":
+		return evaluateContent(o)
+	case "lines", "words":
+		return evaluateLines(o)
+	case "mime", "kind":
+		return evaluateMIME(o)
+	case "time", "accessed", "changed", "created":
 		return evaluateTime(o)
 	case "mode":
 		return evaluateMode(o)
@@ -41,6 +100,58 @@ func Evaluate(o *Opts) (bool, error) {
 	return false, &ErrUnsupportedAttribute{o.Attribute}
 }
 
+// evaluateBetween evaluates a Condition with operator BETWEEN as the
+// conjunction of `>=` the low bound and `<=` the high bound.
+func evaluateBetween(o *Opts) (bool, error) {
+	bounds, ok := o.Value.([]interface{})
+	if !ok || len(bounds) != 2 {
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+
+	low, high := *o, *o
+	low.Operator, low.Value = tokenizer.GreaterThanEquals, bounds[0]
+	high.Operator, high.Value = tokenizer.LessThanEquals, bounds[1]
+
+	if ok, err := Evaluate(&low); err != nil || !ok {
+		return false, err
+	}
+	return Evaluate(&high)
+}
+
+// evaluateModified evaluates a Condition by comparing the modified value of its
+// attribute (see Opts.Modified), which is either numeric or a string.
+func evaluateModified(o *Opts) (bool, error) {
+	switch a := o.Modified.(type) {
+	case int64:
+		var b interface{}
+		switch o.Value.(type) {
+		case map[interface{}]bool:
+			b = o.Value
+		case []string:
+			set, err := numericSet(o.Value.([]string))
+			if err != nil {
+				return false, err
+			}
+			b = set
+		case string:
+			n, err := strconv.ParseInt(o.Value.(string), 10, 64)
+			if err != nil {
+				return false, err
+			}
+			b = n
+		default:
+			return false, &ErrUnsupportedType{o.Attribute, o.Value}
+		}
+		return cmpNumeric(o, a, b)
+	case string:
+		switch o.Value.(type) {
+		case string, []string, map[interface{}]bool:
+			return cmpAlpha(o, a, o.Value)
+		}
+	}
+	return false, &ErrUnsupportedType{o.Attribute, o.Value}
+}
+
 // evaluateName evaluates a Condition with attribute `name`.
 func evaluateName(o *Opts) (bool, error) {
 	var a, b interface{}
@@ -54,6 +165,19 @@ func evaluateName(o *Opts) (bool, error) {
 	return cmpAlpha(o, a, b)
 }
 
+// evaluateExtension evaluates a Condition with attribute `extension`.
+func evaluateExtension(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Value.(type) {
+	case string, []string, map[interface{}]bool:
+		a = transform.Extension(o.File.Name())
+		b = o.Value
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpAlpha(o, a, b)
+}
+
 // evaluateSize evaluates a Condition with attribute `size`.
 func evaluateSize(o *Opts) (bool, error) {
 	var a, b interface{}
@@ -64,8 +188,19 @@ func evaluateSize(o *Opts) (bool, error) {
 	case map[interface{}]bool:
 		a = o.File.Size()
 		b = o.Value
+	case []string:
+		set := make(map[interface{}]bool, len(o.Value.([]string)))
+		for _, value := range o.Value.([]string) {
+			size, err := transform.ParseSize(value)
+			if err != nil {
+				return false, err
+			}
+			set[transform.SetKey(size)] = true
+		}
+		a = o.File.Size()
+		b = set
 	case string:
-		size, err := strconv.ParseFloat(o.Value.(string), 10)
+		size, err := transform.ParseSize(o.Value.(string))
 		if err != nil {
 			return false, err
 		}
@@ -77,28 +212,392 @@ func evaluateSize(o *Opts) (bool, error) {
 	return cmpNumeric(o, a, b)
 }
 
+// evaluateDepth evaluates a Condition with attribute `depth`.
+func evaluateDepth(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Value.(type) {
+	case map[interface{}]bool:
+		a = o.Depth
+		b = o.Value
+	case []string:
+		set, err := numericSet(o.Value.([]string))
+		if err != nil {
+			return false, err
+		}
+		a = o.Depth
+		b = set
+	case string:
+		depth, err := strconv.ParseInt(o.Value.(string), 10, 64)
+		if err != nil {
+			return false, err
+		}
+		a = o.Depth
+		b = depth
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpNumeric(o, a, b)
+}
+
+// evaluateSymlinkTarget evaluates a Condition with attribute `symlink_target`.
+func evaluateSymlinkTarget(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Value.(type) {
+	case string, []string, map[interface{}]bool:
+		a = transform.SymlinkTarget(o.Path, o.File)
+		b = o.Value
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpAlpha(o, a, b)
+}
+
+// evaluatePath evaluates a Condition with attribute `path` or `abspath`.
+func evaluatePath(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Value.(type) {
+	case string, []string, map[interface{}]bool:
+		b = o.Value
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	if o.Attribute == "abspath" {
+		path, err := transform.AbsolutePath(o.Path)
+		if err != nil {
+			return false, err
+		}
+		a = path
+	} else {
+		a = transform.RelativePath(o.Path, o.Depth)
+	}
+	return cmpAlpha(o, a, b)
+}
+
+// evaluateMIME evaluates a Condition with attribute `mime` or `kind`.
+func evaluateMIME(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Value.(type) {
+	case string, []string, map[interface{}]bool:
+		if o.Attribute == "mime" {
+			a = transform.MIMEType(o.Path, o.File)
+		} else {
+			a = transform.Kind(o.Path, o.File)
+		}
+		b = o.Value
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpAlpha(o, a, b)
+}
+
+// evaluateOwner evaluates a Condition with attribute `owner` or `group`.
+func evaluateOwner(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Value.(type) {
+	case string, []string, map[interface{}]bool:
+		if o.Attribute == "owner" {
+			a = transform.Owner(o.File)
+		} else {
+			a = transform.Group(o.File)
+		}
+		b = o.Value
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpAlpha(o, a, b)
+}
+
+// evaluateEmpty evaluates a Condition with attribute `is_empty`.
+func evaluateEmpty(o *Opts) (bool, error) {
+	empty, err := transform.IsEmpty(o.Path, o.File)
+	if err != nil {
+		return false, err
+	}
+	return cmpBool(o, empty)
+}
+
+// evaluateXattr evaluates a Condition with attribute `has_xattr`.
+func evaluateXattr(o *Opts) (bool, error) {
+	has, err := transform.HasXattr(o.Path, o.File)
+	if err != nil {
+		return false, err
+	}
+	return cmpBool(o, has)
+}
+
+// evaluateLines evaluates a Condition with attribute `lines` or `words`. The
+// file is only read once the value is known to be valid.
+func evaluateLines(o *Opts) (bool, error) {
+	var b interface{}
+	switch o.Value.(type) {
+	case map[interface{}]bool:
+		b = o.Value
+	case []string:
+		set, err := numericSet(o.Value.([]string))
+		if err != nil {
+			return false, err
+		}
+		b = set
+	case string:
+		lines, err := strconv.ParseInt(o.Value.(string), 10, 64)
+		if err != nil {
+			return false, err
+		}
+		b = lines
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+
+	count := transform.LineCount
+	if o.Attribute == "words" {
+		count = transform.WordCount
+	}
+	a, err := count(o.Path, o.File, o.Binary)
+	if err != nil {
+		return false, err
+	}
+	return cmpNumeric(o, a, b)
+}
+
+// evaluateContent evaluates a Condition with attribute `content`, which is
+// satisfied iff any line of the file satisfies it. The negative operators
+// aren't supported since they'd be satisfied by nearly every file, use NOT to
+// find files where no line satisfies a condition instead.
+func evaluateContent(o *Opts) (bool, error) {
+	switch o.Value.(type) {
+	case string, []string, map[interface{}]bool:
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	switch o.Operator {
+	case tokenizer.Equals, tokenizer.IEquals, tokenizer.Like, tokenizer.ILike,
+		tokenizer.RLike, tokenizer.Glob, tokenizer.In:
+	default:
+		return false, &ErrUnsupportedOperator{o.Attribute, o.Operator}
+	}
+	return transform.MatchContent(o.Path, o.File, o.Binary, func(line string) (bool, error) {
+		return cmpAlpha(o, line, o.Value)
+	})
+}
+
+// evaluateOwnerID evaluates a Condition with attribute `uid` or `gid`.
+func evaluateOwnerID(o *Opts) (bool, error) {
+	var a, b interface{}
+	if o.Attribute == "uid" {
+		a = transform.UID(o.File)
+	} else {
+		a = transform.GID(o.File)
+	}
+	switch o.Value.(type) {
+	case map[interface{}]bool:
+		b = o.Value
+	case []string:
+		set, err := numericSet(o.Value.([]string))
+		if err != nil {
+			return false, err
+		}
+		b = set
+	case string:
+		id, err := strconv.ParseInt(o.Value.(string), 10, 64)
+		if err != nil {
+			return false, err
+		}
+		b = id
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpNumeric(o, a, b)
+}
+
+// evaluateInode evaluates a Condition with attribute `inode`, `nlink`, or
+// `device`.
+func evaluateInode(o *Opts) (bool, error) {
+	var a, b interface{}
+	switch o.Attribute {
+	case "inode":
+		a = transform.Inode(o.File)
+	case "nlink":
+		a = transform.Nlink(o.File)
+	default:
+		a = transform.Device(o.File)
+	}
+	switch o.Value.(type) {
+	case map[interface{}]bool:
+		b = o.Value
+	case []string:
+		set, err := numericSet(o.Value.([]string))
+		if err != nil {
+			return false, err
+		}
+		b = set
+	case string:
+		n, err := strconv.ParseInt(o.Value.(string), 10, 64)
+		if err != nil {
+			return false, err
+		}
+		b = n
+	default:
+		return false, &ErrUnsupportedType{o.Attribute, o.Value}
+	}
+	return cmpNumeric(o, a, b)
+}
+
 // evaluateTime evaluates a Condition with attribute `time`.
 func evaluateTime(o *Opts) (bool, error) {
 	var a, b interface{}
 	switch o.Value.(type) {
 	case string:
-		t, err := time.Parse("Jan 02 2006 15 04", o.Value.(string))
+		now := o.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		t, err := ParseTime(o.Value.(string), now)
 		if err != nil {
 			return false, err
 		}
-		a = o.File.ModTime()
 		b = t
 	case map[interface{}]bool, time.Time:
-		a = o.File.ModTime()
 		b = o.Value
 	default:
 		return false, &ErrUnsupportedType{o.Attribute, o.Value}
 	}
+
+	// A time which isn't available (e.g. `created` on Linux) never matches.
+	t, ok := transform.FileTime(o.Attribute, o.File)
+	if !ok {
+		return false, nil
+	}
+	a = t
 	return cmpTime(o, a, b)
 }
 
+// relativeUnits holds the duration of each unit of a relative time literal,
+// except for days and weeks, which are calendar days (see ParseTime).
+var relativeUnits = map[string]time.Duration{
+	"h": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+// timeLayouts holds the layouts of an absolute time literal (see ParseTime),
+// in the order they're tried: an RFC 3339 time, an ISO 8601 date (with or
+// without a time of day), and the default layout.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"Jan 02 2006 15 04",
+}
+
+// parseAbsoluteTime parses an absolute time literal in any of timeLayouts.
+// ISO 8601 dates and times without a time zone are in loc, whereas the
+// default layout is in UTC.
+func parseAbsoluteTime(literal string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timeLayouts[:len(timeLayouts)-1] {
+		if t, err := time.ParseInLocation(layout, literal, loc); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.Parse(timeLayouts[len(timeLayouts)-1], literal)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s: expected e.g. 2006-01-02, "+
+			"2006-01-02T15:04:05Z07:00, Jan 02 2006 15 04, or a relative time", literal)
+	}
+	return t, nil
+}
+
+// ParseTime parses a time literal, which is either an absolute time (see
+// parseAbsoluteTime, e.g. `2006-01-02` or `Jan 02 2006 15 04`), or relative
+// to now: `now` (i.e. now itself) or `today` (midnight of now's day),
+// optionally followed by an offset (e.g. `now-1h`), or an offset by itself
+// (e.g. `-7d`). An offset is a sign followed by one or more numbers, each with
+// a unit of `w` (weeks), `d` (days), `h`, `m`, or `s`, e.g. `-1d12h`.
+//
+// Days and weeks are calendar days in now's time zone, so e.g. `-1d` is the
+// same wall clock time yesterday, which is 23 or 25 hours ago across a
+// daylight saving time change. The other units are exact durations.
+func ParseTime(literal string, now time.Time) (time.Time, error) {
+	t, offset := now, literal
+	switch {
+	case len(literal) >= 3 && strings.EqualFold(literal[:3], "now"):
+		offset = literal[3:]
+	case len(literal) >= 5 && strings.EqualFold(literal[:5], "today"):
+		year, month, day := now.Date()
+		t, offset = time.Date(year, month, day, 0, 0, 0, 0, now.Location()), literal[5:]
+	case !strings.HasPrefix(literal, "-") && !strings.HasPrefix(literal, "+"):
+		return parseAbsoluteTime(literal, now.Location())
+	}
+	if offset == "" {
+		return t, nil
+	}
+
+	sign := 1
+	switch offset[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return time.Time{}, fmt.Errorf("invalid relative time %s: expected a sign before the offset",
+			literal)
+	}
+
+	for s := offset[1:]; ; {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(s) && unicode.IsLetter(rune(s[j])) {
+			j++
+		}
+		if i == 0 || j == i {
+			return time.Time{}, fmt.Errorf("invalid relative time %s: expected a number "+
+				"followed by a unit (w, d, h, m, or s)", literal)
+		}
+
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time %s: %v", literal, err)
+		}
+		switch unit := strings.ToLower(s[i:j]); unit {
+		case "w":
+			t = t.AddDate(0, 0, sign*7*n)
+		case "d":
+			t = t.AddDate(0, 0, sign*n)
+		default:
+			d, ok := relativeUnits[unit]
+			if !ok {
+				return time.Time{}, fmt.Errorf("invalid relative time %s: unknown unit %s "+
+					"(expected w, d, h, m, or s)", literal, s[i:j])
+			}
+			t = t.Add(time.Duration(sign*n) * d)
+		}
+
+		if s = s[j:]; s == "" {
+			return t, nil
+		}
+	}
+}
+
 // evaluateMode evaluates a Condition with attribute `mode`.
 func evaluateMode(o *Opts) (bool, error) { return cmpMode(o) }
 
 // evaluateHash evaluates a Condition with attribute `hash`.
 func evaluateHash(o *Opts) (bool, error) { return cmpHash(o) }
+
+// numericSet returns the set of numeric values in values, for use with the IN
+// operator (see transform.SetKey).
+func numericSet(values []string) (map[interface{}]bool, error) {
+	set := make(map[interface{}]bool, len(values))
+	for _, value := range values {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		set[transform.SetKey(n)] = true
+	}
+	return set, nil
+}
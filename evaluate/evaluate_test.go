@@ -1,3 +1,192 @@
 package evaluate
 
-// TODO
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kshvmdn/fsql/tokenizer"
+)
+
+// TODO: Test the remaining evaluate functions.
+
+func TestEvaluate_Between(t *testing.T) {
+	type Expected struct {
+		result bool
+		err    error
+	}
+
+	type Case struct {
+		o        Opts
+		expected Expected
+	}
+
+	info, err := os.Stat("../testdata/baz")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	cases := []Case{
+		{
+			o: Opts{File: info, Attribute: "size", Operator: tokenizer.Between,
+				Value: []interface{}{"0", "10"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "size", Operator: tokenizer.Between,
+				Value: []interface{}{"0", "0"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "size", Operator: tokenizer.Between,
+				Value: []interface{}{"1", "10"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "size", Operator: tokenizer.Between,
+				Value: []interface{}{"0b", "1 kb"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "size", Operator: tokenizer.Between,
+				Value: []interface{}{"0.5kib", "1mb"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "depth", Depth: 3,
+				Operator: tokenizer.Between, Value: []interface{}{"1", "3"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "depth", Depth: 4,
+				Operator: tokenizer.Between, Value: []interface{}{"1", "3"}},
+			expected: Expected{result: false, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "time", Operator: tokenizer.Between,
+				Value: []interface{}{"Jan 01 2000 00 00", "Jan 01 2100 00 00"}},
+			expected: Expected{result: true, err: nil},
+		},
+		{
+			o: Opts{File: info, Attribute: "size", Operator: tokenizer.Between,
+				Value: "10"},
+			expected: Expected{err: &ErrUnsupportedType{"size", "10"}},
+		},
+	}
+
+	for _, c := range cases {
+		result, err := Evaluate(&c.o)
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if result != c.expected.result {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.result, result)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
+func TestEvaluate_ParseTime(t *testing.T) {
+	type Expected struct {
+		time time.Time
+		err  error
+	}
+
+	type Case struct {
+		literal  string
+		expected Expected
+	}
+
+	// Days are calendar days, so a day may be 23 hours long across a daylight
+	// saving time change (early on March 12, 2017 in New York).
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	fixed := time.Date(2017, time.March, 12, 12, 0, 0, 0, loc)
+
+	cases := []Case{
+		{literal: "now", expected: Expected{time: fixed}},
+		{literal: "NOW", expected: Expected{time: fixed}},
+		{literal: "-7d", expected: Expected{time: time.Date(2017, time.March, 5, 12, 0, 0, 0, loc)}},
+		{literal: "-1d", expected: Expected{time: fixed.Add(-23 * time.Hour)}},
+		{literal: "-24h", expected: Expected{time: fixed.Add(-24 * time.Hour)}},
+		{literal: "-1w", expected: Expected{time: time.Date(2017, time.March, 5, 12, 0, 0, 0, loc)}},
+		{literal: "now-1h30m", expected: Expected{time: fixed.Add(-90 * time.Minute)}},
+		{literal: "+10s", expected: Expected{time: fixed.Add(10 * time.Second)}},
+		{literal: "now+1d", expected: Expected{time: time.Date(2017, time.March, 13, 12, 0, 0, 0, loc)}},
+		{literal: "today", expected: Expected{time: time.Date(2017, time.March, 12, 0, 0, 0, 0, loc)}},
+		{literal: "today+9h", expected: Expected{time: time.Date(2017, time.March, 12, 10, 0, 0, 0, loc)}},
+		{literal: "today-1d", expected: Expected{time: time.Date(2017, time.March, 11, 0, 0, 0, 0, loc)}},
+		{
+			literal:  "Apr 01 2017 00 00",
+			expected: Expected{time: time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			literal:  "2023-01-01",
+			expected: Expected{time: time.Date(2023, time.January, 1, 0, 0, 0, 0, loc)},
+		},
+		{
+			literal:  "2020-01-01",
+			expected: Expected{time: time.Date(2020, time.January, 1, 0, 0, 0, 0, loc)},
+		},
+		{
+			literal:  "2023-12-31 23:59",
+			expected: Expected{time: time.Date(2023, time.December, 31, 23, 59, 0, 0, loc)},
+		},
+		{
+			literal:  "2023-06-15T08:30:00",
+			expected: Expected{time: time.Date(2023, time.June, 15, 8, 30, 0, 0, loc)},
+		},
+		{
+			literal:  "2023-06-15T08:30:00Z",
+			expected: Expected{time: time.Date(2023, time.June, 15, 8, 30, 0, 0, time.UTC)},
+		},
+		{
+			literal:  "2023-06-15T08:30:00+02:00",
+			expected: Expected{time: time.Date(2023, time.June, 15, 6, 30, 0, 0, time.UTC)},
+		},
+
+		{
+			literal: "2023-13-01",
+			expected: Expected{err: errors.New("invalid time 2023-13-01: expected e.g. 2006-01-02, " +
+				"2006-01-02T15:04:05Z07:00, Jan 02 2006 15 04, or a relative time")},
+		},
+
+		{
+			literal:  "-7x",
+			expected: Expected{err: errors.New("invalid relative time -7x: unknown unit x (expected w, d, h, m, or s)")},
+		},
+		{
+			literal:  "-d",
+			expected: Expected{err: errors.New("invalid relative time -d: expected a number followed by a unit (w, d, h, m, or s)")},
+		},
+		{
+			literal:  "-7",
+			expected: Expected{err: errors.New("invalid relative time -7: expected a number followed by a unit (w, d, h, m, or s)")},
+		},
+		{
+			literal:  "now7d",
+			expected: Expected{err: errors.New("invalid relative time now7d: expected a sign before the offset")},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseTime(c.literal, fixed)
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("%s\nExpected no error\n     Got %v", c.literal, err)
+			}
+			if !actual.Equal(c.expected.time) {
+				t.Fatalf("%s\nExpected %v\n     Got %v", c.literal, c.expected.time, actual)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.literal, c.expected.err, err)
+		}
+	}
+}
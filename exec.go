@@ -0,0 +1,87 @@
+package fsql
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kshvmdn/fsql/query"
+)
+
+// maxBatchSize is the maximum total length of the paths passed to a single
+// command with Options.ExecBatch, which keeps each command well below the
+// argument size limit of common platforms.
+const maxBatchSize = 128 * 1024
+
+// execRows runs the EXEC command of q for rows, either once per row or, with
+// opts.ExecBatch, for as many rows at once as possible. A command which fails
+// is reported on stderr, and the remaining commands are still run unless
+// opts.ExecAbort is set.
+func execRows(q *query.Query, rows []*row, opts Options) error {
+	var batches [][]string
+	for _, r := range rows {
+		path := execPath(r.path)
+		if opts.ExecBatch && len(batches) > 0 && batchSize(batches[len(batches)-1])+len(path) <= maxBatchSize {
+			batches[len(batches)-1] = append(batches[len(batches)-1], path)
+			continue
+		}
+		batches = append(batches, []string{path})
+	}
+
+	failed := 0
+	for _, paths := range batches {
+		args := execArgs(q.Exec, paths, opts.ExecBatch)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			if opts.ExecAbort {
+				return fmt.Errorf("exec %s: %v", strings.Join(args, " "), err)
+			}
+			fmt.Fprintf(os.Stderr, "exec %s: %v\n", strings.Join(args, " "), err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d command(s) failed", failed, len(batches))
+	}
+	return nil
+}
+
+// execArgs returns the arguments of command for paths. Each argument `{}` is
+// replaced by the paths in a batch, otherwise `{}` is replaced by the (single)
+// path wherever it occurs, e.g. `{}.bak`.
+func execArgs(command, paths []string, batch bool) []string {
+	args := make([]string, 0, len(command)+len(paths))
+	for _, arg := range command {
+		if batch {
+			if arg == "{}" {
+				args = append(args, paths...)
+			} else {
+				args = append(args, arg)
+			}
+			continue
+		}
+		args = append(args, strings.Replace(arg, "{}", paths[0], -1))
+	}
+	return args
+}
+
+// execPath returns path as it's passed to a command. A relative path beginning
+// with a hyphen is prefixed with the current directory, so it's not mistaken
+// for an option.
+func execPath(path string) string {
+	if strings.HasPrefix(path, "-") {
+		return "." + string(filepath.Separator) + path
+	}
+	return path
+}
+
+func batchSize(paths []string) int {
+	size := 0
+	for _, path := range paths {
+		size += len(path) + 1
+	}
+	return size
+}
@@ -1,55 +1,378 @@
 package fsql
 
 import (
-	"bytes"
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/template"
+	"unicode/utf8"
 
 	"github.com/kshvmdn/fsql/parser"
+	"github.com/kshvmdn/fsql/query"
+	"github.com/kshvmdn/fsql/transform"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
+// ErrNoMatches is returned by RunWithOptions when counting the results of a
+// query (see Options.Count), or checking whether it has any (see
+// Options.Quiet), which has none.
+var ErrNoMatches = errors.New("no matching files")
+
+// Options holds the options which control how a query's results are shown.
+type Options struct {
+	// Format is the output format: `table` (the default), `json`, `csv`,
+	// `nul`, `shell`, or `template`.
+	Format string
+
+	// Template is the text/template used to show each result. Setting it
+	// implies the `template` format.
+	Template string
+	template *template.Template
+
+	// Jobs is the number of goroutines used to walk each source (see
+	// query.Query.Jobs).
+	Jobs int
+
+	// FollowSymlinks is true iff symbolic links are followed while walking.
+	FollowSymlinks bool
+
+	// MaxDepth is the maximum depth of the files found in each source (e.g. 1
+	// for only the source's direct children), or 0 if there's no maximum
+	// (the default). Unlike a condition on `depth`, deeper files aren't walked
+	// at all.
+	MaxDepth int
+
+	// SameDevice restricts the walk of each source to the device (i.e.
+	// filesystem) it's on, skipping e.g. mount points, like `find -xdev`.
+	SameDevice bool
+
+	// Absolute shows path-like values (`path` and `FULLPATH(name)`) as
+	// absolute paths (see query.Query.Absolute).
+	Absolute bool
+
+	// ScanBinary searches the contents of binary files for conditions on the
+	// `content` attribute (and counts their `lines` and `words`), which
+	// otherwise never match binary files.
+	ScanBinary bool
+
+	// Verbose shows the error of each file which was skipped (e.g. due to
+	// permissions), instead of only the number of skipped files, and of each
+	// directory which couldn't be read to check if it's empty.
+	Verbose bool
+
+	// Color colors the names (and paths) of directories, symbolic links, and
+	// executable files in the `table` format, like `ls --color`. Other formats
+	// are never colored.
+	Color bool
+
+	// Literal shows the values of the `table` format as is, instead of
+	// escaping their control characters (e.g. `foo\nbar` for a name containing
+	// a newline), which would otherwise break the result across lines. Other
+	// formats never escape values.
+	Literal bool
+
+	// Delimiter is the field delimiter of the `csv` format. Defaults to a
+	// comma.
+	Delimiter rune
+
+	// SizeUnit is the format of each selected `size` without modifiers of its
+	// own, i.e. an argument of `FORMAT(size, ...)` such as `MB` or `HUMAN`, or
+	// empty for a number of bytes (the default).
+	SizeUnit string
+
+	// TimeFormat is the format of each selected time attribute (e.g. `time`)
+	// without modifiers of its own, i.e. an argument of `FORMAT(time, ...)`
+	// such as `ISO` or a custom layout, or empty for the default format.
+	TimeFormat string
+
+	// Explain shows the parsed query (see query.Query.Explain) instead of
+	// running it.
+	Explain bool
+
+	// ExecBatch runs the EXEC command of a query for many results at once,
+	// with the argument `{}` replaced by each of their paths (like `find -exec
+	// ... +`), instead of once per result.
+	ExecBatch bool
+
+	// ExecAbort stops at the first EXEC command which fails, instead of
+	// reporting it and running the remaining commands.
+	ExecAbort bool
+
+	// Max is the maximum number of results to show (or run EXEC for), as a
+	// safety rail for queries which find more files than intended, or 0 if
+	// there's no maximum (the default). Unlike LIMIT, a notice is shown on
+	// stderr if the query has more results. If both are set, the smaller wins.
+	Max int
+
+	// Collate is the language (a BCP 47 tag, e.g. `en` or `de`) whose
+	// collation orders strings in ORDER BY (e.g. so `é` sorts next to `e`, and
+	// `a` next to `A`), or empty to order them byte by byte (the default),
+	// which is faster.
+	Collate string
+
+	// Count shows only the number of results (after LIMIT / OFFSET), instead
+	// of the results themselves. Only supported by the `table` format.
+	Count bool
+
+	// Quiet shows nothing (not even warnings or skipped files), so only the
+	// returned error tells whether the query has any results (ErrNoMatches if
+	// it doesn't), like `grep -q`. The query stops at its first result.
+	Quiet bool
+
+	// Dupes shows only the results which are duplicates of each other (i.e.
+	// regular files with identical contents), with each set of duplicates
+	// grouped together. To avoid hashing every file, only files which are the
+	// same size as another are hashed. Not supported by queries with EXEC,
+	// aggregates, or GROUP BY.
+	Dupes bool
+
+	// Total shows the total size of the results (after LIMIT / OFFSET) on
+	// stderr, e.g. `Total: 1.4 GB across 523 file(s)`, after the results
+	// themselves. Not supported by queries with aggregates or GROUP BY.
+	Total bool
+}
+
+// defaultModifiers returns the modifiers of opts.SizeUnit and opts.TimeFormat
+// (see query.Query.DefaultModifiers), or an error if the size unit is invalid.
+func (opts Options) defaultModifiers() (map[string][]query.Modifier, error) {
+	defaults := make(map[string][]query.Modifier)
+	if opts.SizeUnit != "" {
+		_, err := transform.Format(&transform.FormatParams{
+			Attribute: "size",
+			Value:     int64(0),
+			Name:      "FORMAT",
+			Args:      []string{opts.SizeUnit},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid size unit %s", opts.SizeUnit)
+		}
+		defaults["size"] = []query.Modifier{{Name: "FORMAT", Arguments: []string{opts.SizeUnit}}}
+	}
+	if opts.TimeFormat != "" {
+		for _, attribute := range []string{"time", "accessed", "changed", "created"} {
+			defaults[attribute] = []query.Modifier{
+				{Name: "FORMAT", Arguments: []string{opts.TimeFormat}},
+			}
+		}
+	}
+	return defaults, nil
+}
+
+// ReadQuery reads a query from r (e.g. a file or stdin). Lines starting with
+// `--` (after any indentation) are comments, which are skipped, and leading and
+// trailing whitespace is trimmed.
+func ReadQuery(r io.Reader) (string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if !strings.HasPrefix(strings.TrimSpace(scanner.Text()), "--") {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
 // Run parses the input and executes the resultant query.
 func Run(input string) error {
+	return RunWithOptions(input, Options{})
+}
+
+// RunWithOptions parses the input, executes the resultant query, and writes
+// the results to stdout as specified by opts.
+func RunWithOptions(input string, opts Options) error {
+	if opts.Template != "" {
+		if opts.Format != "" && opts.Format != "template" {
+			return fmt.Errorf("cannot use a template with output format %s", opts.Format)
+		}
+		opts.Format = "template"
+
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(opts.Template)
+		if err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+		opts.template = tmpl
+	} else if opts.Format == "template" {
+		return errors.New("output format template expects a template")
+	}
+
+	write, ok := writers[opts.Format]
+	if !ok {
+		return fmt.Errorf("unknown output format %s", opts.Format)
+	}
+	if opts.Count && opts.Format != "" && opts.Format != "table" {
+		return fmt.Errorf("cannot count results with output format %s", opts.Format)
+	}
+	if opts.MaxDepth < 0 {
+		return fmt.Errorf("invalid max depth %d", opts.MaxDepth)
+	}
+	if opts.Max < 0 {
+		return fmt.Errorf("invalid max %d", opts.Max)
+	}
+	if opts.Delimiter == '"' || opts.Delimiter == '\r' || opts.Delimiter == '\n' ||
+		opts.Delimiter == utf8.RuneError {
+		return fmt.Errorf("invalid delimiter %q", opts.Delimiter)
+	}
+
+	var collator *collate.Collator
+	if opts.Collate != "" {
+		tag, err := language.Parse(opts.Collate)
+		if err != nil {
+			return fmt.Errorf("invalid collation %s", opts.Collate)
+		}
+		collator = collate.New(tag)
+	}
+
+	defaults, err := opts.defaultModifiers()
+	if err != nil {
+		return err
+	}
+
 	q, err := parser.Run(input)
 	if err != nil {
 		return err
 	}
+	q.DefaultModifiers = defaults
+	if !opts.Quiet {
+		for _, warning := range q.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+	if opts.Explain {
+		return q.Explain(os.Stdout)
+	}
+	if opts.Count && len(q.Exec) > 0 {
+		return errors.New("cannot count results of a query with EXEC")
+	}
+	if opts.Quiet && len(q.Exec) > 0 {
+		return errors.New("cannot run a query with EXEC in quiet mode")
+	}
+	if opts.Quiet && (opts.Count || opts.Total) {
+		return errors.New("cannot count or total results in quiet mode")
+	}
+	if opts.Dupes && len(q.Exec) > 0 {
+		return errors.New("cannot find duplicates of a query with EXEC")
+	}
+	if opts.Dupes && (len(q.Aggregates) > 0 || len(q.GroupBy) > 0) {
+		return errors.New("cannot find duplicates of a grouped query")
+	}
+	if opts.Total && (len(q.Aggregates) > 0 || len(q.GroupBy) > 0) {
+		return errors.New("cannot total results of a grouped query")
+	}
+	if (opts.Format == "nul" || opts.Format == "shell") && len(q.Attributes) != 1 && len(q.Exec) == 0 {
+		return fmt.Errorf("output format %s expects a single attribute, got %d",
+			opts.Format, len(q.Attributes))
+	}
+
+	q.Jobs = opts.Jobs
+	q.FollowSymlinks = opts.FollowSymlinks
+	q.ScanBinary = opts.ScanBinary
+	q.SameDevice = opts.SameDevice
+	q.MaxDepth = opts.MaxDepth
+	q.Absolute = opts.Absolute
+	q.Collator = collator
+
+	// Only the first result is needed to know whether there are any, in
+	// whichever order. Duplicates are only known once every file is found.
+	if opts.Quiet && !opts.Dupes {
+		q.OrderBy = nil
+		if q.Limit != 0 {
+			q.Limit = 1
+		}
+	}
 
-	// Find length of the longest name to normalize name output.
-	var max = 0
-	var results = make([]map[string]interface{}, 0)
+	// One more result than the maximum is found, so we know whether there are
+	// more results than shown.
+	if opts.Max > 0 && !opts.Dupes && (q.Limit < 0 || q.Limit > opts.Max) {
+		q.Limit = opts.Max + 1
+	}
 
+	rows := make([]*row, 0)
 	err = q.Execute(
 		func(path string, info os.FileInfo, result map[string]interface{}) {
-			results = append(results, result)
-			if !q.HasAttribute("name") {
-				return
-			}
-			if s, ok := result["name"].(string); ok && len(s) > max {
-				max = len(s)
-			}
+			rows = append(rows, &row{path: path, info: info, values: result})
 		},
 	)
 	if err != nil {
 		return err
 	}
-
-	for _, result := range results {
-		var buf bytes.Buffer
-		for j, attribute := range q.Attributes {
-			// If the current attribute is "name", pad the output string by `max`
-			// spaces.
-			format := "%v"
-			if attribute == "name" {
-				format = fmt.Sprintf("%%-%ds", max)
-			}
-			buf.WriteString(fmt.Sprintf(format, result[attribute]))
-			if j != len(q.Attributes)-1 {
-				buf.WriteString("\t")
-			}
+	groups := [][]*row{rows}
+	if opts.Dupes {
+		if groups, err = dupeGroups(rows); err != nil {
+			return err
+		}
+		rows = flatten(groups)
+	}
+	if opts.Quiet {
+		if len(rows) == 0 {
+			return ErrNoMatches
 		}
-		fmt.Printf("%s\n", buf.String())
+		return nil
+	}
+	stopped := opts.Max > 0 && len(rows) > opts.Max
+	if stopped {
+		rows = rows[:opts.Max]
+		groups = truncateGroups(groups, opts.Max)
+	}
+
+	if len(q.Exec) > 0 {
+		err = execRows(q, rows, opts)
+	} else if opts.Count {
+		fmt.Fprintln(os.Stdout, len(rows))
+	} else {
+		err = writeGroups(os.Stdout, q, groups, write, opts)
+	}
+	if err != nil {
+		return err
 	}
 
+	if opts.Total {
+		if err := writeTotal(os.Stderr, rows); err != nil {
+			return err
+		}
+	}
+	if opts.Verbose {
+		for _, err := range q.Skipped {
+			fmt.Fprintf(os.Stderr, "skipped: %v\n", err)
+		}
+		for _, err := range q.Unreadable {
+			fmt.Fprintf(os.Stderr, "not empty (unreadable): %v\n", err)
+		}
+	}
+	if len(q.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d unreadable file(s)\n", len(q.Skipped))
+	}
+	if stopped {
+		fmt.Fprintf(os.Stderr, "... (stopped at %d results; use -max 0 for all)\n", opts.Max)
+	}
+	if opts.Count && len(rows) == 0 {
+		return ErrNoMatches
+	}
 	return nil
 }
+
+// writeTotal writes the total size of the files of rows to w, in the format
+// of `FORMAT(size, HUMAN)`.
+func writeTotal(w io.Writer, rows []*row) error {
+	var total int64
+	for _, row := range rows {
+		total += row.info.Size()
+	}
+	size, err := transform.Format(&transform.FormatParams{
+		Attribute: "size",
+		Name:      "FORMAT",
+		Args:      []string{"HUMAN"},
+		Value:     total,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Total: %v across %d file(s)\n", size, len(rows))
+	return err
+}
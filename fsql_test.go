@@ -1,18 +1,26 @@
 package fsql
 
 import (
+	"archive/zip"
 	"bytes"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/kshvmdn/fsql/transform"
 )
 
 var files = map[string]*os.FileInfo{}
@@ -175,6 +183,50 @@ func TestRun_Name(t *testing.T) {
 			query:    "SELECT FULLPATH(name) FROM ./testdata WHERE name REGEXP ^b.*",
 			expected: "testdata/bar\ntestdata/baz\n",
 		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name =~ '^qu.[xz]$'",
+			expected: "quux\nquuz\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name ~ 'qu?[xz]'",
+			expected: "quux\nquuz\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name GLOB 'g*' AND NOT name GLOB '*y'",
+			expected: "grault\n",
+		},
+		{
+			query:    "SELECT path FROM ./testdata WHERE path ~ 'foo/*/*'",
+			expected: "foo/quuz/fred\nfoo/quuz/waldo\n",
+		},
+		{
+			query:    "SELECT REPLACE(UPPER(name), U, '-') FROM ./testdata/foo WHERE name LIKE qu%",
+			expected: "Q--X\nQ--Z\nQ-X \n",
+		},
+		{
+			query:    "SELECT LENGTH(name) FROM ./testdata/foo WHERE name LIKE qu%",
+			expected: "4\n4\n3\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE LENGTH(name) = 4",
+			expected: "quux\nquuz\nfred\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE LEN(UPPER(name)) BETWEEN 6 AND 7 AND NOT LENGTH(name) IN (8)",
+			expected: "garply\ngrault\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE LOWER(TRIM(name)) = ' FOO '",
+			expected: "foo\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name =i 'FOO' OR name =i Bar",
+			expected: "bar\nfoo\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = limit OR name IN (exec, foo) LIMIT 1",
+			expected: "foo\n",
+		},
 		{
 			query: "SELECT UPPER(FULLPATH(name)) FROM ./testdata WHERE mode IS DIR",
 			expected: fmt.Sprintf(
@@ -223,7 +275,7 @@ func TestRun_Size(t *testing.T) {
 			expected: fmt.Sprintf("%s\n", GetAttrs("foo", "size:gb")[0]),
 		},
 		{
-			query: "SELECT size FROM ./testdata WHERE name LIKE qu",
+			query: "SELECT size FROM ./testdata WHERE name LIKE qu%",
 			expected: fmt.Sprintf(
 				strings.Repeat("%s\n", 3),
 				GetAttrs("foo/quux", "size")[0],
@@ -315,8 +367,20 @@ func TestRun_Hash(t *testing.T) {
 		expected string
 	}
 
-	// TODO
-	cases := []Case{}
+	cases := []Case{
+		{
+			query:    "SELECT name, hash FROM ./testdata WHERE name = baz",
+			expected: "baz\tda39a3e\n",
+		},
+		{
+			query:    "SELECT name, HASH(hash, md5, full) FROM ./testdata WHERE name = baz",
+			expected: "baz\td41d8cd98f00b204e9800998ecf8427e\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE SHA256(hash) = e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855 AND name LIKE b%",
+			expected: "baz\n",
+		},
+	}
 
 	for _, c := range cases {
 		actual := DoRun(c.query)
@@ -326,86 +390,2198 @@ func TestRun_Hash(t *testing.T) {
 	}
 }
 
-func GetAttrs(path string, attrs ...string) []string {
-	// If the files map is empty, walk ./testdata and populate it.
-	if len(files) == 0 {
-		if err := filepath.Walk(
-			"./testdata",
-			func(path string, info os.FileInfo, err error) error {
-				files[filepath.Clean(path)] = &info
-				return nil
-			},
-		); err != nil {
-			return []string{}
+func TestRun_Extension(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name, ext FROM ./testdata WHERE name = baz",
+			expected: "baz\t\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo/quuz WHERE name = .gitkeep AND NOT extension = gitkeep",
+			expected: ".gitkeep\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
 		}
 	}
+}
 
-	path = filepath.Clean(fmt.Sprintf("testdata/%s", path))
-	file, ok := files[path]
-	if !ok {
-		return []string{}
+func TestRun_Depth(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
 	}
 
-	result := make([]string, len(attrs))
-	for i, attr := range attrs {
-		// Hard-coding modifiers works for the time being, but we might need a more
-		// elegant solution when we introduce new modifiers in the future.
-		switch attr {
-		case "hash":
-			b, err := ioutil.ReadFile(path)
-			if err != nil {
-				return []string{}
-			}
-			h := sha1.New()
-			if _, err := h.Write(b); err != nil {
-				return []string{}
-			}
-			result[i] = hex.EncodeToString(h.Sum(nil))[:7]
-		case "size":
-			result[i] = fmt.Sprintf("%d", (*file).Size())
-		case "size:kb", "size:mb", "size:gb":
-			size := (*file).Size()
-			switch attr[len(attr)-2:] {
-			case "kb":
-				result[i] = fmt.Sprintf("%fkb", float64(size)/(1<<10))
-			case "mb":
-				result[i] = fmt.Sprintf("%fmb", float64(size)/(1<<20))
-			case "gb":
-				result[i] = fmt.Sprintf("%fgb", float64(size)/(1<<30))
-			}
-		case "time":
-			result[i] = (*file).ModTime().Format(time.Stamp)
-		case "time:iso":
-			result[i] = (*file).ModTime().Format(time.RFC3339)
-		case "time:year":
-			result[i] = (*file).ModTime().Format("2006")
+	cases := []Case{
+		{
+			query:    "SELECT name, depth FROM ./testdata WHERE depth = 1",
+			expected: "bar\t1\nbaz\t1\nfoo\t1\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE depth > 2",
+			expected: ".gitkeep\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
 		}
 	}
-	return result
 }
 
-// DoRun executes fsql.Run and returns the output.
-func DoRun(query string) string {
-	stdout := os.Stdout
-	ch := make(chan string)
+func TestRun_Path(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
 
-	r, w, err := os.Pipe()
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT path FROM ./testdata/foo WHERE depth < 2",
+			expected: ".\nquux\nquuz\nqux\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE path = foo/quuz/fred",
+			expected: "fred\n",
+		},
+		{
+			query:    "SELECT abspath FROM ./testdata/foo WHERE name = qux",
+			expected: filepath.Join(wd, "testdata", "foo", "qux") + "\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE abspath LIKE %/foo/quuz",
+			expected: "quuz\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Absolute(t *testing.T) {
+	wd, err := os.Getwd()
 	if err != nil {
-		return ""
+		t.Fatalf("\nExpected no error\n     Got %v", err)
 	}
-	os.Stdout = w
+	foo := filepath.Join(wd, "testdata", "foo")
 
-	if err := Run(query); err != nil {
-		return ""
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT path FROM ./testdata/foo WHERE depth < 2",
+			expected: strings.Join([]string{foo, filepath.Join(foo, "quux"), filepath.Join(foo, "quuz"), filepath.Join(foo, "qux")}, "\n") + "\n",
+		},
+		{
+			query:    "SELECT FULLPATH(name), size FROM ./testdata/bar/.. WHERE name = qux",
+			expected: filepath.Join(foo, "qux") + "\t0\n",
+		},
+		{
+			query:    "SELECT UPPER(FULLPATH(name)) FROM ./testdata/foo WHERE name = quux",
+			expected: strings.ToUpper(filepath.Join(foo, "quux")) + "\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE path = quuz",
+			expected: "quuz\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Absolute: true})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Inode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inodes aren't available on windows")
+	}
+
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE inode > 0",
+			expected: "16\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE nlink = 1 AND NOT mode IS dir",
+			expected: "quux    \n.gitkeep\nwaldo   \nqux     \n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata/foo WHERE inode IN (SELECT inode FROM ./testdata/foo/quuz)",
+			expected: "4\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Device(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("device ids aren't available on windows")
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE device IN (SELECT device FROM ./testdata/foo/quuz)",
+			expected: "16\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE device <> 0",
+			opts:     Options{SameDevice: true},
+			expected: "16\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata",
+			opts:     Options{SameDevice: true, Jobs: 4},
+			expected: "16\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_MaxDepth(t *testing.T) {
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+		err      error
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata",
+			opts:     Options{MaxDepth: 1},
+			expected: "testdata\nbar     \nbaz     \nfoo     \n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE mode IS dir",
+			opts:     Options{MaxDepth: 2},
+			expected: "5\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE mode IS dir",
+			opts:     Options{MaxDepth: 2, Jobs: 4},
+			expected: "5\n",
+		},
+		{
+			query:    "SELECT name FROM (SELECT name FROM ./testdata/foo) WHERE mode IS dir",
+			opts:     Options{MaxDepth: 1},
+			expected: "foo \nquuz\n",
+		},
+		{
+			query: "SELECT name FROM ./testdata",
+			opts:  Options{MaxDepth: -1},
+			err:   errors.New("invalid max depth -1"),
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := DoRunWithError(c.query, c.opts)
+		if !reflect.DeepEqual(c.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.err, err)
+		}
+		if c.err == nil && !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_FileType(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE is_dir",
+			expected: "foo \nquuz\nfred\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE is_file = true AND NOT is_hidden",
+			expected: "quux \nwaldo\nqux  \n",
+		},
+		{
+			query:    "SELECT name, is_dir, is_file FROM ./testdata/foo/quuz",
+			expected: "quuz    \ttrue\tfalse\nfred    \ttrue\tfalse\n.gitkeep\tfalse\ttrue\nwaldo   \tfalse\ttrue\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE NOT (is_dir OR is_file)",
+			expected: "0\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE is_dir = false AND is_file <> false",
+			expected: "8\n",
+		},
 	}
 
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Executable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("execute bits aren't available on windows")
+	}
+
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata WHERE is_executable AND NOT is_dir",
+			expected: "baz\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE is_executable = false",
+			expected: "7\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_OrderBy(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth = 1 ORDER BY name DESC",
+			expected: "foo\nbaz\nbar\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE depth > 0 ORDER BY depth DESC, name",
+			expected: ".gitkeep\nfred    \nwaldo   \nquux    \nquuz    \nqux     \n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Sources(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata/foo, ./testdata/bar WHERE depth = 1",
+			expected: "quux  \nquuz  \nqux   \ncorge \ngarply\ngrault\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo, ./testdata, testdata/foo/ WHERE depth = 1",
+			expected: "quux\nquuz\nqux \nbar \nbaz \n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata, ./testdata/foo",
+			expected: "16\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/bar, ./testdata/foo WHERE depth = 1 ORDER BY name LIMIT 3",
+			expected: "corge \ngarply\ngrault\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Glob(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata/*/qu* WHERE depth = 0",
+			expected: "quux\nquuz\nqux \n",
+		},
+		{
+			query:    "SELECT FULLPATH(name) FROM './testdata/**/.gitkeep'",
+			expected: "testdata/bar/garply/xyzzy/thud/.gitkeep\ntestdata/foo/quuz/fred/.gitkeep        \n",
+		},
+		{
+			query:    "SELECT name FROM './testdata/[bf]a*'",
+			expected: "bar     \ncorge   \ngarply  \nxyzzy   \nthud    \n.gitkeep\ngrault  \nbaz     \n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Archive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "backup.zip"))
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	w := zip.NewWriter(f)
+	for _, entry := range []struct{ name, contents string }{
+		{"logs/a.log", "foo\nbar\n"},
+		{"logs/old/b.log", "baz\n"},
+		{"readme.txt", "foo\n"},
+	} {
+		fw, err := w.Create(entry.name)
+		if err == nil {
+			_, err = io.WriteString(fw, entry.contents)
+		}
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	f.Close()
+
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	from := fmt.Sprintf("FROM '%s'", filepath.Join(dir, "backup.zip"))
+	cases := []Case{
+		{
+			query:    "SELECT path, size, lines, depth " + from + " WHERE extension = log",
+			expected: "logs/a.log\t8\t2\t2\nlogs/old/b.log\t4\t1\t3\n",
+		},
+		{
+			query:    "SELECT path, is_dir " + from + " WHERE depth = 1",
+			expected: "logs\ttrue\nreadme.txt\tfalse\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE content = foo",
+			expected: "a.log     \nreadme.txt\n",
+		},
+		{
+			query:    "SELECT COUNT(*), SUM(size) " + from + " WHERE is_file AND depth > 0",
+			expected: "3\t16\n",
+		},
+		{
+			// Entries of an archive don't have extended attributes.
+			query:    "SELECT name, XATTR(path, user.tag) " + from + " WHERE NOT has_xattr AND depth = 1",
+			expected: "logs      \t\nreadme.txt\t\n",
+		},
+		{
+			query:    "SELECT COUNT(*) " + from + " WHERE has_xattr AND depth > 0",
+			expected: "0\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Exclude(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata EXCLUDE (bar, 'qu_z') WHERE depth > 0",
+			expected: "baz \nfoo \nquux\nqux \n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo EXCLUDE f% WHERE depth < 2",
+			expected: "foo \nquux\nquuz\nqux \n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata EXCLUDE (%)",
+			expected: "2\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_SourceQuery(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM (SELECT name FROM ./testdata/foo WHERE depth = 1) WHERE name LIKE qu%",
+			expected: "quux\nquuz\nqux \n",
+		},
+		{
+			query:    "SELECT name, depth FROM (SELECT size FROM ./testdata ORDER BY name DESC LIMIT 3)",
+			expected: "xyzzy\t3\nwaldo\t3\nthud \t4\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM (SELECT name FROM ./testdata WHERE mode IS DIR)",
+			expected: "8\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Precedence(t *testing.T) {
+	type Case struct {
+		condition string
+		expected  bool
+	}
+
+	// T and F are conditions that are always true and false respectively for
+	// the single file ./testdata/baz.
+	const (
+		T = "name = baz"
+		F = "name = qux"
+	)
+
+	cases := []Case{
+		{condition: T + " AND " + T, expected: true},
+		{condition: T + " AND " + F, expected: false},
+		{condition: F + " OR " + T, expected: true},
+		{condition: F + " OR " + F, expected: false},
+		{condition: T + " OR " + F + " AND " + F, expected: true},
+		{condition: F + " AND " + F + " OR " + T, expected: true},
+		{condition: F + " OR " + T + " AND " + F, expected: false},
+		{condition: T + " AND " + F + " OR " + F + " AND " + T, expected: false},
+		{condition: F + " AND " + T + " OR " + T + " AND " + T, expected: true},
+		{condition: "(" + T + " OR " + F + ") AND " + F, expected: false},
+		{condition: F + " AND (" + F + " OR " + T + ")", expected: false},
+		{condition: "(" + F + " OR " + T + ") AND (" + T + " OR " + F + ")", expected: true},
+		{condition: "((" + F + " OR " + T + ") AND " + T + ") OR " + F, expected: true},
+		{condition: "NOT " + T + " OR " + T + " AND " + F, expected: false},
+		{condition: "NOT " + F + " AND " + T + " OR " + F, expected: true},
+		{condition: T + " OR NOT " + T + " AND NOT " + F, expected: true},
+		{condition: F + " OR NOT " + T + " AND NOT " + F, expected: false},
+		{condition: "NOT (" + T + " OR " + F + ")", expected: false},
+		{condition: "NOT (" + F + " AND " + T + ") AND " + T, expected: true},
+		{condition: "NOT (" + F + ") OR " + F, expected: true},
+		{condition: "NOT NOT " + T, expected: true},
+		{condition: "NOT NOT NOT " + T, expected: false},
+		{condition: "NOT NOT (" + F + " OR " + F + ")", expected: false},
+		{condition: "NOT (NOT (" + T + " AND " + T + "))", expected: true},
+		{condition: "is_empty", expected: true},
+		{condition: "NOT is_hidden AND is_empty", expected: true},
+		{condition: "NOT is_empty OR " + F, expected: false},
+	}
+
+	for _, c := range cases {
+		expected := "0\n"
+		if c.expected {
+			expected = "1\n"
+		}
+		actual := DoRun("SELECT COUNT(*) FROM ./testdata/baz WHERE " + c.condition)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.condition, expected, actual)
+		}
+	}
+}
+
+func TestRun_Limit(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth = 1 LIMIT 2",
+			expected: "bar\nbaz\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth = 1 LIMIT 1 OFFSET 1",
+			expected: "baz\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth = 1 ORDER BY name DESC LIMIT 2",
+			expected: "foo\nbaz\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata LIMIT 0",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Distinct(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT DISTINCT depth FROM ./testdata ORDER BY depth",
+			expected: "0\n1\n2\n3\n4\n5\n",
+		},
+		{
+			query:    "SELECT DISTINCT depth FROM ./testdata ORDER BY depth DESC LIMIT 2",
+			expected: "5\n4\n",
+		},
+		{
+			query:    "SELECT DISTINCT name FROM ./testdata WHERE name = .gitkeep",
+			expected: ".gitkeep\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Aggregate(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata",
+			expected: "16\n",
+		},
+		{
+			query:    "SELECT COUNT(*), SUM(size), MIN(depth), MAX(depth) FROM ./testdata WHERE name = .gitkeep",
+			expected: "2\t0\t4\t5\n",
+		},
+		{
+			query:    "SELECT AVG(depth) FROM ./testdata WHERE depth = 1",
+			expected: "1\n",
+		},
+		{
+			query:    "SELECT COUNT(*), AVG(size) FROM ./testdata WHERE name = nope",
+			expected: "0\t\n",
+		},
+		{
+			query:    "SELECT SUM(LENGTH(name)) FROM ./testdata WHERE depth = 1",
+			expected: "9\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_GroupBy(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT depth, COUNT(*) FROM ./testdata GROUP BY depth",
+			expected: "0\t1\n1\t3\n2\t6\n3\t3\n4\t2\n5\t1\n",
+		},
+		{
+			query:    "SELECT depth, COUNT(*) FROM ./testdata GROUP BY depth ORDER BY COUNT(*) DESC, depth LIMIT 3",
+			expected: "2\t6\n1\t3\n3\t3\n",
+		},
+		{
+			query:    "SELECT UPPER(name), COUNT(*) FROM ./testdata WHERE name LIKE %keep GROUP BY UPPER(name)",
+			expected: ".GITKEEP\t2\n",
+		},
+		{
+			query:    "SELECT depth, COUNT(*) FROM ./testdata WHERE name = nope GROUP BY depth",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Having(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+		err      error
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT depth, COUNT(*) FROM ./testdata GROUP BY depth HAVING COUNT(*) >= 3",
+			expected: "1\t3\n2\t6\n3\t3\n",
+		},
+		{
+			query:    "SELECT depth FROM ./testdata GROUP BY depth HAVING COUNT(*) > 3",
+			expected: "2\n",
+		},
+		{
+			query: "SELECT depth, COUNT(*) AS n FROM ./testdata GROUP BY depth " +
+				"HAVING n < 3 OR depth = 2 ORDER BY n DESC, depth LIMIT 3",
+			expected: "2\t6\n4\t2\n0\t1\n",
+		},
+		{
+			query:    "SELECT is_dir, COUNT(*) FROM ./testdata GROUP BY is_dir HAVING SUM(size) > 0 kb",
+			expected: "true\t8\n",
+		},
+		{
+			query:    "SELECT depth FROM ./testdata GROUP BY depth HAVING NOT COUNT(*) BETWEEN 2 AND 3",
+			expected: "0\n2\n5\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE name = nope HAVING AVG(size) > 0",
+			expected: "",
+		},
+		{
+			query: "SELECT COUNT(*) FROM ./testdata HAVING COUNT(*) > many",
+			err:   errors.New("invalid value many for COUNT(*): expected a number"),
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := DoRunWithError(c.query, Options{})
+		if !reflect.DeepEqual(c.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.query, c.err, err)
+		}
+		if c.err == nil && !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestRun_In(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	// kb returns the size of the file at path in kilobytes, as FORMAT(size, KB)
+	// would parse it.
+	kb := func(path string) string {
+		size, _ := strconv.ParseFloat(GetAttrs(path, "size")[0], 64)
+		return strconv.FormatFloat(size/1e3, 'f', -1, 64)
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata WHERE name IN (quux, 'qux', nope)",
+			expected: "quux\nqux \n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth IN (1, 4) AND name NOT IN (bar, foo)",
+			expected: "thud    \nbaz     \n.gitkeep\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE size IN (0) AND NOT depth IN (3)",
+			expected: "quux \nwaldo\nqux  \n",
+		},
+		{
+			query: fmt.Sprintf("SELECT name FROM ./testdata/foo WHERE FORMAT(size, KB) IN (0, %s) "+
+				"AND depth = 1", kb("foo/quuz")),
+			expected: "quux\nquuz\nqux \n",
+		},
+		{
+			query: fmt.Sprintf("SELECT name FROM ./testdata/foo WHERE FORMAT(size, KB) IN (%s, %s, %s)",
+				kb("foo"), kb("foo/quuz"), kb("foo/quuz/fred")),
+			expected: "foo \nquuz\nfred\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name IN (from, bar, where)",
+			expected: "bar\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name IN (select)",
+			expected: "",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth IN (1.9)",
+			expected: "",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE FORMAT(size, KB) IN (0.0005)",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Between(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "report")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	accessed := time.Date(2019, time.June, 1, 12, 0, 0, 0, time.Local)
+	modified := time.Date(2023, time.June, 15, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(name, accessed, modified); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	from := fmt.Sprintf("FROM '%s'", dir)
+	cases := []Case{
+		{
+			query:    "SELECT name " + from + " WHERE time BETWEEN '2023-01-01' AND '2023-12-31'",
+			expected: "report\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE time BETWEEN '2023-06-15T11:59:00' AND '2023-06-15 12:01'",
+			expected: "report\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE accessed < '2020-01-01'",
+			expected: "report\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE time < '" + modified.Add(time.Second).Format(time.RFC3339) + "'",
+			expected: "report\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth BETWEEN 2 AND 3 AND name LIKE q%",
+			expected: "quux\nquuz\nqux \n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth NOT BETWEEN 1 AND 4",
+			expected: "testdata\n.gitkeep\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth = 1 AND FORMAT(size, KB) BETWEEN 0 AND 1",
+			expected: "baz\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	info, err := os.Stat("./testdata/baz")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	cases := []Case{
+		{
+			query: "SELECT name, size, mode FROM ./testdata WHERE depth = 1",
+			expected: "[\n" +
+				fmt.Sprintf(`  {"name": "bar", "size": %s, "mode": "drwxr-xr-x"},`, GetAttrs("bar", "size")[0]) + "\n" +
+				`  {"name": "baz", "size": 0, "mode": "-rwxr-xr-x"},` + "\n" +
+				fmt.Sprintf(`  {"name": "foo", "size": %s, "mode": "drwxr-xr-x"}`, GetAttrs("foo", "size")[0]) + "\n" +
+				"]\n",
+		},
+		{
+			query: "SELECT time, UPPER(name) FROM ./testdata WHERE name = baz",
+			expected: "[\n" +
+				fmt.Sprintf(`  {"time": "%s", "name": "BAZ"}`, info.ModTime().Format(time.RFC3339)) + "\n" +
+				"]\n",
+		},
+		{
+			query: "SELECT name, all FROM ./testdata WHERE name = baz",
+			expected: "[\n" +
+				fmt.Sprintf(`  {"name": "baz", "mode": "-rwxr-xr-x", "size": 0, "time": "%s", "hash": "da39a3e"}`,
+					info.ModTime().Format(time.RFC3339)) + "\n" +
+				"]\n",
+		},
+		{
+			query: "SELECT name, FORMAT(size, AUTO) AS size FROM ./testdata WHERE depth = 1 AND is_dir",
+			expected: "[\n" +
+				fmt.Sprintf(`  {"name": "bar", "size": %s},`, GetAttrs("bar", "size:auto")[0]) + "\n" +
+				fmt.Sprintf(`  {"name": "foo", "size": %s}`, GetAttrs("foo", "size:auto")[0]) + "\n" +
+				"]\n",
+		},
+		{
+			query: "SELECT COUNT(*), AVG(size) FROM ./testdata WHERE name = nonexistent",
+			expected: "[\n" +
+				`  {"COUNT(*)": 0, "AVG(size)": null}` + "\n" +
+				"]\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = nonexistent",
+			expected: "[]\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Format: "json"})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+
+	expected := errors.New("unknown output format xml")
+	if err := RunWithOptions("SELECT name", Options{Format: "xml"}); !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+}
+
+func TestRun_CSV(t *testing.T) {
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query: "SELECT name, size FROM ./testdata WHERE depth = 1",
+			opts:  Options{Format: "csv"},
+			expected: fmt.Sprintf("name,size\nbar,%s\nbaz,0\nfoo,%s\n",
+				GetAttrs("bar", "size")[0], GetAttrs("foo", "size")[0]),
+		},
+		{
+			query:    "SELECT REPLACE(name, u, '\", ') FROM ./testdata/foo WHERE depth = 1",
+			opts:     Options{Format: "csv"},
+			expected: "name\n\"q\"\", \"\", x\"\n\"q\"\", \"\", z\"\n\"q\"\", x\"\n",
+		},
+		{
+			query:    "SELECT COUNT(*), AVG(size) FROM ./testdata WHERE name = nonexistent",
+			opts:     Options{Format: "csv", Delimiter: '\t'},
+			expected: "COUNT(*)\tAVG(size)\n0\t\n",
+		},
+		{
+			query:    "SELECT name, size FROM ./testdata WHERE name = nonexistent",
+			opts:     Options{Format: "csv", Delimiter: ';'},
+			expected: "name;size\n",
+		},
+		{
+			query: "SELECT * FROM ./testdata WHERE name = nonexistent",
+			opts:  Options{Format: "csv"},
+			expected: "name,extension,size,depth,mode,time,accessed,changed,created,hash," +
+				"owner,group,uid,gid,inode,nlink,device,is_dir,is_file,is_symlink," +
+				"is_hidden,is_empty,is_executable,has_xattr,symlink_target,path," +
+				"abspath,lines,words,mime,kind\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+
+	expected := errors.New("invalid delimiter '\"'")
+	err := RunWithOptions("SELECT name", Options{Format: "csv", Delimiter: '"'})
+	if !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+}
+
+func TestRun_NUL(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT FULLPATH(name) FROM ./testdata/foo WHERE depth = 1",
+			expected: "testdata/foo/quux\x00testdata/foo/quuz\x00testdata/foo/qux\x00",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name LIKE %a% ORDER BY name LIMIT 2",
+			expected: "bar\x00baz\x00",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = nonexistent",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Format: "nul"})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%q\nGot:\n%q", c.expected, actual)
+		}
+	}
+
+	expected := errors.New("output format nul expects a single attribute, got 2")
+	err := RunWithOptions("SELECT name, size FROM ./testdata", Options{Format: "nul"})
+	if !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+}
+
+func TestRun_Shell(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	names := []string{"*.go", "foo bar", "it's", "qux\nquux", "$HOME", "plain-name.txt"}
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	from := fmt.Sprintf("FROM '%s'", dir)
+	cases := []Case{
+		{
+			query:    "SELECT name " + from + " WHERE depth = 1 ORDER BY name",
+			expected: "'$HOME'\n'*.go'\n'foo bar'\n'it'\\''s'\nplain-name.txt\n'qux\nquux'\n",
+		},
+		{
+			query:    "SELECT FULLPATH(name) FROM ./testdata/foo WHERE depth = 1",
+			expected: "testdata/foo/quux\ntestdata/foo/quuz\ntestdata/foo/qux\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = nonexistent",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Format: "shell"})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%q\nGot:\n%q", c.query, c.expected, actual)
+		}
+	}
+
+	// Each quoted name is a single word of the shell, as is.
+	if _, err := exec.LookPath("bash"); err == nil {
+		output := DoRunWithOptions("SELECT name "+from+" WHERE depth = 1 ORDER BY name",
+			Options{Format: "shell"})
+		words, err := exec.Command("bash", "-c", `eval "files=($1)"; printf '[%s]' "${files[@]}"`,
+			"bash", output).Output()
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		expected := "[$HOME][*.go][foo bar][it's][plain-name.txt][qux\nquux]"
+		if string(words) != expected {
+			t.Fatalf("\nExpected:\n%q\nGot:\n%q", expected, string(words))
+		}
+	}
+
+	expected := errors.New("output format shell expects a single attribute, got 2")
+	err = RunWithOptions("SELECT name, size FROM ./testdata", Options{Format: "shell"})
+	if !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+}
+
+func TestRun_Escape(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"foo\nbar", "baz\tqux", "a\x1bb"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+	}
+
+	from := fmt.Sprintf("FROM '%s'", dir)
+	cases := []Case{
+		{
+			query:    "SELECT name " + from + " WHERE depth = 1 ORDER BY name",
+			expected: "a\\x1bb  \nbaz\\tqux\nfoo\\nbar\n",
+		},
+		{
+			query:    "SELECT name, size " + from + " WHERE name LIKE foo% ORDER BY name",
+			expected: "foo\\nbar\t0\n",
+		},
+		{
+			query:    "SELECT path " + from + " WHERE name LIKE baz% ORDER BY name",
+			expected: "baz\\tqux\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE name LIKE foo% ORDER BY name",
+			opts:     Options{Literal: true},
+			expected: "foo\nbar\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE name LIKE foo% ORDER BY name",
+			opts:     Options{Format: "json"},
+			expected: "[\n  {\"name\": \"foo\\nbar\"}\n]\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%q\nGot:\n%q", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Template(t *testing.T) {
+	type Case struct {
+		query    string
+		template string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name, size FROM ./testdata/foo WHERE depth = 1",
+			template: "{{.name}} ({{.size}})",
+			expected: fmt.Sprintf("quux (0)\nquuz (%s)\nqux (0)\n", GetAttrs("foo/quuz", "size")[0]),
+		},
+		{
+			query:    "SELECT UPPER(name), COUNT(*) FROM ./testdata GROUP BY name ORDER BY COUNT(*) DESC LIMIT 1",
+			template: `{{index . "COUNT(*)"}}x {{.name}}`,
+			expected: "2x .GITKEEP\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = nonexistent",
+			template: "{{.name}}",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Template: c.template})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+
+	type ErrorCase struct {
+		opts     Options
+		expected error
+	}
+
+	errorCases := []ErrorCase{
+		{
+			opts:     Options{Template: "{{.name"},
+			expected: errors.New("invalid template: template: output:1: unclosed action"),
+		},
+		{
+			opts:     Options{Format: "json", Template: "{{.name}}"},
+			expected: errors.New("cannot use a template with output format json"),
+		},
+		{
+			opts:     Options{Format: "template"},
+			expected: errors.New("output format template expects a template"),
+		},
+	}
+
+	for _, c := range errorCases {
+		err := RunWithOptions("SELECT name FROM ./testdata", c.opts)
+		if !reflect.DeepEqual(c.expected, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, err)
+		}
+	}
+}
+
+func TestRun_Alias(t *testing.T) {
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query: "SELECT name AS filename, size AS bytes FROM ./testdata/foo WHERE depth = 1 ORDER BY filename DESC",
+			opts:  Options{Format: "json"},
+			expected: fmt.Sprintf("[\n  {\"filename\": \"qux\", \"bytes\": 0},\n  {\"filename\": \"quuz\", \"bytes\": %s},\n  {\"filename\": \"quux\", \"bytes\": 0}\n]\n",
+				GetAttrs("foo/quuz", "size")[0]),
+		},
+		{
+			query:    "SELECT name AS filename, size FROM ./testdata/foo WHERE depth = 1",
+			opts:     Options{Format: "csv"},
+			expected: fmt.Sprintf("filename,size\nquux,0\nquuz,%s\nqux,0\n", GetAttrs("foo/quuz", "size")[0]),
+		},
+		{
+			query:    "SELECT UPPER(name) AS n, COUNT(*) AS count FROM ./testdata GROUP BY name ORDER BY count DESC LIMIT 1",
+			opts:     Options{Template: "{{.count}}x {{.n}}"},
+			expected: "2x .GITKEEP\n",
+		},
+		{
+			query:    "SELECT name AS filename FROM ./testdata/foo WHERE depth = 1 ORDER BY filename DESC",
+			expected: "qux \nquuz\nquux\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Defaults(t *testing.T) {
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT size FROM ./testdata WHERE name = foo",
+			opts:     Options{SizeUnit: "KB"},
+			expected: fmt.Sprintf("%s\n", GetAttrs("foo", "size:kb")[0]),
+		},
+		{
+			query:    "SELECT time FROM ./testdata WHERE name = foo",
+			opts:     Options{TimeFormat: "ISO"},
+			expected: fmt.Sprintf("%s\n", GetAttrs("foo", "time:iso")[0]),
+		},
+		// Modifiers override the defaults.
+		{
+			query:    "SELECT FORMAT(size, MB), FORMAT(time, 2006) FROM ./testdata WHERE name = foo",
+			opts:     Options{SizeUnit: "KB", TimeFormat: "ISO"},
+			expected: fmt.Sprintf("%s\t%s\n", GetAttrs("foo", "size:mb")[0], GetAttrs("foo", "time:year")[0]),
+		},
+		// Conditions and aggregates aren't affected.
+		{
+			query:    "SELECT name, size FROM ./testdata/foo WHERE depth = 1 AND size > 1",
+			opts:     Options{SizeUnit: "MB"},
+			expected: fmt.Sprintf("quuz\t%s\n", GetAttrs("foo/quuz", "size:mb")[0]),
+		},
+		{
+			query:    "SELECT MAX(size) FROM ./testdata/foo WHERE name = quuz",
+			opts:     Options{SizeUnit: "KB"},
+			expected: GetAttrs("foo/quuz", "size")[0] + "\n",
+		},
+		{
+			query:    "SELECT name, size FROM ./testdata/foo WHERE name = quuz",
+			opts:     Options{SizeUnit: "auto", Format: "json"},
+			expected: fmt.Sprintf("[\n  {\"name\": \"quuz\", \"size\": %s}\n]\n", GetAttrs("foo/quuz", "size:auto")[0]),
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected, actual)
+		}
+	}
+
+	expected := errors.New("invalid size unit XB")
+	err := RunWithOptions("SELECT size FROM ./testdata", Options{SizeUnit: "XB"})
+	if !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+
+	// GROUP BY keys without modifiers are grouped by their formatted values.
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, month := range []time.Month{time.January, time.June} {
+		name := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		modified := time.Date(2020, month, 1, 0, 0, 0, 0, time.Local)
+		if err := os.Chtimes(name, modified, modified); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT time, COUNT(*) FROM %s WHERE NOT is_dir GROUP BY time", dir)
+	if actual := DoRunWithOptions(query, Options{TimeFormat: "2006"}); actual != "2020\t2\n" {
+		t.Fatalf("\nExpected:\n2020\t2\nGot:\n%v", actual)
+	}
+}
+
+func TestRun_Count(t *testing.T) {
+	type Expected struct {
+		output string
+		err    error
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected Expected
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata WHERE mode IS dir",
+			opts:     Options{Count: true},
+			expected: Expected{output: "8\n"},
+		},
+		{
+			query:    "SELECT name FROM ./testdata EXCLUDE bar WHERE mode IS dir",
+			opts:     Options{Count: true},
+			expected: Expected{output: "4\n"},
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE mode IS dir LIMIT 3",
+			opts:     Options{Count: true},
+			expected: Expected{output: "3\n"},
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE mode IS dir LIMIT 10 OFFSET 6",
+			opts:     Options{Count: true, Format: "table"},
+			expected: Expected{output: "2\n"},
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata GROUP BY depth",
+			opts:     Options{Count: true},
+			expected: Expected{output: "6\n"},
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = nonexistent",
+			opts:     Options{Count: true},
+			expected: Expected{output: "0\n", err: ErrNoMatches},
+		},
+		{
+			query: "SELECT name FROM ./testdata",
+			opts:  Options{Count: true, Format: "json"},
+			expected: Expected{
+				err: errors.New("cannot count results with output format json"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := DoRunWithError(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+		if !reflect.DeepEqual(c.expected.output, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected.output, actual)
+		}
+	}
+}
+
+func TestRun_Explain(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name",
+			expected: "SELECT\n  name\nFROM\n  .\n",
+		},
+		{
+			query:    "SELECT name AS filename, COUNT(*) AS n GROUP BY name",
+			expected: "SELECT\n  name AS filename\n  COUNT(*) AS n\nFROM\n  .\nGROUP BY\n  name\n",
+		},
+		{
+			query: "SELECT depth GROUP BY depth HAVING COUNT(*) > 1 AND depth <> 0",
+			expected: "SELECT\n  depth\nFROM\n  .\nGROUP BY\n  depth\nHAVING\n  AND\n" +
+				"    COUNT(*) > '1'\n    depth <> '0'\n",
+		},
+		{
+			query: "SELECT DISTINCT FULLPATH(name), FORMAT(size, KB) FROM ./testdata/foo AS foo, " +
+				"'./testdata/**/qu?z', -./testdata/bar EXCLUDE (fred, 'node%') " +
+				"WHERE name = foo OR NOT (size BETWEEN 1 AND 10 OR name =~ 'a+') AND " +
+				"is_hidden ORDER BY size DESC, name LIMIT 3 OFFSET 1",
+			expected: strings.Join([]string{
+				"SELECT DISTINCT",
+				"  FULLPATH(name)",
+				"  FORMAT(size, KB)",
+				"FROM",
+				"  testdata/foo AS foo",
+				"  testdata/**/qu?z (glob)",
+				"    testdata/foo/quuz",
+				"  -testdata/bar (excluded)",
+				"EXCLUDE",
+				"  fred",
+				"  node%",
+				"WHERE",
+				"  OR",
+				"    name = 'foo'",
+				"    AND",
+				"      NOT",
+				"        OR",
+				"          size BETWEEN '1' AND '10'",
+				"          name RLIKE 'a+'",
+				"      is_hidden = 'true'",
+				"ORDER BY",
+				"  size DESC",
+				"  name ASC",
+				"LIMIT 3",
+				"OFFSET 1",
+				"",
+			}, "\n"),
+		},
+		{
+			query: "SELECT name FROM . EXEC mv -n {} '/tmp/old files'",
+			expected: strings.Join([]string{
+				"SELECT",
+				"  name",
+				"FROM",
+				"  .",
+				"EXEC",
+				"  'mv' '-n' '{}' '/tmp/old files'",
+				"",
+			}, "\n"),
+		},
+		{
+			query: "SELECT COUNT(*), depth FROM (SELECT name FROM ./testdata WHERE NOT name IN [foo, bar]) " +
+				"WHERE name IN (SELECT name FROM ./testdata/foo WHERE depth = 1) GROUP BY depth",
+			expected: strings.Join([]string{
+				"SELECT",
+				"  COUNT(*)",
+				"  depth",
+				"FROM",
+				"  (subquery)",
+				"    SELECT",
+				"      name",
+				"    FROM",
+				"      testdata",
+				"    WHERE",
+				"      NOT name IN ('foo', 'bar')",
+				"WHERE",
+				"  name IN ('quux', 'quuz', 'qux')",
+				"GROUP BY",
+				"  depth",
+				"",
+			}, "\n"),
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Explain: true})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Color(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("execute bits aren't available on windows")
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+	}
+
+	dir := func(s string) string { return "\x1b[01;34m" + s + "\x1b[0m" }
+	exe := func(s string) string { return "\x1b[01;32m" + s + "\x1b[0m" }
+
+	cases := []Case{
+		{
+			query: "SELECT name, size FROM ./testdata WHERE depth <= 1 AND name <> bar",
+			opts:  Options{Color: true},
+			expected: fmt.Sprintf("%s\t%s\n%s     \t0\n%s     \t%s\n",
+				dir("testdata"), GetAttrs(".", "size")[0], exe("baz"), dir("foo"), GetAttrs("foo", "size")[0]),
+		},
+		{
+			query:    "SELECT path FROM ./testdata/foo WHERE name LIKE qu%",
+			opts:     Options{Color: true},
+			expected: fmt.Sprintf("quux\n%s\nqux\n", dir("quuz")),
+		},
+		{
+			query:    "SELECT COUNT(*), name FROM ./testdata WHERE name = foo GROUP BY name",
+			opts:     Options{Color: true},
+			expected: "1\tfoo\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = baz",
+			opts:     Options{Color: true, Format: "csv"},
+			expected: "name\nbaz\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = baz",
+			opts:     Options{},
+			expected: "baz\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%q\nGot:\n%q", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Max(t *testing.T) {
+	// The notice is shown on stderr, which is written to a file here.
+	stderr := os.Stderr
+	f, err := ioutil.TempFile("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	os.Stderr = f
+	defer func() { os.Stderr = stderr }()
+
+	type Expected struct {
+		output string
+		notice string
+	}
+
+	type Case struct {
+		query    string
+		max      int
+		expected Expected
+	}
+
+	notice := "... (stopped at 2 results; use -max 0 for all)\n"
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata/foo",
+			max:      2,
+			expected: Expected{output: "foo \nquux\n", notice: notice},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo ORDER BY name DESC LIMIT 3",
+			max:      2,
+			expected: Expected{output: "waldo\nqux  \n", notice: notice},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo LIMIT 1",
+			max:      2,
+			expected: Expected{output: "foo\n"},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo/quuz/fred",
+			max:      2,
+			expected: Expected{output: "fred    \n.gitkeep\n"},
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata GROUP BY depth",
+			max:      2,
+			expected: Expected{output: "1\n3\n", notice: notice},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo/quuz",
+			max:      0,
+			expected: Expected{output: "quuz    \nfred    \n.gitkeep\nwaldo   \n"},
+		},
+	}
+
+	for _, c := range cases {
+		if err := f.Truncate(0); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+
+		output := DoRunWithOptions(c.query, Options{Max: c.max})
+		notice, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual := Expected{output: output, notice: string(notice)}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Collate(t *testing.T) {
+	type Case struct {
+		query    string
+		collate  string
+		expected string
+		err      error
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata/foo/quuz ORDER BY name",
+			collate:  "en",
+			expected: ".gitkeep\nfred    \nquuz    \nwaldo   \n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo/quuz ORDER BY name DESC",
+			collate:  "en",
+			expected: "waldo   \nquuz    \nfred    \n.gitkeep\n",
+		},
+		{
+			query:   "SELECT name FROM ./testdata",
+			collate: "not a language",
+			err:     errors.New("invalid collation not a language"),
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := DoRunWithError(c.query, Options{Collate: c.collate})
+		if !reflect.DeepEqual(c.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.err, err)
+		}
+		if c.err == nil && !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Quiet(t *testing.T) {
+	type Case struct {
+		query string
+		opts  Options
+		err   error
+	}
+
+	cases := []Case{
+		{query: "SELECT name FROM ./testdata WHERE name = baz"},
+		{query: "SELECT name FROM ./testdata ORDER BY size DESC"},
+		{query: "SELECT name FROM ./testdata LIMIT 2 OFFSET 5"},
+		{query: "SELECT COUNT(*) FROM ./testdata GROUP BY depth"},
+		{query: "SELECT name FROM ./testdata", opts: Options{Format: "json"}},
+		{query: "SELECT name FROM ./testdata WHERE name = nonexistent", err: ErrNoMatches},
+		{query: "SELECT name FROM ./testdata LIMIT 0", err: ErrNoMatches},
+		{query: "SELECT name FROM ./testdata ORDER BY name OFFSET 16", err: ErrNoMatches},
+		{
+			query: "SELECT name FROM ./testdata EXEC echo {}",
+			err:   errors.New("cannot run a query with EXEC in quiet mode"),
+		},
+		{
+			query: "SELECT name FROM ./testdata",
+			opts:  Options{Count: true},
+			err:   errors.New("cannot count or total results in quiet mode"),
+		},
+	}
+
+	for _, c := range cases {
+		c.opts.Quiet = true
+		actual, err := DoRunWithError(c.query, c.opts)
+		if !reflect.DeepEqual(c.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.query, c.err, err)
+		}
+		if actual != "" {
+			t.Fatalf("%s\nExpected no output\n     Got %v", c.query, actual)
+		}
+	}
+}
+
+func TestRun_Dupes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The files are written in order of their names, which is the order they're
+	// found in.
+	files := []struct{ name, contents string }{
+		{"a", "foo"},
+		{"b", "bar"},
+		{"c", "foo"},
+		{"d", "baz"},
+		{"e", "bar"},
+		{"f", "foo"},
+		{"g", ""},
+		{"h", ""},
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f.name), []byte(f.contents), 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected string
+		err      error
+	}
+
+	from := fmt.Sprintf("FROM '%s'", dir)
+	cases := []Case{
+		{
+			query:    "SELECT name " + from,
+			expected: "a\nc\nf\n\nb\ne\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE name <> c",
+			expected: "a\nf\n\nb\ne\n",
+		},
+		{
+			query:    "SELECT name " + from,
+			opts:     Options{Format: "csv"},
+			expected: "name\na\nc\nf\nb\ne\n",
+		},
+		{
+			query:    "SELECT name " + from + " WHERE name IN (a, b, d)",
+			expected: "",
+		},
+		{
+			query:    "SELECT name " + from,
+			opts:     Options{Count: true},
+			expected: "5\n",
+		},
+		{
+			query: "SELECT name " + from + " EXEC rm {}",
+			err:   errors.New("cannot find duplicates of a query with EXEC"),
+		},
+		{
+			query: "SELECT COUNT(*) " + from,
+			err:   errors.New("cannot find duplicates of a grouped query"),
+		},
+	}
+
+	for _, c := range cases {
+		c.opts.Dupes = true
+		actual, err := DoRunWithError(c.query, c.opts)
+		if !reflect.DeepEqual(c.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.query, c.err, err)
+		}
+		if c.err == nil && !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestDupes_TruncateGroups(t *testing.T) {
+	type Case struct {
+		max      int
+		expected []int
+	}
+
+	rows := func(n int) []*row {
+		rows := make([]*row, n)
+		for i := range rows {
+			rows[i] = &row{}
+		}
+		return rows
+	}
+	groups := [][]*row{rows(3), rows(2), rows(2)}
+
+	cases := []Case{
+		{max: 0, expected: []int{3, 2, 2}},
+		{max: 2, expected: []int{2}},
+		{max: 3, expected: []int{3}},
+		{max: 4, expected: []int{3, 1}},
+		{max: 7, expected: []int{3, 2, 2}},
+		{max: 10, expected: []int{3, 2, 2}},
+	}
+
+	for _, c := range cases {
+		truncated := truncateGroups(groups, c.max)
+		actual := make([]int, len(truncated))
+		for i, group := range truncated {
+			actual[i] = len(group)
+		}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%d\nExpected %v\n     Got %v", c.max, c.expected, actual)
+		}
+	}
+	if len(groups[0]) != 3 || len(groups) != 3 {
+		t.Fatalf("\nExpected groups to be unchanged\n     Got %v", groups)
+	}
+}
+
+func TestRun_Total(t *testing.T) {
+	// The total is shown on stderr, which is written to a file here.
+	stderr := os.Stderr
+	f, err := ioutil.TempFile("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	os.Stderr = f
+	defer func() { os.Stderr = stderr }()
+
+	type Expected struct {
+		output string
+		total  string
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected Expected
+		err      error
+	}
+
+	cases := []Case{
+		{
+			query: "SELECT name FROM ./testdata/foo/quuz WHERE NOT is_dir",
+			opts:  Options{Total: true},
+			expected: Expected{
+				output: ".gitkeep\nwaldo   \n",
+				total:  "Total: 0 B across 2 file(s)\n",
+			},
+		},
+		{
+			query: "SELECT name FROM ./testdata/foo/quuz WHERE NOT is_dir",
+			opts:  Options{Total: true, Max: 1},
+			expected: Expected{
+				output: ".gitkeep\n",
+				total:  "Total: 0 B across 1 file(s)\n... (stopped at 1 results; use -max 0 for all)\n",
+			},
+		},
+		{
+			query: "SELECT name FROM ./testdata WHERE name = nonexistent",
+			opts:  Options{Total: true},
+			expected: Expected{
+				total: "Total: 0 B across 0 file(s)\n",
+			},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo/quuz WHERE NOT is_dir",
+			opts:     Options{Total: true, Count: true},
+			expected: Expected{output: "2\n", total: "Total: 0 B across 2 file(s)\n"},
+		},
+		{
+			query: "SELECT COUNT(*) FROM ./testdata",
+			opts:  Options{Total: true},
+			err:   errors.New("cannot total results of a grouped query"),
+		},
+		{
+			query: "SELECT depth FROM ./testdata GROUP BY depth",
+			opts:  Options{Total: true},
+			err:   errors.New("cannot total results of a grouped query"),
+		},
+	}
+
+	for _, c := range cases {
+		if err := f.Truncate(0); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+
+		output, err := DoRunWithError(c.query, c.opts)
+		if !reflect.DeepEqual(c.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.query, c.err, err)
+		}
+		if c.err != nil {
+			continue
+		}
+		total, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual := Expected{output: output, total: string(total)}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestRun_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands require a Unix-like system")
+	}
+
+	// Failed commands are reported on stderr, which is discarded here.
+	stderr := os.Stderr
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer devNull.Close()
+	os.Stderr = devNull
+	defer func() { os.Stderr = stderr }()
+
+	type Expected struct {
+		output string
+		err    error
+	}
+
+	type Case struct {
+		query    string
+		opts     Options
+		expected Expected
+	}
+
+	cases := []Case{
+		{
+			query: "SELECT name FROM ./testdata/foo WHERE depth = 1 EXEC echo {}",
+			expected: Expected{
+				output: "testdata/foo/quux\ntestdata/foo/quuz\ntestdata/foo/qux\n",
+			},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE name LIKE qu_x EXEC echo 'x {}.bak'",
+			expected: Expected{output: "x testdata/foo/quux.bak\n"},
+		},
+		{
+			query: "SELECT name FROM ./testdata/foo WHERE depth = 1 ORDER BY name DESC EXEC echo {} end",
+			opts:  Options{ExecBatch: true},
+			expected: Expected{
+				output: "testdata/foo/qux testdata/foo/quuz testdata/foo/quux end\n",
+			},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE name = nonexistent EXEC echo {}",
+			expected: Expected{output: ""},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo WHERE depth = 1 EXEC test -d {}",
+			expected: Expected{err: errors.New("2 of 3 command(s) failed")},
+		},
+		{
+			query: "SELECT name FROM ./testdata/foo WHERE depth = 1 EXEC test -d {}",
+			opts:  Options{ExecAbort: true},
+			expected: Expected{
+				err: errors.New("exec test -d testdata/foo/quux: exit status 1"),
+			},
+		},
+		{
+			query:    "SELECT name FROM ./testdata/foo EXEC echo {}",
+			opts:     Options{Count: true},
+			expected: Expected{err: errors.New("cannot count results of a query with EXEC")},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := DoRunWithError(c.query, c.opts)
+		if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.query, c.expected.err, err)
+		}
+		if !reflect.DeepEqual(c.expected.output, actual) {
+			t.Fatalf("%s\nExpected:\n%v\nGot:\n%v", c.query, c.expected.output, actual)
+		}
+	}
+}
+
+func TestRun_Comments(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name /* base */ FROM ./testdata/foo -- cwd\nWHERE depth = 1 AND name <> quuz -- not (this one)",
+			expected: "quux\nqux \n",
+		},
+		{
+			query: "SELECT name FROM ./testdata WHERE name IN (\n  -- Children of foo.\n" +
+				"  SELECT name FROM ./testdata/foo -- (\n  WHERE depth = 1\n) AND NOT name LIKE %z",
+			expected: "quux\nqux \n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE name = '--' OR name = foo--bar OR name = '/*' OR name = qux",
+			expected: "qux\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRun(c.query)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func TestReadQuery(t *testing.T) {
+	type Case struct {
+		input    string
+		expected string
+	}
+
+	cases := []Case{
+		{input: "SELECT name FROM .", expected: "SELECT name FROM ."},
+		{input: "\n\t SELECT name\nFROM .  \n\n", expected: "SELECT name\nFROM ."},
+		{
+			input:    "-- Go files\nSELECT name\n  -- in the cwd\nFROM .\nWHERE extension = go\n--",
+			expected: "SELECT name\nFROM .\nWHERE extension = go",
+		},
+		{input: "SELECT name FROM ./-- WHERE name = '--'", expected: "SELECT name FROM ./-- WHERE name = '--'"},
+		{input: "-- nothing to see here\n", expected: ""},
+	}
+
+	for _, c := range cases {
+		actual, err := ReadQuery(strings.NewReader(c.input))
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("\nExpected %q\n     Got %q", c.expected, actual)
+		}
+	}
+
+	query := "SELECT name FROM ./testdata/foo WHERE depth = 1"
+	input, err := ReadQuery(strings.NewReader("-- The children of foo.\n" + query + "\n"))
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if expected, actual := DoRun(query), DoRun(input); expected != actual {
+		t.Fatalf("\nExpected:\n%v\nGot:\n%v", expected, actual)
+	}
+}
+
+func TestRun_Jobs(t *testing.T) {
+	type Case struct {
+		query    string
+		expected string
+	}
+
+	cases := []Case{
+		{
+			query:    "SELECT name FROM ./testdata EXCLUDE bar WHERE name LIKE %u% ORDER BY name",
+			expected: "quux\nquuz\nqux \n",
+		},
+		{
+			query:    "SELECT COUNT(*), SUM(size) FROM ./testdata, ./testdata/foo",
+			expected: "16\t32768\n",
+		},
+		{
+			query:    "SELECT COUNT(*) FROM ./testdata WHERE NOT depth BETWEEN 1 AND 2",
+			expected: "7\n",
+		},
+		{
+			query:    "SELECT name FROM ./testdata WHERE depth = 1 ORDER BY name DESC LIMIT 2",
+			expected: "foo\nbaz\n",
+		},
+	}
+
+	for _, c := range cases {
+		actual := DoRunWithOptions(c.query, Options{Jobs: 4})
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected:\n%v\nGot:\n%v", c.expected, actual)
+		}
+	}
+}
+
+func GetAttrs(path string, attrs ...string) []string {
+	// If the files map is empty, walk ./testdata and populate it.
+	if len(files) == 0 {
+		if err := filepath.Walk(
+			"./testdata",
+			func(path string, info os.FileInfo, err error) error {
+				files[filepath.Clean(path)] = &info
+				return nil
+			},
+		); err != nil {
+			return []string{}
+		}
+	}
+
+	path = filepath.Clean(fmt.Sprintf("testdata/%s", path))
+	file, ok := files[path]
+	if !ok {
+		return []string{}
+	}
+
+	result := make([]string, len(attrs))
+	for i, attr := range attrs {
+		// Hard-coding modifiers works for the time being, but we might need a more
+		// elegant solution when we introduce new modifiers in the future.
+		switch attr {
+		case "hash":
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return []string{}
+			}
+			h := sha1.New()
+			if _, err := h.Write(b); err != nil {
+				return []string{}
+			}
+			result[i] = hex.EncodeToString(h.Sum(nil))[:7]
+		case "size":
+			result[i] = fmt.Sprintf("%d", (*file).Size())
+		case "size:auto":
+			size, err := transform.Format(&transform.FormatParams{
+				Attribute: "size",
+				Path:      path,
+				Info:      *file,
+				Value:     (*file).Size(),
+				Name:      "format",
+				Args:      []string{"auto"},
+			})
+			if err != nil {
+				return []string{}
+			}
+			b, err := json.Marshal(size)
+			if err != nil {
+				return []string{}
+			}
+			result[i] = string(b)
+		case "size:kb", "size:mb", "size:gb":
+			size := (*file).Size()
+			switch attr[len(attr)-2:] {
+			case "kb":
+				result[i] = fmt.Sprintf("%fkb", float64(size)/1e3)
+			case "mb":
+				result[i] = fmt.Sprintf("%fmb", float64(size)/1e6)
+			case "gb":
+				result[i] = fmt.Sprintf("%fgb", float64(size)/1e9)
+			}
+		case "time":
+			result[i] = (*file).ModTime().Format(time.Stamp)
+		case "time:iso":
+			result[i] = (*file).ModTime().Format(time.RFC3339)
+		case "time:year":
+			result[i] = (*file).ModTime().Format("2006")
+		}
+	}
+	return result
+}
+
+// DoRun executes fsql.Run and returns the output.
+func DoRun(query string) string {
+	return DoRunWithOptions(query, Options{})
+}
+
+func DoRunWithOptions(query string, opts Options) string {
+	output, err := DoRunWithError(query, opts)
+	if err != nil {
+		return ""
+	}
+	return output
+}
+
+// DoRunWithError is DoRunWithOptions, but returns the output even if the query
+// fails, along with the error.
+func DoRunWithError(query string, opts Options) (string, error) {
+	stdout := os.Stdout
+	ch := make(chan string)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	os.Stdout = w
+
 	go func() {
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
 		ch <- buf.String()
 	}()
 
+	err = RunWithOptions(query, opts)
 	w.Close()
 	os.Stdout = stdout
-	return <-ch
+	return <-ch, err
 }
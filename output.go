@@ -0,0 +1,335 @@
+package fsql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kshvmdn/fsql/query"
+	"github.com/kshvmdn/fsql/transform"
+)
+
+// row is a single result of a query.
+type row struct {
+	path   string
+	info   os.FileInfo
+	values map[string]interface{}
+}
+
+// writer writes the rows of q to w in a specific output format.
+type writer func(w io.Writer, q *query.Query, rows []*row, opts Options) error
+
+// writers maps each output format to its writer. The empty format is the
+// default.
+var writers = map[string]writer{
+	"":         writeTable,
+	"table":    writeTable,
+	"json":     writeJSON,
+	"csv":      writeCSV,
+	"nul":      writeNUL,
+	"shell":    writeShell,
+	"template": writeTemplate,
+}
+
+// writeTable writes each row as a line of tab-separated values. Control
+// characters in string values (e.g. a newline in a name) are escaped (see
+// escapeControl), unless opts.Literal is set.
+func writeTable(w io.Writer, q *query.Query, rows []*row, opts Options) error {
+	value := func(r *row, attribute string) interface{} {
+		// Nil values (e.g. the AVG of zero files) are shown as empty.
+		value := r.values[attribute]
+		if value == nil {
+			return ""
+		}
+		if s, ok := value.(string); ok && !opts.Literal {
+			return escapeControl(s)
+		}
+		return value
+	}
+
+	// Find length of the longest name to normalize name output.
+	var max = 0
+	if q.HasAttribute("name") {
+		for _, r := range rows {
+			if s, ok := value(r, "name").(string); ok && len(s) > max {
+				max = len(s)
+			}
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	for _, r := range rows {
+		var buf bytes.Buffer
+		for j, attribute := range q.Attributes {
+			// If the current attribute is "name", pad the output string by `max`
+			// spaces.
+			format := "%v"
+			if attribute == "name" {
+				format = fmt.Sprintf("%%-%dv", max)
+			}
+			s := fmt.Sprintf(format, value(r, attribute))
+			if opts.Color && isColoredAttribute(attribute) {
+				s = colorize(s, r.info)
+			}
+			buf.WriteString(s)
+			if j != len(q.Attributes)-1 {
+				buf.WriteString("\t")
+			}
+		}
+		fmt.Fprintf(out, "%s\n", buf.String())
+	}
+	return out.Flush()
+}
+
+// escapeControl returns s with each control character replaced by its C-style
+// escape sequence (e.g. `\n` for a newline, or `\x1b` for an escape), so a
+// value is always shown on a single line of the table, and can't e.g. change
+// the terminal's colors. Other characters (including backslashes) are kept as
+// is, so escaped values are ambiguous: the `json` and `nul` formats show
+// values exactly.
+func escapeControl(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+
+	var buf bytes.Buffer
+	for _, r := range s {
+		switch {
+		case r == '\a':
+			buf.WriteString(`\a`)
+		case r == '\b':
+			buf.WriteString(`\b`)
+		case r == '\f':
+			buf.WriteString(`\f`)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\v':
+			buf.WriteString(`\v`)
+		case r < utf8.RuneSelf && unicode.IsControl(r):
+			fmt.Fprintf(&buf, `\x%02x`, r)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// Colors of the names of each type of file (the same as the defaults of `ls
+// --color`), as ANSI escape sequences.
+const (
+	colorDir        = "\x1b[01;34m"
+	colorSymlink    = "\x1b[01;36m"
+	colorExecutable = "\x1b[01;32m"
+	colorReset      = "\x1b[0m"
+)
+
+// isColoredAttribute returns true iff the values of attribute are colored by
+// the type of file (see Options.Color).
+func isColoredAttribute(attribute string) bool {
+	return attribute == "name" || attribute == "path" || attribute == "abspath"
+}
+
+// colorize returns s (the name or path of the file with info) colored by the
+// file's type: directories, symbolic links, and executable files. Trailing
+// padding isn't colored. Other files, and groups (which have no info), aren't
+// colored.
+func colorize(s string, info os.FileInfo) string {
+	if info == nil {
+		return s
+	}
+
+	var color string
+	switch mode := info.Mode(); {
+	case mode&os.ModeSymlink != 0:
+		color = colorSymlink
+	case mode.IsDir():
+		color = colorDir
+	case mode.IsRegular() && transform.IsExecutable(info):
+		color = colorExecutable
+	default:
+		return s
+	}
+
+	trimmed := strings.TrimRight(s, " ")
+	return color + trimmed + colorReset + s[len(trimmed):]
+}
+
+// writeJSON writes the rows as a JSON array of objects, each keyed by the
+// selected attributes (in order). Numeric values are written as numbers, nil
+// values as null, unmodified times in RFC 3339 format, and the values of
+// `FORMAT(size, AUTO)` as objects (see transform.Size).
+func writeJSON(w io.Writer, q *query.Query, rows []*row, opts Options) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("[")
+	for i, r := range rows {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n  {")
+		for j, attribute := range q.Attributes {
+			if j > 0 {
+				out.WriteString(", ")
+			}
+			key, err := json.Marshal(q.Column(attribute))
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(jsonValue(q, attribute, r))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s: %s", key, value)
+		}
+		out.WriteString("}")
+	}
+	if len(rows) > 0 {
+		out.WriteString("\n")
+	}
+	out.WriteString("]\n")
+	return out.Flush()
+}
+
+// jsonValue returns the value of attribute for r, as it should be encoded in
+// JSON.
+func jsonValue(q *query.Query, attribute string, r *row) interface{} {
+	value := r.values[attribute]
+	if transform.IsTimeAttribute(attribute) && len(q.SelectModifiers(attribute)) == 0 && r.info != nil {
+		if t, ok := transform.FileTime(attribute, r.info); ok {
+			return t.Format(time.RFC3339)
+		}
+		return nil
+	}
+	switch t := value.(type) {
+	case nil, string, bool, int64, float64, transform.Size:
+		return t
+	case fmt.Stringer:
+		// E.g. os.FileMode, which would otherwise be encoded as a number.
+		return t.String()
+	}
+	return value
+}
+
+// writeCSV writes a header row of the selected attributes, followed by a row
+// of values for each result. Fields are separated by opts.Delimiter (a comma
+// by default).
+func writeCSV(w io.Writer, q *query.Query, rows []*row, opts Options) error {
+	out := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		out.Comma = opts.Delimiter
+	}
+
+	header := make([]string, len(q.Attributes))
+	for i, attribute := range q.Attributes {
+		header[i] = q.Column(attribute)
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(q.Attributes))
+	for _, r := range rows {
+		for i, attribute := range q.Attributes {
+			record[i] = ""
+			if value := r.values[attribute]; value != nil {
+				record[i] = fmt.Sprintf("%v", value)
+			}
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// writeNUL writes the value of the single selected attribute for each row,
+// terminated by a NUL byte (e.g. for `xargs -0`). Values are written as is.
+func writeNUL(w io.Writer, q *query.Query, rows []*row, opts Options) error {
+	out := bufio.NewWriter(w)
+	for _, r := range rows {
+		if value := r.values[q.Attributes[0]]; value != nil {
+			fmt.Fprintf(out, "%v", value)
+		}
+		out.WriteByte(0)
+	}
+	return out.Flush()
+}
+
+// writeShell writes the value of the single selected attribute for each row
+// on its own line, quoted for a POSIX shell (see shellQuote), so that e.g.
+// `eval "files=($(fsql ...))"` sets a bash array to the values.
+func writeShell(w io.Writer, q *query.Query, rows []*row, opts Options) error {
+	out := bufio.NewWriter(w)
+	for _, r := range rows {
+		var value string
+		if v := r.values[q.Attributes[0]]; v != nil {
+			value = fmt.Sprintf("%v", v)
+		}
+		out.WriteString(shellQuote(value))
+		out.WriteByte('\n')
+	}
+	return out.Flush()
+}
+
+// shellQuote returns s as a single word of a POSIX shell. Unless s only
+// consists of characters which are never special (e.g. `foo/bar.go`), it's
+// enclosed in single quotes, which are closed around each single quote it
+// contains so that it can be escaped with a backslash. Since nothing is special
+// within single quotes, whitespace, globs, and variables are kept as is.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !isShellSafe(r) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// isShellSafe returns true iff r is never special to a POSIX shell.
+func isShellSafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("@%+=:,./_-", r)
+}
+
+// writeTemplate executes the template opts.Template for each row, followed by
+// a newline. Each row is passed to the template as a map of the selected
+// attributes (or their aliases) to their values (e.g. `{{.name}}`).
+func writeTemplate(w io.Writer, q *query.Query, rows []*row, opts Options) error {
+	out := bufio.NewWriter(w)
+	for _, r := range rows {
+		values := make(map[string]interface{}, len(q.Attributes))
+		for _, attribute := range q.Attributes {
+			values[q.Column(attribute)] = r.values[attribute]
+		}
+		if err := opts.template.Execute(out, values); err != nil {
+			return err
+		}
+		out.WriteString("\n")
+	}
+	return out.Flush()
+}
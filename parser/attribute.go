@@ -1,25 +1,91 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/kshvmdn/fsql/query"
 	"github.com/kshvmdn/fsql/tokenizer"
+	"github.com/kshvmdn/fsql/transform"
 )
 
-var allAttributes = []string{"mode", "size", "time", "hash", "name"}
+// defaultAttributes holds the attributes which are selected by `all`, or if
+// the query doesn't select any. `*` selects every attribute instead (see
+// transform.Attributes).
+var defaultAttributes = []string{"mode", "size", "time", "hash", "name"}
+
+// conditionAttributes holds the valid attributes which may only be used in
+// the WHERE clause, since they're too costly to output (e.g. `content`).
+var conditionAttributes = []string{"content"}
+
+// booleanAttributes holds the attributes whose values are either true or
+// false, which may be used as a condition without an operator (e.g.
+// `WHERE is_hidden`).
+var booleanAttributes = []string{
+	"is_dir", "is_file", "is_symlink", "is_hidden", "is_empty", "is_executable",
+	"has_xattr",
+}
+
+// attributeAliases maps each attribute alias to the attribute it refers to.
+var attributeAliases = map[string]string{"ext": "extension"}
 
 func isValidAttribute(attribute string) error {
-	for _, valid := range allAttributes {
+	for _, valid := range transform.Attributes {
 		if attribute == valid {
 			return nil
 		}
 	}
+	if isConditionAttribute(attribute) {
+		return nil
+	}
 	return &ErrUnknownToken{attribute}
 }
 
-// parseAttrs parses the list of attributes passed to the SELECT clause.
-func (p *parser) parseAttrs(attributes *[]string, modifiers *map[string][]query.Modifier) error {
+func isConditionAttribute(attribute string) bool {
+	for _, valid := range conditionAttributes {
+		if attribute == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func isBooleanAttribute(attribute string) bool {
+	for _, valid := range booleanAttributes {
+		if attribute == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString returns true iff s is one of values.
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isAggregateFunction(name string) bool {
+	for _, aggregate := range transform.AggregateFunctions {
+		if strings.ToUpper(name) == aggregate {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAttrs parses the list of attributes passed to the SELECT clause. `*`
+// is expanded to every attribute (see transform.Attributes), and `all` to
+// each of defaultAttributes, which aren't already selected (e.g. `SELECT name,
+// *` shows name first). Aggregates are added to attributes by their string
+// representation (e.g. `SUM(size)`). If an attribute is followed by the AS
+// keyword, the following word is registered as its alias.
+func (p *parser) parseAttrs(attributes *[]string, modifiers *map[string][]query.Modifier,
+	aggregates *map[string]query.Aggregate, aliases *map[string]string) error {
 	for {
 		ident := p.expect(tokenizer.Identifier)
 		if ident == nil {
@@ -27,12 +93,30 @@ func (p *parser) parseAttrs(attributes *[]string, modifiers *map[string][]query.
 		}
 
 		if ident.Raw == "*" || ident.Raw == "all" {
-			*attributes = allAttributes
+			all := defaultAttributes
+			if ident.Raw == "*" {
+				all = transform.Attributes
+			}
+			for _, attribute := range all {
+				if !containsString(*attributes, attribute) {
+					*attributes = append(*attributes, attribute)
+				}
+			}
+		} else if isAggregateFunction(ident.Raw) {
+			aggregate, err := p.parseAggregate(ident)
+			if err != nil {
+				return err
+			}
+			if *aggregates == nil {
+				*aggregates = make(map[string]query.Aggregate)
+			}
+			*attributes = append(*attributes, aggregate.String())
+			(*aggregates)[aggregate.String()] = *aggregate
 		} else {
 			p.current = ident
 
 			attrModifiers := make([]query.Modifier, 0)
-			attribute, err := p.parseAttr(&attrModifiers)
+			attribute, err := p.parseSelectableAttr(&attrModifiers)
 			if err != nil {
 				return err
 			}
@@ -40,13 +124,116 @@ func (p *parser) parseAttrs(attributes *[]string, modifiers *map[string][]query.
 			(*modifiers)[attribute.Raw] = attrModifiers
 		}
 
+		if p.expect(tokenizer.As) != nil {
+			if ident.Raw == "*" || ident.Raw == "all" {
+				return fmt.Errorf("cannot alias %s", ident.Raw)
+			}
+			alias := p.expect(tokenizer.Identifier)
+			if alias == nil {
+				return p.currentError()
+			}
+			if *aliases == nil {
+				*aliases = make(map[string]string)
+			}
+			attribute := (*attributes)[len(*attributes)-1]
+			if other, ok := (*aliases)[attribute]; ok {
+				return fmt.Errorf("cannot alias %s as %s: already aliased as %s (an attribute may only have one alias)",
+					attribute, alias.Raw, other)
+			}
+			(*aliases)[attribute] = alias.Raw
+		}
+
 		if p.expect(tokenizer.Comma) == nil {
 			break
 		}
 	}
+	return validateAliases(*attributes, *aliases)
+}
+
+// validateAliases returns an error if an alias is the name of more than one
+// output column (e.g. `SELECT name AS size, size`), or if an aliased attribute
+// is selected more than once (e.g. `SELECT size, FORMAT(size, kb) AS kb`),
+// since an alias names every column of its attribute.
+func validateAliases(attributes []string, aliases map[string]string) error {
+	for i, attribute := range attributes {
+		alias, ok := aliases[attribute]
+		if ok && containsString(attributes[:i], attribute) {
+			return fmt.Errorf("cannot alias %s as %s: %s is selected more than once (an attribute may only have one alias)",
+				attribute, alias, attribute)
+		}
+	}
+	for _, alias := range aliases {
+		columns := 0
+		for _, attribute := range attributes {
+			if column, ok := aliases[attribute]; (ok && column == alias) ||
+				(!ok && attribute == alias) {
+				columns++
+			}
+		}
+		if columns > 1 {
+			return fmt.Errorf("duplicate column %s", alias)
+		}
+	}
 	return nil
 }
 
+// aliasedAttribute returns the selected attribute of q with the given alias,
+// if any.
+func aliasedAttribute(q *query.Query, alias string) (string, bool) {
+	for attribute, other := range q.AttributeAliases {
+		if other == alias {
+			return attribute, true
+		}
+	}
+	return "", false
+}
+
+// parseAggregate parses the argument of the aggregate function ident, which
+// is either an attribute (optionally with modifiers) or `*` for COUNT.
+func (p *parser) parseAggregate(ident *tokenizer.Token) (*query.Aggregate, error) {
+	if p.expect(tokenizer.OpenParen) == nil {
+		return nil, p.currentError()
+	}
+
+	aggregate := &query.Aggregate{
+		Name:      strings.ToUpper(ident.Raw),
+		Modifiers: make([]query.Modifier, 0),
+	}
+
+	token := p.expect(tokenizer.Identifier)
+	if token == nil {
+		return nil, p.currentError()
+	}
+	if token.Raw == "*" && aggregate.Name == "COUNT" {
+		aggregate.Attribute = token.Raw
+	} else {
+		p.current = token
+		attribute, err := p.parseSelectableAttr(&aggregate.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+		aggregate.Attribute = attribute.Raw
+	}
+
+	if p.expect(tokenizer.CloseParen) == nil {
+		return nil, p.currentError()
+	}
+	return aggregate, nil
+}
+
+// parseSelectableAttr parses an attribute (see parseAttr) outside of the WHERE
+// clause, i.e. one which isn't restricted to conditions.
+func (p *parser) parseSelectableAttr(modifiers *[]query.Modifier) (*tokenizer.Token, error) {
+	attribute, err := p.parseAttr(modifiers)
+	if err != nil {
+		return nil, err
+	}
+	if isConditionAttribute(attribute.Raw) {
+		return nil, fmt.Errorf("attribute %s can only be used in WHERE", attribute.Raw)
+	}
+	return attribute, nil
+}
+
 // parseAttr recursively parses an attribute's modifiers and returns the
 // associated attribute.
 func (p *parser) parseAttr(modifiers *[]query.Modifier) (*tokenizer.Token, error) {
@@ -58,12 +245,23 @@ func (p *parser) parseAttr(modifiers *[]query.Modifier) (*tokenizer.Token, error
 	// ident is a modifier name (e.g. `FORMAT`) iff the next token is an open
 	// paren, otherwise an attribute (e.g. `name`).
 	if token := p.expect(tokenizer.OpenParen); token == nil {
+		if attribute, ok := attributeAliases[ident.Raw]; ok {
+			ident.Raw = attribute
+		}
 		if err := isValidAttribute(ident.Raw); err != nil {
 			return nil, err
 		}
 		return ident, nil
 	}
 
+	// `*` / `all` stands for many attributes, so it can't be modified.
+	if token := p.expect(tokenizer.Identifier); token != nil {
+		if token.Raw == "*" || token.Raw == "all" {
+			return nil, fmt.Errorf("cannot apply modifier %s to %s", strings.ToUpper(ident.Raw), token.Raw)
+		}
+		p.current = token
+	}
+
 	// In the case of chained modifiers, we want to recurse and parse each
 	// inner modifier first. parseAttribute returns the associated attribute that
 	// we're looking for.
@@ -90,6 +288,15 @@ func (p *parser) parseAttr(modifiers *[]query.Modifier) (*tokenizer.Token, error
 			continue
 		}
 
+		// A hyphen followed by an identifier is a negative argument (e.g. `-1`).
+		if token := p.expect(tokenizer.Hyphen); token != nil {
+			if token = p.expect(tokenizer.Identifier); token == nil {
+				return nil, p.currentError()
+			}
+			modifier.Arguments = append(modifier.Arguments, "-"+token.Raw)
+			continue
+		}
+
 		if token := p.expect(tokenizer.Comma); token != nil {
 			continue
 		}
@@ -98,5 +305,7 @@ func (p *parser) parseAttr(modifiers *[]query.Modifier) (*tokenizer.Token, error
 			*modifiers = append(*modifiers, modifier)
 			return attribute, nil
 		}
+
+		return nil, p.currentError()
 	}
 }
@@ -1,12 +1,14 @@
 package parser
 
 import (
+	"errors"
 	"io"
 	"reflect"
 	"testing"
 
 	"github.com/kshvmdn/fsql/query"
 	"github.com/kshvmdn/fsql/tokenizer"
+	"github.com/kshvmdn/fsql/transform"
 )
 
 func TestAttributeParser_ExpectCorrectAttributes(t *testing.T) {
@@ -34,16 +36,62 @@ func TestAttributeParser_ExpectCorrectAttributes(t *testing.T) {
 		},
 		{
 			input:    "*",
-			expected: Expected{attributes: allAttributes, err: nil},
+			expected: Expected{attributes: transform.Attributes, err: nil},
 		},
 		{
 			input:    "all",
-			expected: Expected{attributes: allAttributes, err: nil},
+			expected: Expected{attributes: defaultAttributes, err: nil},
+		},
+		{
+			input: "all, owner",
+			expected: Expected{
+				attributes: []string{"mode", "size", "time", "hash", "name", "owner"},
+				err:        nil,
+			},
+		},
+		{
+			input: "name, path, all",
+			expected: Expected{
+				attributes: []string{"name", "path", "mode", "size", "time", "hash"},
+				err:        nil,
+			},
+		},
+		{
+			input: "path, *",
+			expected: Expected{
+				attributes: []string{
+					"path", "name", "extension", "size", "depth", "mode", "time",
+					"accessed", "changed", "created", "hash", "owner", "group", "uid",
+					"gid", "inode", "nlink", "device", "is_dir", "is_file", "is_symlink",
+					"is_hidden", "is_empty", "is_executable", "has_xattr",
+					"symlink_target", "abspath", "lines", "words", "mime", "kind",
+				},
+				err: nil,
+			},
+		},
+		{
+			input:    "format(*, kb)",
+			expected: Expected{err: errors.New("cannot apply modifier FORMAT to *")},
+		},
+		{
+			input:    "upper(format(all, kb))",
+			expected: Expected{err: errors.New("cannot apply modifier FORMAT to all")},
 		},
 		{
 			input:    "format(time, iso)",
 			expected: Expected{attributes: []string{"time"}, err: nil},
 		},
+		{
+			input: "extension, ext",
+			expected: Expected{
+				attributes: []string{"extension", "extension"},
+				err:        nil,
+			},
+		},
+		{
+			input:    "upper(ext)",
+			expected: Expected{attributes: []string{"extension"}, err: nil},
+		},
 
 		{
 			input:    "",
@@ -57,14 +105,20 @@ func TestAttributeParser_ExpectCorrectAttributes(t *testing.T) {
 			input:    "identifier",
 			expected: Expected{err: &ErrUnknownToken{"identifier"}},
 		},
+		{
+			input:    "name, content",
+			expected: Expected{err: errors.New("attribute content can only be used in WHERE")},
+		},
 	}
 
 	for _, c := range cases {
 		attributes := make([]string, 0)
 		modifiers := make(map[string][]query.Modifier)
+		aggregates := make(map[string]query.Aggregate)
+		aliases := make(map[string]string)
 
 		p := &parser{tokenizer: tokenizer.NewTokenizer(c.input)}
-		err := p.parseAttrs(&attributes, &modifiers)
+		err := p.parseAttrs(&attributes, &modifiers, &aggregates, &aliases)
 
 		if c.expected.err == nil {
 			if err != nil {
@@ -79,6 +133,91 @@ func TestAttributeParser_ExpectCorrectAttributes(t *testing.T) {
 	}
 }
 
+func TestAttributeParser_ExpectCorrectAggregates(t *testing.T) {
+	type Expected struct {
+		attributes []string
+		aggregates map[string]query.Aggregate
+		err        error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{
+			input: "COUNT(*)",
+			expected: Expected{
+				attributes: []string{"COUNT(*)"},
+				aggregates: map[string]query.Aggregate{
+					"COUNT(*)": {
+						Name:      "COUNT",
+						Attribute: "*",
+						Modifiers: []query.Modifier{},
+					},
+				},
+			},
+		},
+		{
+			input: "count(*), sum(size), AVG(LENGTH(name))",
+			expected: Expected{
+				attributes: []string{"COUNT(*)", "SUM(size)", "AVG(LENGTH(name))"},
+				aggregates: map[string]query.Aggregate{
+					"COUNT(*)": {
+						Name:      "COUNT",
+						Attribute: "*",
+						Modifiers: []query.Modifier{},
+					},
+					"SUM(size)": {
+						Name:      "SUM",
+						Attribute: "size",
+						Modifiers: []query.Modifier{},
+					},
+					"AVG(LENGTH(name))": {
+						Name:      "AVG",
+						Attribute: "name",
+						Modifiers: []query.Modifier{
+							{Name: "LENGTH", Arguments: []string{}},
+						},
+					},
+				},
+			},
+		},
+
+		{input: "SUM", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{input: "SUM(*)", expected: Expected{err: &ErrUnknownToken{"*"}}},
+		{
+			input:    "MAX(size",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+	}
+
+	for _, c := range cases {
+		attributes := make([]string, 0)
+		modifiers := make(map[string][]query.Modifier)
+		aggregates := make(map[string]query.Aggregate)
+		aliases := make(map[string]string)
+
+		p := &parser{tokenizer: tokenizer.NewTokenizer(c.input)}
+		err := p.parseAttrs(&attributes, &modifiers, &aggregates, &aliases)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.attributes, attributes) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.attributes, attributes)
+			}
+			if !reflect.DeepEqual(c.expected.aggregates, aggregates) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.aggregates, aggregates)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
 func TestAttributeParser_ExpectCorrectModifiers(t *testing.T) {
 	type Expected struct {
 		modifiers map[string][]query.Modifier
@@ -221,6 +360,28 @@ func TestAttributeParser_ExpectCorrectModifiers(t *testing.T) {
 			},
 		},
 
+		{
+			input: "substr(name, -3, 2)",
+			expected: Expected{
+				modifiers: map[string][]query.Modifier{
+					"name": {
+						{
+							Name:      "SUBSTR",
+							Arguments: []string{"-3", "2"},
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+		{
+			input:    "substr(name, -)",
+			expected: Expected{err: &ErrUnexpectedToken{Actual: tokenizer.CloseParen, Expected: tokenizer.Identifier}},
+		},
+		{
+			input:    "upper(name",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
 		{
 			input:    "",
 			expected: Expected{err: io.ErrUnexpectedEOF},
@@ -238,9 +399,11 @@ func TestAttributeParser_ExpectCorrectModifiers(t *testing.T) {
 	for _, c := range cases {
 		attributes := make([]string, 0)
 		modifiers := make(map[string][]query.Modifier)
+		aggregates := make(map[string]query.Aggregate)
+		aliases := make(map[string]string)
 
 		p := &parser{tokenizer: tokenizer.NewTokenizer(c.input)}
-		err := p.parseAttrs(&attributes, &modifiers)
+		err := p.parseAttrs(&attributes, &modifiers, &aggregates, &aliases)
 
 		if c.expected.err == nil {
 			if err != nil {
@@ -2,100 +2,137 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"os"
-
-	"gopkg.in/oleiade/lane.v1"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/kshvmdn/fsql/query"
 	"github.com/kshvmdn/fsql/tokenizer"
+	"github.com/kshvmdn/fsql/transform"
 )
 
-// parseConditionTree parses the condition tree passed to the WHERE clause.
-func (p *parser) parseConditionTree() (*query.ConditionNode, error) {
-	stack := lane.NewStack()
-	errFailedToParse := errors.New("failed to parse conditions")
+// errFailedToParse is returned for a malformed condition tree.
+var errFailedToParse = errors.New("failed to parse conditions")
 
-	for {
-		if p.current = p.tokenizer.Next(); p.current == nil {
-			break
-		}
+// parseConditionTree parses the condition tree passed to the WHERE clause. AND
+// binds tighter than OR (e.g. `a OR b AND c` is `a OR (b AND c)`), both are
+// left-associative, and parentheses may be used for grouping.
+func (p *parser) parseConditionTree() (*query.ConditionNode, error) {
+	root, err := p.parseDisjunction()
+	if err != nil {
+		return nil, err
+	}
 
+	// The condition tree ends at the GROUP BY / HAVING / ORDER BY / LIMIT /
+	// OFFSET / EXEC clause; leave the token for the respective parser function.
+	if p.current == nil {
+		p.current = p.tokenizer.Next()
+	}
+	if p.current != nil {
 		switch p.current.Type {
+		case tokenizer.GroupBy, tokenizer.Having, tokenizer.OrderBy, tokenizer.Limit,
+			tokenizer.Offset, tokenizer.Exec:
+		default:
+			return nil, errFailedToParse
+		}
+	}
+	return root, nil
+}
 
-		case tokenizer.Not:
-			// TODO: Handle NOT (...), for the time being we proceed with the other
-			// tokens and handle the negation when parsing the condition.
-			fallthrough
-
-		case tokenizer.Identifier:
-			condition, err := p.parseCondition()
-			if err != nil {
-				return nil, err
-			}
-			if condition == nil {
-				return nil, p.currentError()
-			}
-
-			if condition.IsSubquery {
-				if err := p.parseSubquery(condition); err != nil {
-					return nil, err
-				}
-			}
-
-			leafNode := &query.ConditionNode{Condition: condition}
-			if prevNode, ok := stack.Pop().(*query.ConditionNode); !ok {
-				stack.Push(leafNode)
-			} else if prevNode.Condition == nil {
-				prevNode.Right = leafNode
-				stack.Push(prevNode)
-			} else {
-				return nil, errFailedToParse
-			}
-
-		case tokenizer.And, tokenizer.Or:
-			leftNode, ok := stack.Pop().(*query.ConditionNode)
-			if !ok {
-				return nil, errFailedToParse
-			}
-
-			node := query.ConditionNode{
-				Type: &p.current.Type,
-				Left: leftNode,
-			}
-			stack.Push(&node)
-
-		case tokenizer.OpenParen:
-			stack.Push(nil)
+// parseDisjunction parses one or more conjunctions (see parseConjunction)
+// joined by OR.
+func (p *parser) parseDisjunction() (*query.ConditionNode, error) {
+	left, err := p.parseConjunction()
+	if err != nil {
+		return nil, err
+	}
+	for p.expect(tokenizer.Or) != nil {
+		right, err := p.parseConjunction()
+		if err != nil {
+			return nil, err
+		}
+		or := tokenizer.Or
+		left = &query.ConditionNode{Type: &or, Left: left, Right: right}
+	}
+	return left, nil
+}
 
-		case tokenizer.CloseParen:
-			rightNode, ok := stack.Pop().(*query.ConditionNode)
-			if !ok {
-				return nil, errFailedToParse
-			}
+// parseConjunction parses one or more operands (see parseOperand) joined by
+// AND.
+func (p *parser) parseConjunction() (*query.ConditionNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.expect(tokenizer.And) != nil {
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		and := tokenizer.And
+		left = &query.ConditionNode{Type: &and, Left: left, Right: right}
+	}
+	return left, nil
+}
 
-			if rootNode, ok := stack.Pop().(*query.ConditionNode); !ok {
-				stack.Push(rightNode)
-			} else {
-				rootNode.Right = rightNode
-				stack.Push(rootNode)
-			}
+// parseOperand parses either a single condition or a parenthesized condition
+// tree, each optionally prefixed by one or more NOTs. NOT binds tighter than
+// AND, and an even number of NOTs cancel out.
+func (p *parser) parseOperand() (*query.ConditionNode, error) {
+	negate := false
+	for p.expect(tokenizer.Not) != nil {
+		negate = !negate
+	}
 
+	if p.expect(tokenizer.OpenParen) != nil {
+		if p.expect(tokenizer.CloseParen) != nil {
+			return nil, errFailedToParse
+		}
+		node, err := p.parseDisjunction()
+		if err != nil {
+			return nil, err
+		}
+		if p.expect(tokenizer.CloseParen) == nil {
+			return nil, p.currentError()
 		}
+		if !negate {
+			return node, nil
+		}
+		if node.Condition != nil {
+			node.Condition.Negate = !node.Condition.Negate
+			return node, nil
+		}
+		if *node.Type == tokenizer.Not {
+			return node.Left, nil
+		}
+		not := tokenizer.Not
+		return &query.ConditionNode{Type: &not, Left: node}, nil
 	}
 
-	if stack.Size() == 0 {
+	parse := p.parseCondition
+	if p.grouped != nil {
+		parse = p.parseHavingCondition
+	}
+	condition, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	if condition == nil {
 		return nil, p.currentError()
 	}
 
-	if stack.Size() > 1 {
-		return nil, errFailedToParse
+	if negate {
+		condition.Negate = !condition.Negate
 	}
 
-	node, ok := stack.Pop().(*query.ConditionNode)
-	if !ok {
-		return nil, errFailedToParse
+	if condition.IsSubquery {
+		if err := p.parseSubquery(condition); err != nil {
+			return nil, err
+		}
 	}
-	return node, nil
+	return &query.ConditionNode{Condition: condition}, nil
 }
 
 // parseCondition parses and returns the next condition.
@@ -121,67 +158,242 @@ func (p *parser) parseCondition() (*query.Condition, error) {
 	cond.Attribute = attr.Raw
 	cond.AttributeModifiers = modifiers
 
-	// If this condition has modifiers, then p.current was unset while parsing
-	// the modifier, se we set the current token manually.
-	if len(modifiers) > 0 {
+	// The unit of a size may be separated from it (e.g. `size > 1 mb`).
+	units := cond.Attribute == "size" && len(cond.AttributeModifiers) == 0
+	return p.parseComparison(cond, units)
+}
+
+// parseComparison parses the operator and value(s) of cond, following its
+// attribute. If units is true, each value may be followed by a size unit (see
+// expectValue).
+func (p *parser) parseComparison(cond *query.Condition, units bool) (*query.Condition, error) {
+	// If the attribute has modifiers (or is an aggregate), then p.current was
+	// unset while parsing its closing paren, so we set the current token
+	// manually.
+	if p.current == nil {
 		p.current = p.tokenizer.Next()
 	}
+	// A boolean attribute without an operator (e.g. `is_hidden`) is shorthand
+	// for `= true`; leave the next token for the caller.
+	if isBooleanAttribute(cond.Attribute) && endsCondition(p.current) {
+		cond.Operator = tokenizer.Equals
+		cond.Value = "true"
+		return cond, nil
+	}
 	if p.current == nil {
 		return nil, p.currentError()
 	}
+
+	// A NOT before the operator (e.g. `name NOT LIKE ...`) also negates the
+	// condition.
+	if p.current.Type == tokenizer.Not {
+		cond.Negate = !cond.Negate
+		if p.current = p.tokenizer.Next(); p.current == nil {
+			return nil, p.currentError()
+		}
+	}
 	cond.Operator = p.current.Type
 	p.current = nil
 
-	// Parse subquery of format `(...)`.
+	// Parse subquery or list of values of format `(...)`.
 	if p.expect(tokenizer.OpenParen) != nil {
-		token := p.expect(tokenizer.Subquery)
-		if token == nil {
+		if token := p.expect(tokenizer.Subquery); token != nil {
+			cond.IsSubquery = true
+			cond.Value = token.Raw
+			if p.expect(tokenizer.CloseParen) == nil {
+				return nil, p.currentError()
+			}
+			return cond, nil
+		}
+
+		if cond.Operator != tokenizer.In {
+			return nil, fmt.Errorf("unexpected list of values for operator %s",
+				cond.Operator.String())
+		}
+		values, err := p.parseValueList(tokenizer.CloseParen, units)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return nil, errors.New("expected at least one value for operator in")
+		}
+		cond.Value = values
+		return cond, nil
+	}
+
+	// Parse the bounds of format `low AND high`.
+	if cond.Operator == tokenizer.Between {
+		low := p.expectValue(units)
+		if low == nil {
 			return nil, p.currentError()
 		}
-		cond.IsSubquery = true
-		cond.Value = token.Raw
-		if p.expect(tokenizer.CloseParen) == nil {
+		if p.expect(tokenizer.And) == nil {
 			return nil, p.currentError()
 		}
+		high := p.expectValue(units)
+		if high == nil {
+			return nil, p.currentError()
+		}
+		cond.Value = []interface{}{low.Raw, high.Raw}
 		return cond, nil
 	}
 
 	// Parse list of values of format `[...]`.
 	if p.expect(tokenizer.OpenBracket) != nil {
-		values := make([]string, 0)
-		for {
-			if token := p.expect(tokenizer.Identifier); token != nil {
-				values = append(values, token.Raw)
-			}
-			if p.expect(tokenizer.Comma) != nil {
-				continue
-			}
-			if p.expect(tokenizer.CloseBracket) != nil {
-				break
-			}
+		values, err := p.parseValueList(tokenizer.CloseBracket, units)
+		if err != nil {
+			return nil, err
 		}
 		cond.Value = values
 		return cond, nil
 	}
 
 	// Not a list nor a subquery -> plain identifier!
-	token := p.expect(tokenizer.Identifier)
+	token := p.expectValue(units)
 	if token == nil {
 		return nil, p.currentError()
 	}
 	cond.Value = token.Raw
+
+	// Regular expressions are validated up front instead of failing for each
+	// file.
+	if cond.Operator == tokenizer.RLike {
+		if _, err := regexp.Compile(token.Raw); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s for operator %s: %v",
+				token.Raw, cond.Operator.String(), err)
+		}
+	}
+	if cond.Operator == tokenizer.Glob {
+		if _, err := filepath.Match(token.Raw, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s for operator %s: %v",
+				token.Raw, cond.Operator.String(), err)
+		}
+	}
 	return cond, nil
 }
 
+// endsCondition returns true iff token may directly follow a condition, i.e.
+// it's the end of the query or of the WHERE clause, a closing paren, or a
+// conjunction.
+func endsCondition(token *tokenizer.Token) bool {
+	if token == nil {
+		return true
+	}
+	switch token.Type {
+	case tokenizer.And, tokenizer.Or, tokenizer.CloseParen, tokenizer.GroupBy,
+		tokenizer.Having, tokenizer.OrderBy, tokenizer.Limit, tokenizer.Offset,
+		tokenizer.Exec:
+		return true
+	}
+	return false
+}
+
+// parseValueList parses a comma-separated list of values, up to and including
+// the closing token end. If units is true, each value may be followed by a
+// size unit (see expectValue).
+func (p *parser) parseValueList(end tokenizer.TokenType, units bool) ([]string, error) {
+	values := make([]string, 0)
+	for {
+		if token := p.expectValue(units); token != nil {
+			values = append(values, token.Raw)
+		}
+		if p.expect(tokenizer.Comma) != nil {
+			continue
+		}
+		if p.expect(end) != nil {
+			return values, nil
+		}
+		return nil, p.currentError()
+	}
+}
+
+// expectValue expects a single value (see expect), which may be preceded by a
+// hyphen (e.g. `-7d`), or be a time function (see expectTimeFunction). If
+// units is true, the value may be followed by a size unit as a separate
+// identifier (e.g. `1 mb`), which is joined to it.
+func (p *parser) expectValue(units bool) *tokenizer.Token {
+	negative := p.expect(tokenizer.Hyphen) != nil
+	token := p.expect(tokenizer.Identifier)
+	if token != nil && negative {
+		token = &tokenizer.Token{Type: token.Type, Raw: "-" + token.Raw}
+	} else if token != nil && isTimeFunction(token.Raw) {
+		return p.expectTimeFunction(token)
+	}
+	if token == nil || !units {
+		return token
+	}
+	if unit := p.expect(tokenizer.Identifier); unit != nil {
+		if !transform.IsSizeUnit(unit.Raw) {
+			p.current = unit
+			return token
+		}
+		return &tokenizer.Token{Type: token.Type, Raw: token.Raw + unit.Raw}
+	}
+	return token
+}
+
+// timeFunctions holds the names of the functions which may be used as a time
+// value, relative to when the query is run.
+var timeFunctions = []string{"now", "today"}
+
+func isTimeFunction(name string) bool {
+	for _, fn := range timeFunctions {
+		if strings.EqualFold(name, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// expectTimeFunction expects the parentheses of the time function ident (e.g.
+// `now()`), optionally followed by an offset (e.g. `now() - 1h` or `today() +
+// 9h`), and returns the equivalent relative time value (e.g. `now-1h`). If
+// ident isn't followed by an open paren, it's a value of its own (e.g. `now`).
+func (p *parser) expectTimeFunction(ident *tokenizer.Token) *tokenizer.Token {
+	if p.expect(tokenizer.OpenParen) == nil {
+		return ident
+	}
+	if p.expect(tokenizer.CloseParen) == nil {
+		return nil
+	}
+
+	raw := strings.ToLower(ident.Raw)
+	if p.expect(tokenizer.Hyphen) != nil {
+		offset := p.expect(tokenizer.Identifier)
+		if offset == nil {
+			return nil
+		}
+		raw += "-" + offset.Raw
+	} else if sign := p.expect(tokenizer.Identifier); sign != nil {
+		switch {
+		case sign.Raw == "+":
+			offset := p.expect(tokenizer.Identifier)
+			if offset == nil {
+				return nil
+			}
+			raw += "+" + offset.Raw
+		case strings.HasPrefix(sign.Raw, "+"):
+			raw += sign.Raw
+		default:
+			p.current = sign
+		}
+	}
+	return &tokenizer.Token{Type: tokenizer.Identifier, Raw: raw}
+}
+
 // parseSubquery parses a subquery by recursively evaluating it's condition(s).
 // If the subquery contains references to aliases from the superquery, it's
 // Subquery attribute is set. Otherwise, we evaluate it's Subquery and set
 // it's Value to the result.
 func (p *parser) parseSubquery(condition *query.Condition) error {
-	q, err := Run(condition.Value.(string))
+	q, err := (&parser{now: p.now}).parse(condition.Value.(string))
 	if err != nil {
 		return err
 	}
+	if len(q.Exec) > 0 {
+		return errors.New("cannot use EXEC in a subquery")
+	}
+	p.warnings = append(p.warnings, q.Warnings...)
 
 	// If the subquery has aliases, we'll have to parse the subquery against
 	// each file, so we don't do anything here.
@@ -192,7 +404,12 @@ func (p *parser) parseSubquery(condition *query.Condition) error {
 
 	value := make(map[interface{}]bool, 0)
 	workFunc := func(path string, info os.FileInfo, res map[string]interface{}) {
-		for _, attr := range [...]string{"name", "size", "time", "mode"} {
+		for _, attr := range [...]string{
+			"name", "extension", "size", "depth", "time", "accessed", "changed",
+			"created", "mode", "owner", "group", "uid", "gid", "inode", "nlink",
+			"device", "symlink_target", "path", "abspath", "lines", "words", "mime",
+			"kind",
+		} {
 			if q.HasAttribute(attr) {
 				value[res[attr]] = true
 				return
@@ -34,6 +34,32 @@ func TestConditionParser_ExpectCorrectCondition(t *testing.T) {
 			},
 		},
 
+		{
+			input: "name NOT LIKE foo%",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "name",
+					Operator:  tokenizer.Like,
+					Value:     "foo%",
+					Negate:    true,
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "NOT name NOT ILIKE foo%",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "name",
+					Operator:  tokenizer.ILike,
+					Value:     "foo%",
+					Negate:    false,
+				},
+				err: nil,
+			},
+		},
+
 		{
 			input: "size = 10",
 			expected: Expected{
@@ -107,6 +133,109 @@ func TestConditionParser_ExpectCorrectCondition(t *testing.T) {
 			},
 		},
 
+		{
+			input: "ext IN (go, 'mod', sum)",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "extension",
+					Operator:  tokenizer.In,
+					Value:     []string{"go", "mod", "sum"},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "size NOT IN (0, 1024)",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "size",
+					Operator:  tokenizer.In,
+					Value:     []string{"0", "1024"},
+					Negate:    true,
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "size NOT BETWEEN 1 AND 10",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "size",
+					Operator:  tokenizer.Between,
+					Value:     []interface{}{"1", "10"},
+					Negate:    true,
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "size BETWEEN 1 10",
+			expected: Expected{
+				err: &ErrUnexpectedToken{
+					Actual:   tokenizer.Identifier,
+					Expected: tokenizer.And,
+				},
+			},
+		},
+
+		{
+			input:    "name IN ()",
+			expected: Expected{err: errors.New("expected at least one value for operator in")},
+		},
+
+		{
+			input:    "name = (foo, bar)",
+			expected: Expected{err: errors.New("unexpected list of values for operator equal")},
+		},
+
+		{
+			input:    "name IN (foo",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+
+		{
+			input: "name =~ '^test_.*\\.go$'",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "name",
+					Operator:  tokenizer.RLike,
+					Value:     "^test_.*\\.go$",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "name =~ '[a-'",
+			expected: Expected{
+				err: errors.New("invalid pattern [a- for operator RLike: " +
+					"error parsing regexp: missing closing ]: `[a-`"),
+			},
+		},
+
+		{
+			input: "name ~ '*.go'",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "name",
+					Operator:  tokenizer.Glob,
+					Value:     "*.go",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "name GLOB '[a-'",
+			expected: Expected{
+				err: errors.New("invalid pattern [a- for operator glob: " +
+					"syntax error in pattern"),
+			},
+		},
+
 		// No attribute-operator validation yet (these 3 should /eventually/ throw
 		// some error)!
 		{
@@ -145,6 +274,129 @@ func TestConditionParser_ExpectCorrectCondition(t *testing.T) {
 			},
 		},
 
+		{
+			input: "size > 1 mb",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "size",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "1mb",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "size IN (1 kb, 2kib, 3)",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "size",
+					Operator:  tokenizer.In,
+					Value:     []string{"1kb", "2kib", "3"},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "time > -7d",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "time",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "-7d",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "time BETWEEN -2w AND now-1d",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "time",
+					Operator:  tokenizer.Between,
+					Value:     []interface{}{"-2w", "now-1d"},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input:    "time > -",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+
+		{
+			input: "time > now()",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "time",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "now",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "time > NOW() - 1h",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "time",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "now-1h",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "created > today()+9h",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "created",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "today+9h",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "time < today() + 1d",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "time",
+					Operator:  tokenizer.LessThan,
+					Value:     "today+1d",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "time > now",
+			expected: Expected{
+				condition: &query.Condition{
+					Attribute: "time",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "now",
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input:    "time > now(",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+
+		{
+			input:    "time > now() -",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+
 		{
 			input:    "name =",
 			expected: Expected{err: io.ErrUnexpectedEOF},
@@ -189,6 +441,7 @@ func TestConditionParser_ExpectCorrectConditionTree(t *testing.T) {
 	var (
 		tmpAnd = tokenizer.And
 		tmpOr  = tokenizer.Or
+		tmpNot = tokenizer.Not
 	)
 
 	cases := []Case{
@@ -342,21 +595,224 @@ func TestConditionParser_ExpectCorrectConditionTree(t *testing.T) {
 		{
 			input: "name = foo AND NOT (name = bar OR name = baz)",
 			expected: Expected{
-				node: &query.ConditionNode{},
-				err: &ErrUnexpectedToken{
-					Expected: tokenizer.Identifier,
-					Actual:   tokenizer.OpenParen,
+				node: &query.ConditionNode{
+					Type: &tmpAnd,
+					Left: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "name",
+							Operator:  tokenizer.Equals,
+							Value:     "foo",
+						},
+					},
+					Right: &query.ConditionNode{
+						Type: &tmpNot,
+						Left: &query.ConditionNode{
+							Type: &tmpOr,
+							Left: &query.ConditionNode{
+								Condition: &query.Condition{
+									Attribute: "name",
+									Operator:  tokenizer.Equals,
+									Value:     "bar",
+								},
+							},
+							Right: &query.ConditionNode{
+								Condition: &query.Condition{
+									Attribute: "name",
+									Operator:  tokenizer.Equals,
+									Value:     "baz",
+								},
+							},
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "NOT NOT (name = foo OR name = bar)",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Type: &tmpOr,
+					Left: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "name",
+							Operator:  tokenizer.Equals,
+							Value:     "foo",
+						},
+					},
+					Right: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "name",
+							Operator:  tokenizer.Equals,
+							Value:     "bar",
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "NOT (NOT (name = foo OR name = bar))",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Type: &tmpOr,
+					Left: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "name",
+							Operator:  tokenizer.Equals,
+							Value:     "foo",
+						},
+					},
+					Right: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "name",
+							Operator:  tokenizer.Equals,
+							Value:     "bar",
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "NOT NOT name = foo",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Condition: &query.Condition{
+						Attribute: "name",
+						Operator:  tokenizer.Equals,
+						Value:     "foo",
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "NOT (extension = tmp)",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Condition: &query.Condition{
+						Attribute: "extension",
+						Operator:  tokenizer.Equals,
+						Value:     "tmp",
+						Negate:    true,
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "NOT is_hidden AND is_empty",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Type: &tmpAnd,
+					Left: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "is_hidden",
+							Operator:  tokenizer.Equals,
+							Value:     "true",
+							Negate:    true,
+						},
+					},
+					Right: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "is_empty",
+							Operator:  tokenizer.Equals,
+							Value:     "true",
+						},
+					},
 				},
+				err: nil,
 			},
 		},
 
+		{input: "NOT", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{input: "name", expected: Expected{err: io.ErrUnexpectedEOF}},
+
 		{
 			input:    "size = 5 AND ()",
 			expected: Expected{err: errors.New("failed to parse conditions")},
 		},
 
-		// FIXME: The following case /should/ throw EOF (it doesn't right now).
-		// Case{input: "name = foo AND", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{
+			input: "name = foo OR name = bar AND size = 5",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Type: &tmpOr,
+					Left: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "name",
+							Operator:  tokenizer.Equals,
+							Value:     "foo",
+						},
+					},
+					Right: &query.ConditionNode{
+						Type: &tmpAnd,
+						Left: &query.ConditionNode{
+							Condition: &query.Condition{
+								Attribute: "name",
+								Operator:  tokenizer.Equals,
+								Value:     "bar",
+							},
+						},
+						Right: &query.ConditionNode{
+							Condition: &query.Condition{
+								Attribute: "size",
+								Operator:  tokenizer.Equals,
+								Value:     "5",
+							},
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{
+			input: "(name = foo OR name = bar) AND size = 5",
+			expected: Expected{
+				node: &query.ConditionNode{
+					Type: &tmpAnd,
+					Left: &query.ConditionNode{
+						Type: &tmpOr,
+						Left: &query.ConditionNode{
+							Condition: &query.Condition{
+								Attribute: "name",
+								Operator:  tokenizer.Equals,
+								Value:     "foo",
+							},
+						},
+						Right: &query.ConditionNode{
+							Condition: &query.Condition{
+								Attribute: "name",
+								Operator:  tokenizer.Equals,
+								Value:     "bar",
+							},
+						},
+					},
+					Right: &query.ConditionNode{
+						Condition: &query.Condition{
+							Attribute: "size",
+							Operator:  tokenizer.Equals,
+							Value:     "5",
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+
+		{input: "name = foo AND", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{input: "(name = foo", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{
+			input:    "name = foo name = bar",
+			expected: Expected{err: errors.New("failed to parse conditions")},
+		},
 	}
 
 	for _, c := range cases {
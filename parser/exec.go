@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/kshvmdn/fsql/query"
+	"github.com/kshvmdn/fsql/tokenizer"
+)
+
+// parseExecClause parses the EXEC clause of the query, i.e. the command which
+// is run for the results. The path of each result is substituted for `{}`, so
+// the command has to contain it.
+func (p *parser) parseExecClause(q *query.Query) error {
+	if p.expect(tokenizer.Exec) == nil {
+		return nil
+	}
+
+	token := p.expect(tokenizer.Command)
+	if token == nil {
+		return p.currentError()
+	}
+	args, err := splitCommand(token.Raw)
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		if strings.Contains(arg, "{}") {
+			q.Exec = args
+			return nil
+		}
+	}
+	return errors.New("expected {} in EXEC command")
+}
+
+// splitCommand splits a command into its arguments, separated by whitespace
+// like in a shell. Single quotes preserve the enclosed characters verbatim;
+// within double quotes (and outside of quotes), a backslash escapes the
+// following character.
+func splitCommand(command string) ([]string, error) {
+	var (
+		args   []string
+		arg    []rune
+		inArg  bool
+		quote  rune
+		escape bool
+	)
+	for _, r := range command {
+		switch {
+		case escape:
+			arg = append(arg, r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg = append(arg, r)
+			}
+		case r == '\\':
+			inArg, escape = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg = append(arg, r)
+			}
+		case r == '\'' || r == '"':
+			inArg, quote = true, r
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, string(arg))
+				arg, inArg = arg[:0], false
+			}
+		default:
+			arg, inArg = append(arg, r), true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c in EXEC command", quote)
+	}
+	if escape {
+		return nil, errors.New("unterminated \\ in EXEC command")
+	}
+	if inArg {
+		args = append(args, string(arg))
+	}
+	if len(args) == 0 {
+		return nil, errors.New("expected a command for EXEC")
+	}
+	return args, nil
+}
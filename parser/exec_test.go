@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/kshvmdn/fsql/query"
+	"github.com/kshvmdn/fsql/tokenizer"
+)
+
+func TestExecParser_ParseExec(t *testing.T) {
+	type Expected struct {
+		exec []string
+		err  error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{input: "", expected: Expected{exec: nil}},
+		{input: "EXEC rm {}", expected: Expected{exec: []string{"rm", "{}"}}},
+		{
+			input:    "exec   mv -n {} '/tmp/old files/'  ",
+			expected: Expected{exec: []string{"mv", "-n", "{}", "/tmp/old files/"}},
+		},
+		{
+			input:    `EXEC sh -c "echo \"{}\"" 'it''s' a\ b`,
+			expected: Expected{exec: []string{"sh", "-c", `echo "{}"`, "its", "a b"}},
+		},
+		{
+			input:    "EXEC cp {} {}.bak",
+			expected: Expected{exec: []string{"cp", "{}", "{}.bak"}},
+		},
+		{input: "EXEC rm {};", expected: Expected{exec: []string{"rm", "{}"}}},
+		{input: "EXEC rm {} ; \n", expected: Expected{exec: []string{"rm", "{}"}}},
+		{input: `EXEC echo {} \;`, expected: Expected{exec: []string{"echo", "{}", ";"}}},
+		{input: "EXEC echo {} ';'", expected: Expected{exec: []string{"echo", "{}", ";"}}},
+
+		{input: "EXEC", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{input: "EXEC ;", expected: Expected{err: errors.New("expected a command for EXEC")}},
+		{input: "EXEC rm", expected: Expected{err: errors.New("expected {} in EXEC command")}},
+		{
+			input:    "EXEC rm '{}",
+			expected: Expected{err: errors.New("unterminated ' in EXEC command")},
+		},
+		{
+			input:    `EXEC rm {} \`,
+			expected: Expected{err: errors.New("unterminated \\ in EXEC command")},
+		},
+	}
+
+	for _, c := range cases {
+		q := query.NewQuery()
+		err := (&parser{tokenizer: tokenizer.NewTokenizer(c.input)}).parseExecClause(q)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.exec, q.Exec) {
+				t.Fatalf("\nExpected %q\n     Got %q", c.expected.exec, q.Exec)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package parser
 
 import (
-	"os/user"
-	"path/filepath"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kshvmdn/fsql/query"
 	"github.com/kshvmdn/fsql/tokenizer"
@@ -11,18 +14,31 @@ import (
 
 // Run parses the input string and returns the parsed AST (query).
 func Run(input string) (*query.Query, error) {
-	return (&parser{}).parse(input)
+	return (&parser{now: time.Now()}).parse(input)
 }
 
 type parser struct {
 	tokenizer *tokenizer.Tokenizer
 	current   *tokenizer.Token
 	expected  tokenizer.TokenType
+
+	// warnings holds the warnings of the query (and its subqueries), see
+	// query.Query.Warnings.
+	warnings []string
+
+	// grouped is the query whose HAVING clause is being parsed, if any, in
+	// which case conditions are parsed by parseHavingCondition.
+	grouped *query.Query
+
+	// now is the moment the relative times of the query (and its subqueries)
+	// are relative to, see query.Query.Now.
+	now time.Time
 }
 
 // parse runs the respective parser function on each clause of the query.
 func (p *parser) parse(input string) (*query.Query, error) {
 	q := query.NewQuery()
+	q.Now = p.now
 	p.tokenizer = tokenizer.NewTokenizer(input)
 	if err := p.parseSelectClause(q); err != nil {
 		return nil, err
@@ -30,9 +46,31 @@ func (p *parser) parse(input string) (*query.Query, error) {
 	if err := p.parseFromClause(q); err != nil {
 		return nil, err
 	}
+	if err := p.parseExcludeClause(q); err != nil {
+		return nil, err
+	}
 	if err := p.parseWhereClause(q); err != nil {
 		return nil, err
 	}
+	if err := p.parseGroupByClause(q); err != nil {
+		return nil, err
+	}
+	if err := p.parseHavingClause(q); err != nil {
+		return nil, err
+	}
+	if err := p.parseOrderByClause(q); err != nil {
+		return nil, err
+	}
+	if err := p.parseLimitClause(q); err != nil {
+		return nil, err
+	}
+	if err := p.parseExecClause(q); err != nil {
+		return nil, err
+	}
+	if err := validateGrouping(q); err != nil {
+		return nil, err
+	}
+	q.Warnings = p.warnings
 	return q, nil
 }
 
@@ -53,14 +91,20 @@ func (p *parser) parseSelectClause(q *query.Query) error {
 			// input.
 			return p.currentError()
 		}
-	} else if current := p.expect(tokenizer.Identifier); current != nil {
-		p.current = current
-		showAll = false
+	} else {
+		if p.expect(tokenizer.Distinct) != nil {
+			q.Distinct = true
+		}
+		if current := p.expect(tokenizer.Identifier); current != nil {
+			p.current = current
+			showAll = false
+		}
 	}
 
 	if showAll {
-		q.Attributes = allAttributes
-	} else if err := p.parseAttrs(&q.Attributes, &q.Modifiers); err != nil {
+		q.Attributes = defaultAttributes
+	} else if err := p.parseAttrs(&q.Attributes, &q.Modifiers, &q.Aggregates,
+		&q.AttributeAliases); err != nil {
 		return err
 	}
 
@@ -81,25 +125,68 @@ func (p *parser) parseFromClause(q *query.Query) error {
 		return nil
 	}
 
-	if err := p.parseSourceList(&q.Sources, &q.SourceAliases); err != nil {
-		return err
+	if p.expect(tokenizer.OpenParen) != nil {
+		return p.parseSourceQuery(q)
+	}
+
+	return p.parseSourceList(&q.Sources, &q.SourceAliases)
+}
+
+// parseSourceQuery parses a subquery in the FROM clause (following the open
+// paren), whose results are the files of the query. The subquery has to find
+// individual files, so it can't have aggregates or a GROUP BY clause.
+func (p *parser) parseSourceQuery(q *query.Query) error {
+	token := p.expect(tokenizer.Subquery)
+	if token == nil {
+		return p.currentError()
+	}
+	if p.expect(tokenizer.CloseParen) == nil {
+		return p.currentError()
 	}
 
-	// Replace the tilde with the home directory in each source directory. This
-	// is only required when the query is wrapped in quotes, since the shell
-	// will automatically expand tildes otherwise.
-	u, err := user.Current()
+	subquery, err := (&parser{now: p.now}).parse(token.Raw)
 	if err != nil {
 		return err
 	}
-	for _, sourceType := range []string{"include", "exclude"} {
-		for i, src := range q.Sources[sourceType] {
-			if strings.Contains(src, "~") {
-				q.Sources[sourceType][i] = filepath.Join(u.HomeDir, src[1:])
-			}
+	if len(subquery.Aggregates) > 0 || len(subquery.GroupBy) > 0 {
+		return errors.New("cannot use aggregates or GROUP BY in a subquery in FROM")
+	}
+	if len(subquery.Exec) > 0 {
+		return errors.New("cannot use EXEC in a subquery")
+	}
+	q.SourceQuery = subquery
+	p.warnings = append(p.warnings, subquery.Warnings...)
+
+	if p.expect(tokenizer.Exclude) != nil {
+		return errors.New("cannot EXCLUDE from a subquery in FROM, use EXCLUDE in the subquery instead")
+	}
+	return nil
+}
+
+// parseExcludeClause parses the EXCLUDE clause of the query, which is either a
+// single pattern or a parenthesized list of patterns.
+func (p *parser) parseExcludeClause(q *query.Query) error {
+	if p.expect(tokenizer.Exclude) == nil {
+		return nil
+	}
+
+	if p.expect(tokenizer.OpenParen) == nil {
+		pattern := p.expect(tokenizer.Identifier)
+		if pattern == nil {
+			return p.currentError()
 		}
+		q.Exclude = append(q.Exclude, pattern.Raw)
+		return nil
 	}
 
+	patterns, err := p.parseValueList(tokenizer.CloseParen, false)
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		return errors.New("expected at least one pattern for EXCLUDE")
+	}
+	q.Exclude = append(q.Exclude, patterns...)
 	return nil
 }
 
@@ -121,6 +208,322 @@ func (p *parser) parseWhereClause(q *query.Query) error {
 	return nil
 }
 
+// parseGroupByClause parses the GROUP BY clause of the query. Each key is an
+// attribute (optionally with modifiers).
+func (p *parser) parseGroupByClause(q *query.Query) error {
+	if p.expect(tokenizer.GroupBy) == nil {
+		return nil
+	}
+
+	for {
+		modifiers := make([]query.Modifier, 0)
+		attribute, err := p.parseSelectableAttr(&modifiers)
+		if err != nil {
+			return err
+		}
+		q.GroupBy = append(q.GroupBy, query.GroupKey{
+			Attribute: attribute.Raw,
+			Modifiers: modifiers,
+		})
+
+		if p.expect(tokenizer.Comma) == nil {
+			break
+		}
+	}
+	return nil
+}
+
+// parseHavingClause parses the HAVING clause of the query, whose conditions
+// (see parseHavingCondition) filter the query's groups.
+func (p *parser) parseHavingClause(q *query.Query) error {
+	if p.expect(tokenizer.Having) == nil {
+		return nil
+	}
+	if len(q.Aggregates) == 0 && len(q.GroupBy) == 0 {
+		return errors.New("cannot use HAVING without aggregates or GROUP BY")
+	}
+
+	p.grouped = q
+	defer func() { p.grouped = nil }()
+	root, err := p.parseConditionTree()
+	if err != nil {
+		return err
+	}
+	q.Having = root
+	return nil
+}
+
+// parseHavingCondition parses and returns the next condition of the HAVING
+// clause of p.grouped. Its attribute is either an aggregate (which needn't be
+// selected, e.g. `HAVING COUNT(*) > 1`), a GROUP BY key (with the same
+// modifiers), or the alias of either. The condition's attribute is then the
+// aggregate's column or the key's attribute, respectively.
+func (p *parser) parseHavingCondition() (*query.Condition, error) {
+	q := p.grouped
+	cond := &query.Condition{}
+	if p.expect(tokenizer.Not) != nil {
+		cond.Negate = true
+	}
+
+	ident := p.expect(tokenizer.Identifier)
+	if ident == nil {
+		return nil, p.currentError()
+	}
+
+	if attribute, ok := aliasedAttribute(q, ident.Raw); ok {
+		if _, ok := q.Aggregates[attribute]; ok {
+			cond.Attribute = attribute
+		} else if key := groupKey(q, attribute, nil); key != nil {
+			cond.Attribute, cond.AttributeModifiers = key.Attribute, key.Modifiers
+		} else {
+			return nil, fmt.Errorf("cannot use %s in HAVING: expected an aggregate or GROUP BY key",
+				ident.Raw)
+		}
+	} else if isAggregateFunction(ident.Raw) {
+		aggregate, err := p.parseAggregate(ident)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := q.Aggregates[aggregate.String()]; !ok {
+			if q.Aggregates == nil {
+				q.Aggregates = make(map[string]query.Aggregate)
+			}
+			q.Aggregates[aggregate.String()] = *aggregate
+		}
+		cond.Attribute = aggregate.String()
+	} else {
+		p.current = ident
+		modifiers := make([]query.Modifier, 0)
+		attribute, err := p.parseSelectableAttr(&modifiers)
+		if err != nil {
+			return nil, err
+		}
+		key := groupKey(q, attribute.Raw, modifiers)
+		if key == nil {
+			return nil, fmt.Errorf("cannot use %s in HAVING: expected an aggregate or GROUP BY key",
+				attribute.Raw)
+		}
+		cond.Attribute, cond.AttributeModifiers = key.Attribute, key.Modifiers
+	}
+
+	// The unit of a size may be separated from it (e.g. `SUM(size) > 1 gb`).
+	units := cond.Attribute == "size" && len(cond.AttributeModifiers) == 0
+	if aggregate, ok := q.Aggregates[cond.Attribute]; ok {
+		units = aggregate.Name != "COUNT" && aggregate.Attribute == "size" &&
+			len(aggregate.Modifiers) == 0
+	}
+	cond, err := p.parseComparison(cond, units)
+	if err != nil {
+		return nil, err
+	}
+	if cond.IsSubquery {
+		return nil, errors.New("cannot use a subquery in HAVING")
+	}
+	switch cond.Operator {
+	case tokenizer.Equals, tokenizer.NotEquals, tokenizer.GreaterThanEquals,
+		tokenizer.GreaterThan, tokenizer.LessThanEquals, tokenizer.LessThan,
+		tokenizer.In, tokenizer.Between:
+	default:
+		return nil, fmt.Errorf("unsupported operator %s in HAVING", cond.Operator.String())
+	}
+	return cond, nil
+}
+
+// groupKey returns the GROUP BY key of q with the given attribute and
+// modifiers, if any. If modifiers is nil, any key of the attribute matches.
+func groupKey(q *query.Query, attribute string, modifiers []query.Modifier) *query.GroupKey {
+	for i, key := range q.GroupBy {
+		if key.Attribute != attribute {
+			continue
+		}
+		if modifiers == nil || sameModifiers(modifiers, key.Modifiers) {
+			return &q.GroupBy[i]
+		}
+	}
+	return nil
+}
+
+// sameModifiers returns true iff a and b are the same modifiers, in the same
+// order (e.g. both are `UPPER` of `FORMAT(kb)`, or there are none).
+func sameModifiers(a, b []query.Modifier) bool {
+	return len(a) == len(b) && (len(a) == 0 || reflect.DeepEqual(a, b))
+}
+
+// validateGrouping ensures that a query with aggregates or a GROUP BY clause
+// only SELECTs and ORDERs BY values that are defined for each group, and
+// doesn't EXEC a command (since groups don't have a path).
+func validateGrouping(q *query.Query) error {
+	if len(q.Aggregates) == 0 && len(q.GroupBy) == 0 {
+		return nil
+	}
+	if len(q.Exec) > 0 {
+		return errors.New("cannot use EXEC with aggregates or GROUP BY")
+	}
+
+	for _, attribute := range q.Attributes {
+		if _, ok := q.Aggregates[attribute]; ok {
+			continue
+		}
+		// The attribute must be selected with the modifiers of its key (and
+		// optionally more), since e.g. `UPPER(extension)` is the same for each
+		// file of a group of `UPPER(extension)` or `extension`, but not of
+		// `LOWER(extension)`.
+		modifiers := q.Modifiers[attribute]
+		grouped, ambiguous := false, false
+		for _, key := range q.GroupBy {
+			if key.Attribute != attribute {
+				continue
+			}
+			if len(key.Modifiers) <= len(modifiers) &&
+				sameModifiers(key.Modifiers, modifiers[:len(key.Modifiers)]) {
+				grouped = true
+			} else {
+				ambiguous = true
+			}
+		}
+		if grouped {
+			continue
+		}
+		if ambiguous {
+			return fmt.Errorf("attribute %s must be selected with the same modifiers as in GROUP BY",
+				attribute)
+		}
+		return fmt.Errorf("attribute %s must be aggregated or used in GROUP BY",
+			attribute)
+	}
+
+	for _, key := range q.OrderBy {
+		if !q.HasAttribute(key.Attribute) || len(key.Modifiers) > 0 {
+			return fmt.Errorf("cannot ORDER BY %s: expected a selected attribute or aggregate",
+				key.Attribute)
+		}
+	}
+	return nil
+}
+
+// parseOrderByClause parses the ORDER BY clause of the query. Each key is an
+// attribute (optionally with modifiers), followed by an optional ASC / DESC
+// direction.
+func (p *parser) parseOrderByClause(q *query.Query) error {
+	if p.expect(tokenizer.OrderBy) == nil {
+		return nil
+	}
+
+	for {
+		key, err := p.parseOrderKey(q)
+		if err != nil {
+			return err
+		}
+
+		if token := p.expect(tokenizer.Identifier); token != nil {
+			switch strings.ToUpper(token.Raw) {
+			case "ASC":
+			case "DESC":
+				key.Descending = true
+			default:
+				return &ErrUnknownToken{token.Raw}
+			}
+		}
+		q.OrderBy = append(q.OrderBy, *key)
+
+		if p.expect(tokenizer.Comma) == nil {
+			break
+		}
+	}
+
+	if p.expect(tokenizer.Identifier) != nil {
+		return p.currentError()
+	}
+	return nil
+}
+
+// parseOrderKey parses a single ORDER BY key (excluding its direction), which
+// is either an attribute (optionally with modifiers), one of the query's
+// aggregates, or the alias of a selected attribute or aggregate. Aliases take
+// precedence over attributes of the same name.
+func (p *parser) parseOrderKey(q *query.Query) (*query.OrderKey, error) {
+	ident := p.expect(tokenizer.Identifier)
+	if ident == nil {
+		return nil, p.currentError()
+	}
+
+	// An aliased attribute is ordered by its modifiers in SELECT, whereas
+	// groups are ordered by their output values (which are already modified).
+	if attribute, ok := aliasedAttribute(q, ident.Raw); ok {
+		key := &query.OrderKey{Attribute: attribute, Modifiers: make([]query.Modifier, 0)}
+		if len(q.Aggregates) == 0 && len(q.GroupBy) == 0 {
+			key.Modifiers = append(key.Modifiers, q.Modifiers[attribute]...)
+		}
+		return key, nil
+	}
+
+	if isAggregateFunction(ident.Raw) {
+		aggregate, err := p.parseAggregate(ident)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := q.Aggregates[aggregate.String()]; !ok {
+			return nil, fmt.Errorf("cannot ORDER BY %s: expected a selected aggregate",
+				aggregate.String())
+		}
+		return &query.OrderKey{
+			Attribute: aggregate.String(),
+			Modifiers: make([]query.Modifier, 0),
+		}, nil
+	}
+
+	p.current = ident
+	modifiers := make([]query.Modifier, 0)
+	attribute, err := p.parseSelectableAttr(&modifiers)
+	if err != nil {
+		return nil, err
+	}
+	return &query.OrderKey{Attribute: attribute.Raw, Modifiers: modifiers}, nil
+}
+
+// parseLimitClause parses the LIMIT and OFFSET clauses of the query, in
+// either order.
+func (p *parser) parseLimitClause(q *query.Query) error {
+	for {
+		if p.expect(tokenizer.Limit) != nil {
+			limit, err := p.parseCount("LIMIT")
+			if err != nil {
+				return err
+			}
+			q.Limit = limit
+			continue
+		}
+		if p.expect(tokenizer.Offset) != nil {
+			offset, err := p.parseCount("OFFSET")
+			if err != nil {
+				return err
+			}
+			q.Offset = offset
+			continue
+		}
+		break
+	}
+
+	if p.expect(tokenizer.Identifier) != nil {
+		return p.currentError()
+	}
+	return nil
+}
+
+// parseCount parses the non-negative integer argument of the named clause.
+func (p *parser) parseCount(clause string) (int, error) {
+	token := p.expect(tokenizer.Identifier)
+	if token == nil {
+		return 0, p.currentError()
+	}
+	n, err := strconv.Atoi(token.Raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %s: expected a non-negative integer",
+			clause, token.Raw)
+	}
+	return n, nil
+}
+
 // expect returns the next token if it matches the expectation t, and
 // nil otherwise.
 func (p *parser) expect(t tokenizer.TokenType) *tokenizer.Token {
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"io"
 	"os/user"
 	"reflect"
@@ -14,6 +15,8 @@ func TestParser_ParseSelect(t *testing.T) {
 	type Expected struct {
 		attributes []string
 		modifiers  map[string][]query.Modifier
+		aliases    map[string]string
+		distinct   bool
 		err        error
 	}
 
@@ -26,7 +29,7 @@ func TestParser_ParseSelect(t *testing.T) {
 		{
 			input: "all",
 			expected: Expected{
-				attributes: allAttributes,
+				attributes: defaultAttributes,
 				modifiers:  map[string][]query.Modifier{},
 				err:        nil,
 			},
@@ -35,7 +38,7 @@ func TestParser_ParseSelect(t *testing.T) {
 		{
 			input: "SELECT",
 			expected: Expected{
-				attributes: allAttributes,
+				attributes: defaultAttributes,
 				modifiers:  map[string][]query.Modifier{},
 				err:        nil,
 			},
@@ -44,7 +47,7 @@ func TestParser_ParseSelect(t *testing.T) {
 		{
 			input: "FROM",
 			expected: Expected{
-				attributes: allAttributes,
+				attributes: defaultAttributes,
 				modifiers:  map[string][]query.Modifier{},
 				err:        nil,
 			},
@@ -75,6 +78,88 @@ func TestParser_ParseSelect(t *testing.T) {
 			},
 		},
 
+		{
+			input: "SELECT DISTINCT extension",
+			expected: Expected{
+				attributes: []string{"extension"},
+				modifiers:  map[string][]query.Modifier{"extension": {}},
+				distinct:   true,
+				err:        nil,
+			},
+		},
+
+		{
+			input: "SELECT DISTINCT FROM",
+			expected: Expected{
+				attributes: defaultAttributes,
+				modifiers:  map[string][]query.Modifier{},
+				distinct:   true,
+				err:        nil,
+			},
+		},
+
+		{
+			input: "SELECT name AS filename, size",
+			expected: Expected{
+				attributes: []string{"name", "size"},
+				modifiers:  map[string][]query.Modifier{"name": {}, "size": {}},
+				aliases:    map[string]string{"name": "filename"},
+				err:        nil,
+			},
+		},
+
+		{
+			input: "SELECT UPPER(name) AS n, COUNT(*) AS count",
+			expected: Expected{
+				attributes: []string{"name", "COUNT(*)"},
+				modifiers: map[string][]query.Modifier{
+					"name": {
+						{
+							Name:      "UPPER",
+							Arguments: []string{},
+						},
+					},
+				},
+				aliases: map[string]string{"name": "n", "COUNT(*)": "count"},
+				err:     nil,
+			},
+		},
+
+		{
+			input:    "SELECT * AS files",
+			expected: Expected{err: errors.New("cannot alias *")},
+		},
+
+		{
+			input:    "SELECT name AS a, name AS b",
+			expected: Expected{err: errors.New("cannot alias name as b: already aliased as a (an attribute may only have one alias)")},
+		},
+
+		{
+			input:    "SELECT size AS raw, FORMAT(size, kb) AS kilo",
+			expected: Expected{err: errors.New("cannot alias size as kilo: already aliased as raw (an attribute may only have one alias)")},
+		},
+
+		{
+			input:    "SELECT size, FORMAT(size, kb) AS kilo",
+			expected: Expected{err: errors.New("cannot alias size as kilo: size is selected more than once (an attribute may only have one alias)")},
+		},
+
+		{
+			input:    "SELECT size AS raw, FORMAT(size, kb)",
+			expected: Expected{err: errors.New("cannot alias size as raw: size is selected more than once (an attribute may only have one alias)")},
+		},
+
+		{
+			input:    "SELECT name AS size, size",
+			expected: Expected{err: errors.New("duplicate column size")},
+		},
+
+		{
+			input:    "SELECT name AS",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+
 		{
 			input:    "",
 			expected: Expected{err: io.ErrUnexpectedEOF},
@@ -95,6 +180,12 @@ func TestParser_ParseSelect(t *testing.T) {
 			if !reflect.DeepEqual(c.expected.modifiers, q.Modifiers) {
 				t.Fatalf("\nExpected %v\n     Got %v", c.expected.modifiers, q.Modifiers)
 			}
+			if !reflect.DeepEqual(c.expected.aliases, q.AttributeAliases) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.aliases, q.AttributeAliases)
+			}
+			if c.expected.distinct != q.Distinct {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.distinct, q.Distinct)
+			}
 		} else if !reflect.DeepEqual(c.expected.err, err) {
 			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
 		}
@@ -156,6 +247,18 @@ func TestParser_ParseFrom(t *testing.T) {
 			},
 		},
 
+		{
+			input: "FROM ~ AS home",
+			expected: Expected{
+				sources: map[string][]string{
+					"include": {u.HomeDir},
+					"exclude": {},
+				},
+				aliases: map[string]string{"home": u.HomeDir},
+				err:     nil,
+			},
+		},
+
 		{
 			input: "FROM ./foo/ AS foo",
 			expected: Expected{
@@ -264,6 +367,472 @@ func TestParser_ParseWhere(t *testing.T) {
 	}
 }
 
+func TestParser_ParseOrderBy(t *testing.T) {
+	type Expected struct {
+		keys []query.OrderKey
+		err  error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{input: "", expected: Expected{}},
+
+		{
+			input: "ORDER BY size",
+			expected: Expected{
+				keys: []query.OrderKey{
+					{Attribute: "size", Modifiers: []query.Modifier{}},
+				},
+			},
+		},
+
+		{
+			input: "ORDER BY size DESC, ext asc, LOWER(name)",
+			expected: Expected{
+				keys: []query.OrderKey{
+					{Attribute: "size", Modifiers: []query.Modifier{}, Descending: true},
+					{Attribute: "extension", Modifiers: []query.Modifier{}},
+					{
+						Attribute: "name",
+						Modifiers: []query.Modifier{
+							{Name: "LOWER", Arguments: []string{}},
+						},
+					},
+				},
+			},
+		},
+
+		{input: "ORDER BY", expected: Expected{err: io.ErrUnexpectedEOF}},
+
+		{
+			input:    "ORDER BY size sideways",
+			expected: Expected{err: &ErrUnknownToken{"sideways"}},
+		},
+
+		{
+			input:    "ORDER BY foo",
+			expected: Expected{err: &ErrUnknownToken{"foo"}},
+		},
+	}
+
+	for _, c := range cases {
+		q := query.NewQuery()
+		err := (&parser{tokenizer: tokenizer.NewTokenizer(c.input)}).parseOrderByClause(q)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.keys, q.OrderBy) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.keys, q.OrderBy)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
+func TestParser_ParseGroupBy(t *testing.T) {
+	type Expected struct {
+		keys []query.GroupKey
+		err  error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{input: "", expected: Expected{}},
+
+		{
+			input: "GROUP BY ext, LOWER(group)",
+			expected: Expected{
+				keys: []query.GroupKey{
+					{Attribute: "extension", Modifiers: []query.Modifier{}},
+					{
+						Attribute: "group",
+						Modifiers: []query.Modifier{
+							{Name: "LOWER", Arguments: []string{}},
+						},
+					},
+				},
+			},
+		},
+
+		{input: "GROUP BY", expected: Expected{err: io.ErrUnexpectedEOF}},
+	}
+
+	for _, c := range cases {
+		q := query.NewQuery()
+		err := (&parser{tokenizer: tokenizer.NewTokenizer(c.input)}).parseGroupByClause(q)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.keys, q.GroupBy) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.keys, q.GroupBy)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
+func TestParser_ParseHaving(t *testing.T) {
+	type Expected struct {
+		having     *query.ConditionNode
+		aggregates []string
+		err        error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	and := tokenizer.And
+	cases := []Case{
+		{
+			input: "SELECT extension FROM . GROUP BY extension HAVING COUNT(*) > 100",
+			expected: Expected{
+				having: &query.ConditionNode{Condition: &query.Condition{
+					Attribute: "COUNT(*)",
+					Operator:  tokenizer.GreaterThan,
+					Value:     "100",
+				}},
+				aggregates: []string{"COUNT(*)"},
+			},
+		},
+		{
+			input: "SELECT LOWER(ext) AS e, SUM(size) FROM . GROUP BY LOWER(ext) " +
+				"HAVING e <> go AND SUM(size) >= 1 mb",
+			expected: Expected{
+				having: &query.ConditionNode{
+					Type: &and,
+					Left: &query.ConditionNode{Condition: &query.Condition{
+						Attribute:          "extension",
+						AttributeModifiers: []query.Modifier{{Name: "LOWER", Arguments: []string{}}},
+						Operator:           tokenizer.NotEquals,
+						Value:              "go",
+					}},
+					Right: &query.ConditionNode{Condition: &query.Condition{
+						Attribute: "SUM(size)",
+						Operator:  tokenizer.GreaterThanEquals,
+						Value:     "1mb",
+					}},
+				},
+				aggregates: []string{"SUM(size)"},
+			},
+		},
+		{
+			input: "SELECT depth FROM . GROUP BY depth HAVING NOT MAX(size) IN (0, 1) ORDER BY depth",
+			expected: Expected{
+				having: &query.ConditionNode{Condition: &query.Condition{
+					Attribute: "MAX(size)",
+					Operator:  tokenizer.In,
+					Value:     []string{"0", "1"},
+					Negate:    true,
+				}},
+				aggregates: []string{"MAX(size)"},
+			},
+		},
+		{
+			input: "SELECT is_dir, COUNT(*) FROM . GROUP BY is_dir HAVING is_dir",
+			expected: Expected{
+				having: &query.ConditionNode{Condition: &query.Condition{
+					Attribute:          "is_dir",
+					AttributeModifiers: []query.Modifier{},
+					Operator:           tokenizer.Equals,
+					Value:              "true",
+				}},
+				aggregates: []string{"COUNT(*)"},
+			},
+		},
+
+		{
+			input: "SELECT SUM(size) AS total FROM . HAVING total < 2 gb",
+			expected: Expected{
+				having: &query.ConditionNode{Condition: &query.Condition{
+					Attribute: "SUM(size)",
+					Operator:  tokenizer.LessThan,
+					Value:     "2gb",
+				}},
+				aggregates: []string{"SUM(size)"},
+			},
+		},
+
+		{
+			input:    "SELECT name FROM . HAVING COUNT(*) > 1",
+			expected: Expected{err: errors.New("cannot use HAVING without aggregates or GROUP BY")},
+		},
+		{
+			input: "SELECT depth FROM . GROUP BY depth HAVING size > 1",
+			expected: Expected{
+				err: errors.New("cannot use size in HAVING: expected an aggregate or GROUP BY key"),
+			},
+		},
+		{
+			input: "SELECT LOWER(ext) FROM . GROUP BY LOWER(ext) HAVING ext = go",
+			expected: Expected{
+				err: errors.New("cannot use extension in HAVING: expected an aggregate or GROUP BY key"),
+			},
+		},
+		{
+			input:    "SELECT COUNT(*) FROM . HAVING COUNT(*) LIKE 1%",
+			expected: Expected{err: errors.New("unsupported operator like in HAVING")},
+		},
+		{
+			input:    "SELECT COUNT(*) FROM . HAVING COUNT(*) IN (SELECT size FROM .)",
+			expected: Expected{err: errors.New("cannot use a subquery in HAVING")},
+		},
+		{
+			input:    "SELECT COUNT(*) FROM . HAVING",
+			expected: Expected{err: io.ErrUnexpectedEOF},
+		},
+	}
+
+	for _, c := range cases {
+		q, err := Run(c.input)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("%s\nExpected no error\n     Got %v", c.input, err)
+			}
+			if !reflect.DeepEqual(c.expected.having, q.Having) {
+				t.Fatalf("%s\nExpected %v\n     Got %v", c.input, c.expected.having, q.Having)
+			}
+			for _, aggregate := range c.expected.aggregates {
+				if _, ok := q.Aggregates[aggregate]; !ok {
+					t.Fatalf("%s\nExpected aggregate %s\n     Got %v", c.input, aggregate, q.Aggregates)
+				}
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.input, c.expected.err, err)
+		}
+	}
+}
+
+func TestParser_ValidateGrouping(t *testing.T) {
+	type Case struct {
+		input    string
+		expected error
+	}
+
+	cases := []Case{
+		{input: "SELECT name FROM . ORDER BY size", expected: nil},
+		{input: "SELECT COUNT(*), SUM(size) FROM .", expected: nil},
+		{input: "SELECT depth, COUNT(*) FROM . GROUP BY depth ORDER BY COUNT(*) DESC", expected: nil},
+		{input: "SELECT depth FROM . GROUP BY depth, ext", expected: nil},
+		{input: "SELECT UPPER(ext), COUNT(*) FROM . GROUP BY UPPER(ext)", expected: nil},
+		{input: "SELECT UPPER(ext), COUNT(*) FROM . GROUP BY ext", expected: nil},
+		{input: "SELECT UPPER(ext) FROM . GROUP BY LOWER(ext), UPPER(ext)", expected: nil},
+
+		{
+			input:    "SELECT name, COUNT(*) FROM .",
+			expected: errors.New("attribute name must be aggregated or used in GROUP BY"),
+		},
+		{
+			input:    "SELECT depth, name FROM . GROUP BY depth",
+			expected: errors.New("attribute name must be aggregated or used in GROUP BY"),
+		},
+		{
+			input:    "SELECT UPPER(extension), COUNT(*) FROM . GROUP BY LOWER(extension)",
+			expected: errors.New("attribute extension must be selected with the same modifiers as in GROUP BY"),
+		},
+		{
+			input:    "SELECT name, COUNT(*) FROM . GROUP BY UPPER(name)",
+			expected: errors.New("attribute name must be selected with the same modifiers as in GROUP BY"),
+		},
+		{
+			input:    "SELECT depth FROM . GROUP BY depth ORDER BY size",
+			expected: errors.New("cannot ORDER BY size: expected a selected attribute or aggregate"),
+		},
+		{
+			input:    "SELECT name FROM . ORDER BY COUNT(*)",
+			expected: errors.New("cannot ORDER BY COUNT(*): expected a selected aggregate"),
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Run(c.input)
+		if !reflect.DeepEqual(c.expected, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, err)
+		}
+	}
+}
+
+func TestParser_ParseLimit(t *testing.T) {
+	type Expected struct {
+		limit  int
+		offset int
+		err    error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{input: "", expected: Expected{limit: -1}},
+		{input: "LIMIT 10", expected: Expected{limit: 10}},
+		{input: "LIMIT 0", expected: Expected{limit: 0}},
+		{input: "LIMIT 10 OFFSET 20", expected: Expected{limit: 10, offset: 20}},
+		{input: "OFFSET 20 LIMIT 10", expected: Expected{limit: 10, offset: 20}},
+		{input: "OFFSET 5", expected: Expected{limit: -1, offset: 5}},
+
+		{input: "LIMIT", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{
+			input: "LIMIT -1",
+			expected: Expected{
+				err: &ErrUnexpectedToken{
+					Actual:   tokenizer.Hyphen,
+					Expected: tokenizer.Identifier,
+				},
+			},
+		},
+		{
+			input: "LIMIT 1.5",
+			expected: Expected{
+				err: errors.New("invalid LIMIT 1.5: expected a non-negative integer"),
+			},
+		},
+		{
+			input: "LIMIT 1 OFFSET foo",
+			expected: Expected{
+				err: errors.New("invalid OFFSET foo: expected a non-negative integer"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		q := query.NewQuery()
+		err := (&parser{tokenizer: tokenizer.NewTokenizer(c.input)}).parseLimitClause(q)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if c.expected.limit != q.Limit || c.expected.offset != q.Offset {
+				t.Fatalf("\nExpected %d, %d\n     Got %d, %d", c.expected.limit,
+					c.expected.offset, q.Limit, q.Offset)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
+func TestParser_ParseExclude(t *testing.T) {
+	type Expected struct {
+		exclude []string
+		err     error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{input: "", expected: Expected{exclude: nil}},
+		{input: "EXCLUDE node_modules", expected: Expected{exclude: []string{"node_modules"}}},
+		{
+			input:    "EXCLUDE ('node_modules', .git, 'tmp%')",
+			expected: Expected{exclude: []string{"node_modules", ".git", "tmp%"}},
+		},
+
+		{input: "EXCLUDE", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{input: "EXCLUDE (foo", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{
+			input:    "EXCLUDE ()",
+			expected: Expected{err: errors.New("expected at least one pattern for EXCLUDE")},
+		},
+	}
+
+	for _, c := range cases {
+		q := query.NewQuery()
+		err := (&parser{tokenizer: tokenizer.NewTokenizer(c.input)}).parseExcludeClause(q)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.exclude, q.Exclude) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.exclude, q.Exclude)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
+func TestParser_ParseSourceQuery(t *testing.T) {
+	type Expected struct {
+		attributes []string
+		sources    []string
+		err        error
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	cases := []Case{
+		{
+			input:    "FROM (SELECT size FROM ./foo WHERE size > 0)",
+			expected: Expected{attributes: []string{"size"}, sources: []string{"foo"}},
+		},
+		{
+			input: "FROM (SELECT name FROM (SELECT name FROM ./foo))",
+			expected: Expected{attributes: []string{"name"},
+				sources: []string{}},
+		},
+
+		{input: "FROM (SELECT name FROM ./foo", expected: Expected{err: io.ErrUnexpectedEOF}},
+		{
+			input:    "FROM (SELECT COUNT(*) FROM ./foo)",
+			expected: Expected{err: errors.New("cannot use aggregates or GROUP BY in a subquery in FROM")},
+		},
+		{
+			input: "FROM (SELECT name FROM ./foo) EXCLUDE bar",
+			expected: Expected{
+				err: errors.New("cannot EXCLUDE from a subquery in FROM, use EXCLUDE in the subquery instead"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		q := query.NewQuery()
+		err := (&parser{tokenizer: tokenizer.NewTokenizer(c.input)}).parseFromClause(q)
+
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if q.SourceQuery == nil {
+				t.Fatalf("\nExpected a subquery\n     Got %v", q.SourceQuery)
+			}
+			if !reflect.DeepEqual(c.expected.attributes, q.SourceQuery.Attributes) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.attributes, q.SourceQuery.Attributes)
+			}
+			if !reflect.DeepEqual(c.expected.sources, q.SourceQuery.Sources["include"]) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.sources, q.SourceQuery.Sources["include"])
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
+
 func TestParser_Expect(t *testing.T) {
 	type Case struct {
 		param    tokenizer.TokenType
@@ -350,7 +919,7 @@ func TestParser_Expect(t *testing.T) {
 
 func TestParser_SelectAllVariations(t *testing.T) {
 	expected := &query.Query{
-		Attributes: allAttributes,
+		Attributes: defaultAttributes,
 		Sources: map[string][]string{
 			"include": {"."},
 			"exclude": {},
@@ -364,6 +933,7 @@ func TestParser_SelectAllVariations(t *testing.T) {
 		},
 		SourceAliases: map[string]string{},
 		Modifiers:     map[string][]query.Modifier{},
+		Limit:         -1,
 	}
 
 	cases := []string{
@@ -378,6 +948,10 @@ func TestParser_SelectAllVariations(t *testing.T) {
 		if err != nil {
 			t.Fatalf("\nExpected no error\n     Got %v", err)
 		}
+		if actual.Now.IsZero() {
+			t.Fatalf("\nExpected the time the query was parsed\n     Got %v", actual.Now)
+		}
+		expected.Now = actual.Now
 		if !reflect.DeepEqual(expected, actual) {
 			t.Fatalf("\nExpected %v\n     Got %v", expected, actual)
 		}
@@ -401,7 +975,7 @@ func TestParser_Run(t *testing.T) {
 			input: "SELECT all FROM . WHERE name LIKE foo",
 			expected: Expected{
 				q: &query.Query{
-					Attributes: allAttributes,
+					Attributes: defaultAttributes,
 					Sources: map[string][]string{
 						"include": {"."},
 						"exclude": {},
@@ -415,6 +989,7 @@ func TestParser_Run(t *testing.T) {
 					},
 					SourceAliases: map[string]string{},
 					Modifiers:     map[string][]query.Modifier{},
+					Limit:         -1,
 				},
 				err: nil,
 			},
@@ -427,6 +1002,10 @@ func TestParser_Run(t *testing.T) {
 			if err != nil {
 				t.Fatalf("\nExpected no error\n     Got %v", err)
 			}
+			if actual.Now.IsZero() {
+				t.Fatalf("\nExpected the time the query was parsed\n     Got %v", actual.Now)
+			}
+			c.expected.q.Now = actual.Now
 			if !reflect.DeepEqual(c.expected.q, actual) {
 				t.Fatalf("\nExpected %v\n     Got %v", c.expected.q, actual)
 			}
@@ -435,3 +1014,15 @@ func TestParser_Run(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_RunSubqueryNow(t *testing.T) {
+	q, err := Run("SELECT name FROM (FROM (FROM ./foo WHERE time > -1d) WHERE time < now)")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	for sub := q.SourceQuery; sub != nil; sub = sub.SourceQuery {
+		if !sub.Now.Equal(q.Now) {
+			t.Fatalf("\nExpected %v\n     Got %v", q.Now, sub.Now)
+		}
+	}
+}
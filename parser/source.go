@@ -2,14 +2,17 @@ package parser
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/kshvmdn/fsql/tokenizer"
 )
 
 // parseSourceList parses the list of directories passed to the FROM clause. If
 // a source is followed by the AS keyword, the following word is registered as
-// an alias.
+// an alias. A leading tilde and environment variables are expanded in each
+// source (see expandSource).
 func (p *parser) parseSourceList(sources *map[string][]string,
 	aliases *map[string]string) error {
 	for {
@@ -21,9 +24,17 @@ func (p *parser) parseSourceList(sources *map[string][]string,
 
 		source := p.expect(tokenizer.Identifier)
 		if source == nil {
-			return p.currentError()
+			// A lone tilde is the home directory, not the GLOB operator.
+			err := p.currentError()
+			if source = p.expect(tokenizer.Glob); source == nil || source.Raw != "~" {
+				return err
+			}
+		}
+		raw, err := p.expandSource(source.Raw)
+		if err != nil {
+			return err
 		}
-		source.Raw = filepath.Clean(source.Raw)
+		source.Raw = filepath.Clean(raw)
 		(*sources)[sourceType] = append((*sources)[sourceType], source.Raw)
 
 		if token := p.expect(tokenizer.As); token != nil {
@@ -43,3 +54,37 @@ func (p *parser) parseSourceList(sources *map[string][]string,
 	}
 	return nil
 }
+
+// expandSource expands src (see ExpandSource), and records its warnings.
+func (p *parser) expandSource(src string) (string, error) {
+	src, warnings, err := ExpandSource(src)
+	p.warnings = append(p.warnings, warnings...)
+	return src, err
+}
+
+// ExpandSource replaces a leading tilde (e.g. `~/foo`) in src with the home
+// directory, and environment variables (`$VAR` or `${VAR}`) with their values.
+// This is only required when the query is wrapped in quotes, since the shell
+// expands them otherwise. Variables which aren't set are empty, and a warning
+// is returned for each.
+func ExpandSource(src string) (string, []string, error) {
+	if src == "~" || strings.HasPrefix(src, "~/") ||
+		strings.HasPrefix(src, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil, err
+		}
+		src = home + src[1:]
+	}
+
+	var warnings []string
+	src = os.Expand(src, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			warnings = append(warnings,
+				fmt.Sprintf("environment variable %s in FROM isn't set, so it's empty", name))
+		}
+		return value
+	})
+	return src, warnings, nil
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -20,6 +22,11 @@ func TestSourceParser_ExpectCorrectSources(t *testing.T) {
 		expected Expected
 	}
 
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
 	cases := []Case{
 		{
 			input: ".",
@@ -31,7 +38,7 @@ func TestSourceParser_ExpectCorrectSources(t *testing.T) {
 		{
 			input: "., ~/foo",
 			expected: Expected{
-				sources: map[string][]string{"include": {".", "~/foo"}},
+				sources: map[string][]string{"include": {".", filepath.Join(home, "foo")}},
 				err:     nil,
 			},
 		},
@@ -49,7 +56,7 @@ func TestSourceParser_ExpectCorrectSources(t *testing.T) {
 			input: "-.bar, ., ~/foo AS foo",
 			expected: Expected{
 				sources: map[string][]string{
-					"include": {".", "~/foo"},
+					"include": {".", filepath.Join(home, "foo")},
 					"exclude": {".bar"},
 				},
 				err: nil,
@@ -91,6 +98,11 @@ func TestSourceParser_ExpectCorrectAliases(t *testing.T) {
 		expected Expected
 	}
 
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
 	cases := []Case{
 		{
 			input: ".",
@@ -109,7 +121,7 @@ func TestSourceParser_ExpectCorrectAliases(t *testing.T) {
 		{
 			input: "., -.bar, ~/foo AS foo",
 			expected: Expected{
-				aliases: map[string]string{"foo": "~/foo"},
+				aliases: map[string]string{"foo": filepath.Join(home, "foo")},
 				err:     nil,
 			},
 		},
@@ -147,3 +159,58 @@ func TestSourceParser_ExpectCorrectAliases(t *testing.T) {
 		}
 	}
 }
+
+func TestSourceParser_ExpandSource(t *testing.T) {
+	type Expected struct {
+		source   string
+		warnings []string
+	}
+
+	type Case struct {
+		input    string
+		expected Expected
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	os.Setenv("FSQL_TEST_DIR", "/tmp/fsql")
+	defer os.Unsetenv("FSQL_TEST_DIR")
+	os.Unsetenv("FSQL_TEST_UNSET")
+
+	cases := []Case{
+		{input: "~", expected: Expected{source: home}},
+		{input: "~/foo", expected: Expected{source: filepath.Join(home, "foo")}},
+		{input: "foo~", expected: Expected{source: "foo~"}},
+		{input: "~foo", expected: Expected{source: "~foo"}},
+		{input: "$FSQL_TEST_DIR/foo", expected: Expected{source: filepath.FromSlash("/tmp/fsql/foo")}},
+		{input: "${FSQL_TEST_DIR}/foo", expected: Expected{source: filepath.FromSlash("/tmp/fsql/foo")}},
+		{input: "'$FSQL_TEST_DIR/foo bar'", expected: Expected{source: filepath.FromSlash("/tmp/fsql/foo bar")}},
+		{
+			input: "./$FSQL_TEST_UNSET/foo",
+			expected: Expected{
+				source: "foo",
+				warnings: []string{
+					"environment variable FSQL_TEST_UNSET in FROM isn't set, so it's empty",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		sources := make(map[string][]string, 0)
+		aliases := make(map[string]string, 0)
+
+		p := &parser{tokenizer: tokenizer.NewTokenizer(c.input)}
+		if err := p.parseSourceList(&sources, &aliases); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if !reflect.DeepEqual(c.expected.source, sources["include"][0]) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.source, sources["include"][0])
+		}
+		if !reflect.DeepEqual(c.expected.warnings, p.warnings) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.warnings, p.warnings)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package query
+
+import (
+	"fmt"
+	"os"
+)
+
+// Aggregate represents an aggregate function applied to an attribute in the
+// SELECT clause (e.g. `SUM(size)`).
+type Aggregate struct {
+	Name string
+
+	// Attribute is `*` for `COUNT(*)`.
+	Attribute string
+	Modifiers []Modifier
+}
+
+// String returns the aggregate as it's displayed, e.g. `SUM(FORMAT(size, KB))`.
+func (a *Aggregate) String() string {
+	return fmt.Sprintf("%s(%s)", a.Name, modifiedString(a.Attribute, a.Modifiers))
+}
+
+// value returns the value of the file that this aggregate accumulates.
+func (a *Aggregate) value(path string, info os.FileInfo, depth int64, binary bool) (interface{}, error) {
+	if a.Attribute == "*" {
+		return nil, nil
+	}
+	return formatValue(a.Attribute, a.Modifiers, path, info, depth, binary)
+}
+
+// accumulator accumulates the values of a single aggregate across files.
+type accumulator struct {
+	aggregate Aggregate
+
+	count    int64
+	sum      float64
+	isFloat  bool
+	min, max interface{}
+}
+
+// add accumulates value, returning an error if the aggregate requires a
+// numeric value and value isn't one.
+func (acc *accumulator) add(value interface{}) error {
+	acc.count++
+	if acc.aggregate.Name == "COUNT" {
+		return nil
+	}
+
+	var n float64
+	switch v := value.(type) {
+	case int64:
+		n = float64(v)
+	case float64:
+		n, acc.isFloat = v, true
+	default:
+		return fmt.Errorf("function %s expected a numeric value for attribute %s; got %v",
+			acc.aggregate.Name, acc.aggregate.Attribute, value)
+	}
+
+	acc.sum += n
+	if acc.min == nil || compareValues(value, acc.min) < 0 {
+		acc.min = value
+	}
+	if acc.max == nil || compareValues(value, acc.max) > 0 {
+		acc.max = value
+	}
+	return nil
+}
+
+// result returns the aggregated value. AVG, MIN, and MAX are nil if no values
+// were accumulated.
+func (acc *accumulator) result() interface{} {
+	switch acc.aggregate.Name {
+	case "COUNT":
+		return acc.count
+	case "SUM":
+		if acc.isFloat {
+			return acc.sum
+		}
+		return int64(acc.sum)
+	case "AVG":
+		if acc.count == 0 {
+			return nil
+		}
+		return acc.sum / float64(acc.count)
+	case "MIN":
+		return acc.min
+	case "MAX":
+		return acc.max
+	}
+	return nil
+}
+
+// GroupKey represents a single key of a query's GROUP BY clause.
+type GroupKey struct {
+	Attribute string
+	Modifiers []Modifier
+}
+
+// value returns the value of the file that this key groups by.
+func (k *GroupKey) value(path string, info os.FileInfo, depth int64, binary bool) (interface{}, error) {
+	return formatValue(k.Attribute, k.Modifiers, path, info, depth, binary)
+}
+
+// group represents the files sharing a single set of GROUP BY values.
+type group struct {
+	// values holds the output values of the first file in this group, and keys
+	// its GROUP BY values.
+	values       map[string]interface{}
+	keys         []interface{}
+	accumulators map[string]*accumulator
+}
+
+// aggregator buckets files into groups by the query's GROUP BY keys and
+// accumulates each of the query's aggregates per group. Without GROUP BY,
+// every file belongs to a single group.
+type aggregator struct {
+	q      *Query
+	groups []*group
+	index  map[string]*group
+}
+
+func newAggregator(q *Query) *aggregator {
+	a := &aggregator{q: q, groups: make([]*group, 0), index: make(map[string]*group)}
+	if len(q.GroupBy) == 0 {
+		// Without GROUP BY, there's always a summary row (even if no files
+		// match).
+		a.group("", map[string]interface{}{}, nil)
+	}
+	return a
+}
+
+// group returns the group for key, creating it with the output (and GROUP BY)
+// values of its first file if it doesn't yet exist.
+func (a *aggregator) group(key string, values map[string]interface{},
+	keys []interface{}) *group {
+	if g, ok := a.index[key]; ok {
+		return g
+	}
+
+	g := &group{
+		values:       values,
+		keys:         keys,
+		accumulators: make(map[string]*accumulator, len(a.q.Aggregates)),
+	}
+	for column, aggregate := range a.q.Aggregates {
+		g.accumulators[column] = &accumulator{aggregate: aggregate}
+	}
+	a.groups = append(a.groups, g)
+	a.index[key] = g
+	return g
+}
+
+// add accumulates the aggregate values of a single file into its group.
+func (a *aggregator) add(r *result) error {
+	g := a.group(rowKey(r.group), r.values, r.group)
+	for column, acc := range g.accumulators {
+		if err := acc.add(r.values[column]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// results returns a row for each group which satisfies the query's HAVING
+// clause (if any), in the order that each group was first found.
+func (a *aggregator) results() ([]*result, error) {
+	results := make([]*result, 0, len(a.groups))
+	for _, g := range a.groups {
+		if ok, err := a.q.Having.evaluateGroup(a.q, g); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		values := make(map[string]interface{}, len(g.values))
+		for column, value := range g.values {
+			values[column] = value
+		}
+		for column, acc := range g.accumulators {
+			values[column] = acc.result()
+		}
+
+		// Grouped rows are ordered by their output values.
+		keys := make([]interface{}, len(a.q.OrderBy))
+		for j, key := range a.q.OrderBy {
+			keys[j] = values[key.Attribute]
+		}
+		results = append(results, &result{values: values, keys: keys})
+	}
+	return results, nil
+}
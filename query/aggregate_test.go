@@ -0,0 +1,133 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAggregate_String(t *testing.T) {
+	type Case struct {
+		aggregate Aggregate
+		expected  string
+	}
+
+	cases := []Case{
+		{aggregate: Aggregate{Name: "COUNT", Attribute: "*"}, expected: "COUNT(*)"},
+		{aggregate: Aggregate{Name: "SUM", Attribute: "size"}, expected: "SUM(size)"},
+		{
+			aggregate: Aggregate{
+				Name:      "MAX",
+				Attribute: "name",
+				Modifiers: []Modifier{
+					{Name: "LOWER", Arguments: []string{}},
+					{Name: "LENGTH", Arguments: []string{}},
+				},
+			},
+			expected: "MAX(LENGTH(LOWER(name)))",
+		},
+	}
+
+	for _, c := range cases {
+		actual := c.aggregate.String()
+		if actual != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestAggregate_Aggregator(t *testing.T) {
+	q := &Query{
+		Attributes: []string{"extension", "COUNT(*)", "SUM(size)"},
+		Aggregates: map[string]Aggregate{
+			"COUNT(*)":  {Name: "COUNT", Attribute: "*"},
+			"SUM(size)": {Name: "SUM", Attribute: "size"},
+		},
+		GroupBy: []GroupKey{{Attribute: "extension"}},
+	}
+
+	file := func(extension string, size int64) *result {
+		return &result{
+			values: map[string]interface{}{"extension": extension, "SUM(size)": size},
+			group:  []interface{}{extension},
+		}
+	}
+
+	a := newAggregator(q)
+	for _, r := range []*result{file("go", 1), file("md", 2), file("go", 3)} {
+		if err := a.add(r); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	expected := []map[string]interface{}{
+		{"extension": "go", "COUNT(*)": int64(2), "SUM(size)": int64(4)},
+		{"extension": "md", "COUNT(*)": int64(1), "SUM(size)": int64(2)},
+	}
+	results, err := a.results()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("\nExpected %d groups\n     Got %d", len(expected), len(results))
+	}
+	for i, r := range results {
+		if !reflect.DeepEqual(expected[i], r.values) {
+			t.Fatalf("\nExpected %v\n     Got %v", expected[i], r.values)
+		}
+	}
+}
+
+func TestAggregate_Accumulator(t *testing.T) {
+	type Case struct {
+		name     string
+		values   []interface{}
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{name: "COUNT", values: []interface{}{nil, nil, nil}, expected: int64(3)},
+		{name: "COUNT", values: []interface{}{}, expected: int64(0)},
+		{name: "SUM", values: []interface{}{int64(1), int64(2), int64(3)}, expected: int64(6)},
+		{name: "SUM", values: []interface{}{int64(1), 0.5}, expected: 1.5},
+		{name: "SUM", values: []interface{}{}, expected: int64(0)},
+		{name: "AVG", values: []interface{}{int64(1), int64(2)}, expected: 1.5},
+		{name: "AVG", values: []interface{}{}, expected: nil},
+		{name: "MIN", values: []interface{}{int64(3), int64(1), int64(2)}, expected: int64(1)},
+		{name: "MAX", values: []interface{}{int64(3), int64(1), int64(2)}, expected: int64(3)},
+		{name: "MAX", values: []interface{}{}, expected: nil},
+		{name: "MIN", values: []interface{}{int64(10), 9.5}, expected: 9.5},
+		{name: "MAX", values: []interface{}{9.5, int64(10)}, expected: int64(10)},
+
+		{
+			name:   "SUM",
+			values: []interface{}{"foo"},
+			err:    errors.New("function SUM expected a numeric value for attribute name; got foo"),
+		},
+	}
+
+	for _, c := range cases {
+		acc := &accumulator{aggregate: Aggregate{Name: c.name, Attribute: "name"}}
+
+		var err error
+		for _, value := range c.values {
+			if err = acc.add(value); err != nil {
+				break
+			}
+		}
+
+		if c.err != nil {
+			if !reflect.DeepEqual(c.err, err) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual := acc.result(); !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package query
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archiveFormats maps the (lower case) extension of each supported archive to
+// its format.
+var archiveFormats = []struct{ extension, format string }{
+	{".zip", "zip"},
+	{".tar", "tar"},
+	{".tar.gz", "tar.gz"},
+	{".tgz", "tar.gz"},
+}
+
+// archiveFormat returns the format of the archive at path (a regular file),
+// judging by its extension, or an empty string if it isn't an archive.
+func archiveFormat(path string) string {
+	lower := strings.ToLower(path)
+	for _, f := range archiveFormats {
+		if strings.HasSuffix(lower, f.extension) {
+			return f.format
+		}
+	}
+	return ""
+}
+
+// archive is a zip or tar archive, whose entries are walked as if they were
+// the files of a directory (see walkArchive).
+type archive struct {
+	path   string
+	format string
+
+	// zip is the reader of a zip archive while it's being walked, which is
+	// shared by each of its entries.
+	mu  sync.Mutex
+	zip *zip.ReadCloser
+}
+
+// archiveInfo is the info of an entry of an archive, read from its header.
+// Directories which only appear in the paths of other entries have no header.
+type archiveInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	sys     interface{}
+
+	// entries is the number of entries of a directory.
+	entries int
+
+	// target is the target of a symbolic link.
+	target string
+
+	// open opens the contents of a regular file.
+	open func() (io.ReadCloser, error)
+}
+
+func (a *archiveInfo) Name() string       { return a.name }
+func (a *archiveInfo) Size() int64        { return a.size }
+func (a *archiveInfo) Mode() os.FileMode  { return a.mode }
+func (a *archiveInfo) ModTime() time.Time { return a.modTime }
+func (a *archiveInfo) IsDir() bool        { return a.mode.IsDir() }
+func (a *archiveInfo) Sys() interface{}   { return a.sys }
+
+// Open opens the contents of the entry, which are empty unless it's a regular
+// file.
+func (a *archiveInfo) Open() (io.ReadCloser, error) {
+	if a.open == nil || !a.mode.IsRegular() {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+	return a.open()
+}
+
+// Entries returns the number of entries of the directory.
+func (a *archiveInfo) Entries() int {
+	return a.entries
+}
+
+// Readlink returns the target of the entry, which is a symbolic link.
+func (a *archiveInfo) Readlink() (string, error) {
+	return a.target, nil
+}
+
+// Target returns the info of the entry, which can't be followed if it's a
+// symbolic link (so it's treated as a broken link).
+func (a *archiveInfo) Target() (os.FileInfo, error) {
+	if a.mode&os.ModeSymlink != 0 {
+		return nil, fmt.Errorf("cannot follow symbolic link %s in archive", a.name)
+	}
+	return a, nil
+}
+
+// Link returns the info of the entry.
+func (a *archiveInfo) Link() (os.FileInfo, error) {
+	return a, nil
+}
+
+// archiveEntry is an entry of an archive, named by its slash-separated path
+// within the archive.
+type archiveEntry struct {
+	name string
+	info *archiveInfo
+}
+
+// walkArchive walks the entries of the archive at root (with info), calling
+// walkFn for root itself and then for each entry in lexical order, as
+// walkTree does for a directory. The path of each entry is root joined with
+// its path within the archive (e.g. `backup.zip/logs/a.log`). Directories
+// which only appear in the paths of other entries are walked too. Symbolic
+// links (and nested archives) aren't followed.
+//
+// The contents of a zip entry are read directly, whereas those of a tar entry
+// are found by reading the archive up to the entry.
+func walkArchive(root, format string, info os.FileInfo, walkFn filepath.WalkFunc) error {
+	a := &archive{path: root, format: format}
+	entries, err := a.entries()
+	if err == nil {
+		defer a.close()
+	}
+	if err = walkFn(root, info, err); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	var skip string
+	for _, entry := range entries {
+		if skip != "" && strings.HasPrefix(entry.name, skip) {
+			continue
+		}
+		// As with walkTree, SkipDir skips the directory, or the remaining
+		// entries of the directory of a file.
+		err := walkFn(filepath.Join(root, filepath.FromSlash(entry.name)), entry.info, nil)
+		if err == filepath.SkipDir {
+			if entry.info.IsDir() {
+				skip = entry.name + "/"
+			} else if dir := path.Dir(entry.name); dir != "." {
+				skip = dir + "/"
+			} else {
+				return nil
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entries returns the entries of the archive in lexical order, including
+// the directories which only appear in the paths of other entries. Entries
+// with invalid paths (e.g. `../foo`) are skipped.
+func (a *archive) entries() ([]archiveEntry, error) {
+	var entries []archiveEntry
+	var err error
+	if a.format == "zip" {
+		entries, err = a.zipEntries()
+	} else {
+		entries, err = a.tarEntries()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %v", a.path, err)
+	}
+
+	// Later entries with the same path replace earlier ones, as they would
+	// when the archive is extracted.
+	byName := make(map[string]*archiveInfo)
+	for _, entry := range entries {
+		byName[entry.name] = entry.info
+	}
+	for name := range byName {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if _, ok := byName[dir]; !ok {
+				byName[dir] = &archiveInfo{name: path.Base(dir), mode: os.ModeDir | 0755}
+			}
+		}
+	}
+
+	entries = entries[:0]
+	for name, info := range byName {
+		if dir := path.Dir(name); dir != "." {
+			byName[dir].entries++
+		}
+		entries = append(entries, archiveEntry{name, info})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return lessPath(entries[i].name, entries[j].name)
+	})
+	return entries, nil
+}
+
+// lessPath returns true iff the slash-separated path a is walked before b,
+// i.e. if its elements sort before those of b (so a directory is walked
+// before its entries, and `foo/bar` before `foo.txt`).
+func lessPath(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// entryName returns the cleaned path of an entry named name, or false if the
+// path is invalid (i.e. it's absolute or outside of the archive).
+func entryName(name string) (string, bool) {
+	name = path.Clean(strings.Replace(name, "\\", "/", -1))
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, true
+}
+
+// zipEntries returns the entries of the zip archive, whose reader is kept
+// open (until close) so its entries can be read while they're walked.
+func (a *archive) zipEntries() ([]archiveEntry, error) {
+	r, err := zip.OpenReader(a.path)
+	if err != nil {
+		return nil, err
+	}
+	a.zip = r
+
+	entries := make([]archiveEntry, 0, len(r.File))
+	for i, f := range r.File {
+		name, ok := entryName(f.Name)
+		if !ok {
+			continue
+		}
+		fi := f.FileInfo()
+		i := i
+		info := &archiveInfo{
+			name:    path.Base(name),
+			size:    fi.Size(),
+			mode:    fi.Mode(),
+			modTime: fi.ModTime(),
+			sys:     &f.FileHeader,
+			open:    func() (io.ReadCloser, error) { return a.openZip(i) },
+		}
+		// The target of a symbolic link is stored as its contents.
+		if info.mode&os.ModeSymlink != 0 {
+			if info.target, err = readZipLink(f); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, archiveEntry{name, info})
+	}
+	return entries, nil
+}
+
+// readZipLink returns the target of the symbolic link f.
+func readZipLink(f *zip.File) (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	target, err := ioutil.ReadAll(io.LimitReader(r, 4096))
+	return string(target), err
+}
+
+// openZip opens the contents of the i-th file of the zip archive. Once the
+// archive has been walked (e.g. to hash the results of a query), it's opened
+// again.
+func (a *archive) openZip(i int) (io.ReadCloser, error) {
+	a.mu.Lock()
+	r := a.zip
+	a.mu.Unlock()
+	if r != nil {
+		return r.File[i].Open()
+	}
+
+	r, err := zip.OpenReader(a.path)
+	if err != nil {
+		return nil, err
+	}
+	if i >= len(r.File) {
+		r.Close()
+		return nil, fmt.Errorf("invalid archive %s: missing entry %d", a.path, i)
+	}
+	f, err := r.File[i].Open()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &readCloser{f, func() error {
+		f.Close()
+		return r.Close()
+	}}, nil
+}
+
+// close closes the reader of a zip archive, if any.
+func (a *archive) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.zip == nil {
+		return nil
+	}
+	err := a.zip.Close()
+	a.zip = nil
+	return err
+}
+
+// tarEntries returns the entries of the tar archive.
+func (a *archive) tarEntries() ([]archiveEntry, error) {
+	f, r, err := a.openTar()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []archiveEntry
+	for i := 0; ; i++ {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		name, ok := entryName(hdr.Name)
+		if !ok {
+			continue
+		}
+		fi := hdr.FileInfo()
+		i := i
+		entries = append(entries, archiveEntry{name, &archiveInfo{
+			name:    path.Base(name),
+			size:    fi.Size(),
+			mode:    fi.Mode(),
+			modTime: fi.ModTime(),
+			sys:     hdr,
+			target:  hdr.Linkname,
+			open:    func() (io.ReadCloser, error) { return a.openTarEntry(i) },
+		}})
+	}
+}
+
+// openTar opens the tar archive, decompressing it if it's gzipped. The
+// returned file must be closed by the caller.
+func (a *archive) openTar() (io.Closer, *tar.Reader, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a.format != "tar.gz" {
+		return f, tar.NewReader(f), nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, tar.NewReader(gz), nil
+}
+
+// openTarEntry opens the contents of the i-th entry of the tar archive, by
+// reading the archive up to it.
+func (a *archive) openTarEntry(i int) (io.ReadCloser, error) {
+	f, r, err := a.openTar()
+	if err != nil {
+		return nil, err
+	}
+	for j := 0; j <= i; j++ {
+		if _, err := r.Next(); err != nil {
+			f.Close()
+			if err == io.EOF {
+				err = errors.New("unexpected end of archive")
+			}
+			return nil, fmt.Errorf("invalid archive %s: %v", a.path, err)
+		}
+	}
+	return &readCloser{r, f.Close}, nil
+}
+
+// readCloser is a reader which is closed by calling close.
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r *readCloser) Close() error {
+	return r.close()
+}
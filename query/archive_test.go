@@ -0,0 +1,185 @@
+package query
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// archiveFiles holds the entries (and their contents) of the archives of
+// writeArchives, in the order they're written. `foo/` is only implied by the
+// path of `foo/bar/baz`, and `../qux` is outside of the archive.
+var archiveFiles = []struct{ name, contents string }{
+	{"quux.txt", "a\nb\n"},
+	{"foo/bar/baz", "c\n"},
+	{"foo/corge", ""},
+	{"foo/grault", "e\n"},
+	{"../qux", "d\n"},
+}
+
+// writeArchives writes a zip archive (`archive.zip`) and a gzipped tar archive
+// (`archive.tar.gz`) of archiveFiles to a new temporary directory, which must
+// be removed by the caller.
+func writeArchives(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	zf, err := os.Create(filepath.Join(dir, "archive.zip"))
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	zw := zip.NewWriter(zf)
+	for _, f := range archiveFiles {
+		w, err := zw.Create(f.name)
+		if err == nil {
+			_, err = io.WriteString(w, f.contents)
+		}
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	zf.Close()
+
+	tf, err := os.Create(filepath.Join(dir, "archive.tar.gz"))
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	gw := gzip.NewWriter(tf)
+	tw := tar.NewWriter(gw)
+	for _, f := range archiveFiles {
+		hdr := &tar.Header{
+			Name:    f.name,
+			Mode:    0644,
+			Size:    int64(len(f.contents)),
+			ModTime: time.Now(),
+		}
+		err := tw.WriteHeader(hdr)
+		if err == nil {
+			_, err = io.WriteString(tw, f.contents)
+		}
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	gw.Close()
+	tf.Close()
+
+	return dir
+}
+
+func TestArchive_WalkArchive(t *testing.T) {
+	dir := writeArchives(t)
+	defer os.RemoveAll(dir)
+
+	type Entry struct {
+		path     string
+		dir      bool
+		contents string
+	}
+
+	type Case struct {
+		skip     string
+		expected []Entry
+	}
+
+	all := []Entry{
+		{path: "."},
+		{path: "foo", dir: true},
+		{path: "foo/bar", dir: true},
+		{path: "foo/bar/baz", contents: "c\n"},
+		{path: "foo/corge"},
+		{path: "foo/grault", contents: "e\n"},
+		{path: "quux.txt", contents: "a\nb\n"},
+	}
+	cases := []Case{
+		{expected: all},
+		// Skipping a directory skips its entries.
+		{skip: "foo/bar", expected: append(append([]Entry{}, all[:3]...), all[4:]...)},
+		// Skipping a file skips the remaining entries of its directory.
+		{skip: "foo/corge", expected: append(append([]Entry{}, all[:5]...), all[6:]...)},
+		{skip: ".", expected: all[:1]},
+	}
+
+	for _, name := range []string{"archive.zip", "archive.tar.gz"} {
+		root := filepath.Join(dir, name)
+		info, err := os.Stat(root)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+
+		for _, c := range cases {
+			actual := make([]Entry, 0)
+			err := walkArchive(root, archiveFormat(root), info, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				entry := Entry{path: filepath.ToSlash(rel), dir: info.IsDir()}
+				if a, ok := info.(*archiveInfo); ok && !a.IsDir() {
+					r, err := a.Open()
+					if err != nil {
+						return err
+					}
+					contents, err := ioutil.ReadAll(r)
+					r.Close()
+					if err != nil {
+						return err
+					}
+					entry.contents = string(contents)
+				}
+				actual = append(actual, entry)
+				if entry.path == c.skip {
+					return filepath.SkipDir
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("%s\nExpected no error\n     Got %v", name, err)
+			}
+			if !reflect.DeepEqual(c.expected, actual) {
+				t.Fatalf("%s, %s\nExpected %v\n     Got %v", name, c.skip, c.expected, actual)
+			}
+		}
+	}
+}
+
+func TestArchive_ArchiveFormat(t *testing.T) {
+	type Case struct {
+		path     string
+		expected string
+	}
+
+	cases := []Case{
+		{path: "backup.zip", expected: "zip"},
+		{path: "./foo/BACKUP.ZIP", expected: "zip"},
+		{path: "backup.tar", expected: "tar"},
+		{path: "backup.tar.gz", expected: "tar.gz"},
+		{path: "backup.tgz", expected: "tar.gz"},
+		{path: "backup.gz", expected: ""},
+		{path: "zip", expected: ""},
+	}
+
+	for _, c := range cases {
+		if actual := archiveFormat(c.path); actual != c.expected {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.path, c.expected, actual)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/kshvmdn/fsql/evaluate"
 	"github.com/kshvmdn/fsql/tokenizer"
@@ -30,7 +32,7 @@ func (root *ConditionNode) String() string {
 // evaluateTree runs pre-order traversal on the ConditionNode tree rooted at
 // root and evaluates each conditional along the path with the provided compare
 // method.
-func (root *ConditionNode) evaluateTree(path string, info os.FileInfo) (bool, error) {
+func (root *ConditionNode) evaluateTree(path string, info os.FileInfo, depth int64) (bool, error) {
 	if root == nil {
 		return true, nil
 	}
@@ -48,30 +50,98 @@ func (root *ConditionNode) evaluateTree(path string, info os.FileInfo) (bool, er
 			}
 		}
 
-		return root.Condition.evaluate(path, info)
+		return root.Condition.evaluate(path, info, depth)
 	}
 
 	if *root.Type == tokenizer.And {
-		if ok, err := root.Left.evaluateTree(path, info); err != nil {
+		if ok, err := root.Left.evaluateTree(path, info, depth); err != nil {
 			return false, err
 		} else if !ok {
 			return false, nil
 		}
-		return root.Right.evaluateTree(path, info)
+		return root.Right.evaluateTree(path, info, depth)
 	}
 
 	if *root.Type == tokenizer.Or {
-		if ok, err := root.Left.evaluateTree(path, info); err != nil {
+		if ok, err := root.Left.evaluateTree(path, info, depth); err != nil {
 			return false, nil
 		} else if ok {
 			return true, nil
 		}
-		return root.Right.evaluateTree(path, info)
+		return root.Right.evaluateTree(path, info, depth)
+	}
+
+	// A NOT node negates the result of its only (left) child.
+	if *root.Type == tokenizer.Not {
+		ok, err := root.Left.evaluateTree(path, info, depth)
+		if err != nil {
+			return false, err
+		}
+		return !ok, nil
 	}
 
 	return false, nil
 }
 
+// prepare applies the modifiers of each condition of the tree rooted at root
+// (see Condition.applyModifiers), so the tree isn't modified when it's
+// evaluated. Binary is true iff the contents of binary files are compared for
+// the `content` attribute, and relative times (e.g. `now() - 1h`) are relative
+// to now.
+//
+// The operands of each conjunction and disjunction are also reordered so the
+// cheaper one is evaluated first (see cost). Since evaluateTree short-circuits,
+// a costly attribute (e.g. `content`) is then only computed for the files
+// which aren't already decided by the cheaper operand.
+func (root *ConditionNode) prepare(binary bool, now time.Time) error {
+	if root == nil {
+		return nil
+	}
+	if c := root.Condition; c != nil && !c.IsSubquery {
+		c.binary, c.now = binary, now
+		if !c.Parsed {
+			if err := c.applyModifiers(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := root.Left.prepare(binary, now); err != nil {
+		return err
+	}
+	if err := root.Right.prepare(binary, now); err != nil {
+		return err
+	}
+
+	if root.Type != nil && (*root.Type == tokenizer.And || *root.Type == tokenizer.Or) &&
+		root.Left.cost() > root.Right.cost() {
+		root.Left, root.Right = root.Right, root.Left
+	}
+	return nil
+}
+
+// costlyAttributes holds the attributes which are computed by reading the
+// file's contents (or, for `is_empty`, the directory's entries), rather than
+// from its metadata.
+var costlyAttributes = map[string]bool{
+	"content": true, "hash": true, "lines": true, "words": true, "mime": true,
+	"kind": true, "is_empty": true,
+}
+
+// cost returns the number of conditions of the tree rooted at root which
+// compare a costly attribute.
+func (root *ConditionNode) cost() int {
+	if root == nil {
+		return 0
+	}
+	if root.Condition != nil {
+		if costlyAttributes[root.Condition.Attribute] {
+			return 1
+		}
+		return 0
+	}
+	return root.Left.cost() + root.Right.cost()
+}
+
 // Condition represents a WHERE condition.
 type Condition struct {
 	Attribute          string
@@ -84,13 +154,41 @@ type Condition struct {
 
 	Subquery   *Query
 	IsSubquery bool
+
+	// binary is true iff the contents of binary files are compared (for the
+	// `content` attribute).
+	binary bool
+
+	// now is the time relative times are relative to (see prepare).
+	now time.Time
 }
 
-// ApplyModifiers applies each modifier to the value of this Condition.
-func (c *Condition) applyModifiers() error {
-	value := c.Value
+// computedModifiers holds the modifiers which are applied to the attribute of
+// a condition rather than to its value, since their result (e.g. the LENGTH of
+// a name) can only be compared with the modified attribute.
+var computedModifiers = map[string]bool{"LENGTH": true, "LEN": true}
 
+// isComputed returns true iff the condition compares the value of its
+// attribute with its modifiers applied (see computedModifiers).
+func (c *Condition) isComputed() bool {
 	for _, m := range c.AttributeModifiers {
+		if computedModifiers[m.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyModifiers applies each modifier to the value of this Condition, unless
+// they're applied to its attribute instead (see isComputed).
+func (c *Condition) applyModifiers() error {
+	modifiers := c.AttributeModifiers
+	if c.isComputed() {
+		modifiers = nil
+	}
+
+	value := c.Value
+	for _, m := range modifiers {
 		var err error
 		value, err = transform.Parse(&transform.ParseParams{
 			Attribute: c.Attribute,
@@ -103,13 +201,83 @@ func (c *Condition) applyModifiers() error {
 		}
 	}
 
+	if c.Operator == tokenizer.Between {
+		if err := checkBounds(c.Attribute, value, c.now); err != nil {
+			return err
+		}
+	}
+
 	c.Value = value
 	c.Parsed = true
 	return nil
 }
 
+// checkBounds returns an error if the low bound of a BETWEEN condition on
+// attribute is greater than its high bound. Only numeric bounds (including
+// sizes with a unit, e.g. `1mb`) and time bounds (including relative times,
+// which are relative to now) are checked.
+func checkBounds(attribute string, value interface{}, now time.Time) error {
+	bounds, ok := value.([]interface{})
+	if !ok || len(bounds) != 2 {
+		return nil
+	}
+
+	low, lowOk := boundValue(attribute, bounds[0], now)
+	high, highOk := boundValue(attribute, bounds[1], now)
+	if !lowOk || !highOk {
+		return nil
+	}
+
+	invalid := false
+	switch l := low.(type) {
+	case float64:
+		if h, ok := high.(float64); ok {
+			invalid = l > h
+		}
+	case time.Time:
+		if h, ok := high.(time.Time); ok {
+			invalid = l.After(h)
+		}
+	}
+
+	if invalid {
+		return fmt.Errorf("invalid bounds for operator between: %v is greater than %v",
+			bounds[0], bounds[1])
+	}
+	return nil
+}
+
+// boundValue returns the bound v of a BETWEEN condition on attribute as a
+// float64 or a time.Time, the same way it's parsed when the condition is
+// evaluated (see evaluate), or false if it's neither.
+func boundValue(attribute string, v interface{}, now time.Time) (interface{}, bool) {
+	switch t := v.(type) {
+	case string:
+		switch attribute {
+		case "size":
+			n, err := transform.ParseSize(t)
+			return n, err == nil
+		case "time", "accessed", "changed", "created":
+			if now.IsZero() {
+				now = time.Now()
+			}
+			tm, err := evaluate.ParseTime(t, now)
+			return tm, err == nil
+		}
+		n, err := strconv.ParseFloat(t, 64)
+		return n, err == nil
+	case int64:
+		return float64(t), true
+	case float64:
+		return t, true
+	case time.Time:
+		return t, true
+	}
+	return nil, false
+}
+
 // evaluate runs the respective evaluate function for this Condition.
-func (c *Condition) evaluate(path string, file os.FileInfo) (bool, error) {
+func (c *Condition) evaluate(path string, file os.FileInfo, depth int64) (bool, error) {
 	// FIXME: This is a bit of a hack. We can't pass c.AttributeModifiers, since
 	// that'll cause a import cycle, so we have to recreate the attribute
 	// modifiers slice using a separate type defined in evaluate.
@@ -121,10 +289,20 @@ func (c *Condition) evaluate(path string, file os.FileInfo) (bool, error) {
 	o := &evaluate.Opts{
 		Path:      path,
 		File:      file,
+		Depth:     depth,
 		Attribute: c.Attribute,
 		Modifiers: modifiers,
 		Operator:  c.Operator,
 		Value:     c.Value,
+		Binary:    c.binary,
+		Now:       c.now,
+	}
+	if c.isComputed() {
+		value, err := formatValue(c.Attribute, c.AttributeModifiers, path, file, depth, c.binary)
+		if err != nil {
+			return false, err
+		}
+		o.Modified = value
 	}
 	result, err := evaluate.Evaluate(o)
 	if err != nil {
@@ -1 +1,149 @@
 package query
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kshvmdn/fsql/tokenizer"
+)
+
+func TestCondition_CheckBounds(t *testing.T) {
+	type Case struct {
+		attribute string
+		value     interface{}
+		expected  error
+	}
+
+	now := time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []Case{
+		{attribute: "depth", value: []interface{}{"1", "10"}, expected: nil},
+		{attribute: "depth", value: []interface{}{"1", "1"}, expected: nil},
+		{attribute: "size", value: []interface{}{int64(1), 1.5}, expected: nil},
+		{attribute: "time", value: []interface{}{now, now.Add(time.Hour)}, expected: nil},
+		{attribute: "name", value: []interface{}{"b", "a"}, expected: nil},
+		{attribute: "size", value: []interface{}{"1kb", "1mb"}, expected: nil},
+		{attribute: "size", value: []interface{}{"1000", "1kb"}, expected: nil},
+		{attribute: "time", value: []interface{}{"2000-01-01", "2100-01-01"}, expected: nil},
+		{attribute: "time", value: []interface{}{"-1000w", "now"}, expected: nil},
+		{attribute: "accessed", value: []interface{}{"today", "now+1h"}, expected: nil},
+
+		{
+			attribute: "depth",
+			value:     []interface{}{"10", "9"},
+			expected:  errors.New("invalid bounds for operator between: 10 is greater than 9"),
+		},
+		{
+			attribute: "time",
+			value:     []interface{}{now.Add(time.Hour), now},
+			expected: errors.New("invalid bounds for operator between: " +
+				"2017-04-01 01:00:00 +0000 UTC is greater than 2017-04-01 00:00:00 +0000 UTC"),
+		},
+		{
+			attribute: "size",
+			value:     []interface{}{"1mb", "1kb"},
+			expected:  errors.New("invalid bounds for operator between: 1mb is greater than 1kb"),
+		},
+		{
+			attribute: "size",
+			value:     []interface{}{"2kb", "1024"},
+			expected:  errors.New("invalid bounds for operator between: 2kb is greater than 1024"),
+		},
+		{
+			attribute: "time",
+			value:     []interface{}{"2100-01-01", "2000-01-01"},
+			expected:  errors.New("invalid bounds for operator between: 2100-01-01 is greater than 2000-01-01"),
+		},
+		{
+			attribute: "changed",
+			value:     []interface{}{"now", "-1000w"},
+			expected:  errors.New("invalid bounds for operator between: now is greater than -1000w"),
+		},
+		{
+			attribute: "time",
+			value:     []interface{}{"today+1d", "today"},
+			expected:  errors.New("invalid bounds for operator between: today+1d is greater than today"),
+		},
+	}
+
+	for _, c := range cases {
+		actual := checkBounds(c.attribute, c.value, now)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
+
+func TestConditionNode_Prepare(t *testing.T) {
+	type Expected struct {
+		first  string
+		result bool
+	}
+
+	type Case struct {
+		typ      tokenizer.TokenType
+		left     *Condition
+		right    *Condition
+		expected Expected
+	}
+
+	info, err := os.Stat("../testdata/foo/quux")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	// The file is evaluated at a nonexistent path, so evaluating a costly
+	// attribute (which reads the file) fails.
+	cases := []Case{
+		{
+			typ:      tokenizer.And,
+			left:     &Condition{Attribute: "content", Operator: tokenizer.Equals, Value: "foo"},
+			right:    &Condition{Attribute: "name", Operator: tokenizer.Equals, Value: "bar"},
+			expected: Expected{first: "name", result: false},
+		},
+		{
+			typ:      tokenizer.Or,
+			left:     &Condition{Attribute: "hash", Operator: tokenizer.Equals, Value: "foo"},
+			right:    &Condition{Attribute: "name", Operator: tokenizer.Equals, Value: "quux"},
+			expected: Expected{first: "name", result: true},
+		},
+		{
+			typ:      tokenizer.And,
+			left:     &Condition{Attribute: "name", Operator: tokenizer.Equals, Value: "bar"},
+			right:    &Condition{Attribute: "lines", Operator: tokenizer.GreaterThan, Value: "1"},
+			expected: Expected{first: "name", result: false},
+		},
+		{
+			typ:      tokenizer.And,
+			left:     &Condition{Attribute: "depth", Operator: tokenizer.Equals, Value: "2"},
+			right:    &Condition{Attribute: "name", Operator: tokenizer.Equals, Value: "quux"},
+			expected: Expected{first: "depth", result: true},
+		},
+	}
+
+	for _, c := range cases {
+		typ := c.typ
+		root := &ConditionNode{
+			Type:  &typ,
+			Left:  &ConditionNode{Condition: c.left},
+			Right: &ConditionNode{Condition: c.right},
+		}
+		if err := root.prepare(false, time.Now()); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if c.expected.first != root.Left.Condition.Attribute {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.first, root.Left.Condition.Attribute)
+		}
+
+		result, err := root.evaluateTree("nonexistent", info, 2)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if c.expected.result != result {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.result, result)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kshvmdn/fsql/tokenizer"
+)
+
+// explainIndent is the indentation of each level of an explained query.
+const explainIndent = "  "
+
+// Explain writes a readable tree of the parsed query to w: the SELECT list
+// (with modifiers, including default ones), the FROM sources (with the
+// matches of each glob pattern), and the WHERE condition tree, followed by the
+// remaining clauses (with each argument of the EXEC command quoted). The query
+// isn't executed, aside from expanding glob patterns.
+func (q *Query) Explain(w io.Writer) error {
+	e := &explainer{w: w}
+	q.explain(e, 0)
+	return e.err
+}
+
+// explainer writes the lines of an explained query, retaining the first write
+// error.
+type explainer struct {
+	w   io.Writer
+	err error
+}
+
+func (e *explainer) line(depth int, format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, "%s%s\n", strings.Repeat(explainIndent, depth),
+		fmt.Sprintf(format, args...))
+}
+
+func (q *Query) explain(e *explainer, depth int) {
+	if q.Distinct {
+		e.line(depth, "SELECT DISTINCT")
+	} else {
+		e.line(depth, "SELECT")
+	}
+	for _, attribute := range q.Attributes {
+		column := attribute
+		if _, ok := q.Aggregates[attribute]; !ok {
+			column = modifiedString(attribute, q.SelectModifiers(attribute))
+		}
+		if alias, ok := q.AttributeAliases[attribute]; ok {
+			column = fmt.Sprintf("%s AS %s", column, alias)
+		}
+		e.line(depth+1, "%s", column)
+	}
+
+	e.line(depth, "FROM")
+	if q.SourceQuery != nil {
+		e.line(depth+1, "(subquery)")
+		q.SourceQuery.explain(e, depth+2)
+	}
+	q.explainSources(e, depth+1)
+
+	if len(q.Exclude) > 0 {
+		e.line(depth, "EXCLUDE")
+		for _, pattern := range q.Exclude {
+			e.line(depth+1, "%s", pattern)
+		}
+	}
+
+	if q.ConditionTree != nil {
+		e.line(depth, "WHERE")
+		q.ConditionTree.explain(e, depth+1)
+	}
+
+	if len(q.GroupBy) > 0 {
+		e.line(depth, "GROUP BY")
+		for _, key := range q.GroupBy {
+			e.line(depth+1, "%s", modifiedString(key.Attribute, key.Modifiers))
+		}
+	}
+
+	if q.Having != nil {
+		e.line(depth, "HAVING")
+		q.Having.explain(e, depth+1)
+	}
+
+	if len(q.OrderBy) > 0 {
+		e.line(depth, "ORDER BY")
+		for _, key := range q.OrderBy {
+			direction := "ASC"
+			if key.Descending {
+				direction = "DESC"
+			}
+			e.line(depth+1, "%s %s", modifiedString(key.Attribute, key.Modifiers), direction)
+		}
+	}
+
+	if q.Limit >= 0 {
+		e.line(depth, "LIMIT %d", q.Limit)
+	}
+	if q.Offset > 0 {
+		e.line(depth, "OFFSET %d", q.Offset)
+	}
+
+	if len(q.Exec) > 0 {
+		args := make([]string, len(q.Exec))
+		for i, arg := range q.Exec {
+			args[i] = quoteValue(arg)
+		}
+		e.line(depth, "EXEC")
+		e.line(depth+1, "%s", strings.Join(args, " "))
+	}
+}
+
+// explainSources writes the query's sources, each followed by its alias (if
+// any). Each glob pattern is followed by its matches, or by the error of
+// expanding it.
+func (q *Query) explainSources(e *explainer, depth int) {
+	aliases := make(map[string]string, len(q.SourceAliases))
+	for alias, src := range q.SourceAliases {
+		aliases[src] = alias
+	}
+
+	for _, src := range q.Sources["include"] {
+		source := src
+		if alias, ok := aliases[src]; ok {
+			source = fmt.Sprintf("%s AS %s", src, alias)
+		}
+		if !isGlob(src) {
+			e.line(depth, "%s", source)
+			continue
+		}
+
+		e.line(depth, "%s (glob)", source)
+		matches, err := expandGlob(src)
+		if err != nil {
+			e.line(depth+1, "error: %v", err)
+			continue
+		}
+		for _, match := range matches {
+			e.line(depth+1, "%s", match)
+		}
+	}
+
+	for _, src := range q.Sources["exclude"] {
+		e.line(depth, "-%s (excluded)", src)
+	}
+}
+
+// explain writes the condition tree rooted at root, with each conjunction
+// (or negation) above its indented operands.
+func (root *ConditionNode) explain(e *explainer, depth int) {
+	if root == nil {
+		return
+	}
+
+	if root.Condition != nil {
+		root.Condition.explain(e, depth)
+		return
+	}
+
+	e.line(depth, "%s", strings.ToUpper(root.Type.String()))
+	root.Left.explain(e, depth+1)
+	root.Right.explain(e, depth+1)
+}
+
+func (c *Condition) explain(e *explainer, depth int) {
+	var buf bytes.Buffer
+	if c.Negate {
+		buf.WriteString("NOT ")
+	}
+	fmt.Fprintf(&buf, "%s %s", modifiedString(c.Attribute, c.AttributeModifiers),
+		operatorString(c.Operator))
+
+	if c.Subquery != nil {
+		buf.WriteString(" (subquery)")
+		e.line(depth, "%s", buf.String())
+		c.Subquery.explain(e, depth+1)
+		return
+	}
+
+	buf.WriteString(" ")
+	buf.WriteString(explainValue(c.Operator, c.Value))
+	e.line(depth, "%s", buf.String())
+}
+
+// operatorString returns the operator t as it's written in a query.
+func operatorString(t tokenizer.TokenType) string {
+	switch t {
+	case tokenizer.Equals:
+		return "="
+	case tokenizer.IEquals:
+		return "=i"
+	case tokenizer.NotEquals:
+		return "<>"
+	case tokenizer.GreaterThanEquals:
+		return ">="
+	case tokenizer.GreaterThan:
+		return ">"
+	case tokenizer.LessThanEquals:
+		return "<="
+	case tokenizer.LessThan:
+		return "<"
+	}
+	return strings.ToUpper(t.String())
+}
+
+// explainValue returns the value of a condition with operator t, with each
+// string quoted (so e.g. surrounding whitespace is visible). The values of an
+// evaluated subquery are sorted.
+func explainValue(t tokenizer.TokenType, value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return quoteValue(v)
+	case []string:
+		values := make([]string, len(v))
+		for i, el := range v {
+			values[i] = quoteValue(el)
+		}
+		return fmt.Sprintf("(%s)", strings.Join(values, ", "))
+	case []interface{}:
+		if t == tokenizer.Between && len(v) == 2 {
+			return fmt.Sprintf("%s AND %s", explainValue(t, v[0]), explainValue(t, v[1]))
+		}
+	case map[interface{}]bool:
+		values := make([]string, 0, len(v))
+		for el := range v {
+			values = append(values, explainValue(t, el))
+		}
+		sort.Strings(values)
+		return fmt.Sprintf("(%s)", strings.Join(values, ", "))
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func quoteValue(s string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(s, "'", "\\'", -1))
+}
@@ -0,0 +1,107 @@
+package query
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isGlob returns true iff src resembles a glob pattern.
+//
+// Pattern reference: https://golang.org/pkg/path/filepath/#Match. Square
+// brackets are only recognized when the source is quoted, since the tokenizer
+// doesn't treat them as part of a directory otherwise.
+func isGlob(src string) bool {
+	return strings.ContainsAny(src, "*?[")
+}
+
+// expandGlob returns the paths matching the glob pattern, in lexical order. In
+// addition to the syntax supported by filepath.Match, a `**` path element
+// matches zero or more directories (e.g. `./src/**/testdata`). Matches may be
+// files or directories. It's an error for the pattern to be malformed or to
+// match nothing.
+func expandGlob(pattern string) ([]string, error) {
+	elems := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+
+	// Matching begins at the longest prefix of pattern without any meta
+	// characters.
+	i := 0
+	for i < len(elems) && !isGlob(elems[i]) {
+		i++
+	}
+	root := strings.Join(elems[:i], "/")
+	if root == "" && i > 0 {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+	elems = elems[i:]
+
+	for _, elem := range elems {
+		if _, err := filepath.Match(elem, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s: %v", pattern, err)
+		}
+	}
+
+	var matches []string
+	if !strings.Contains(pattern, "**") {
+		var err error
+		if matches, err = filepath.Glob(pattern); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s: %v", pattern, err)
+		}
+	} else {
+		root = filepath.FromSlash(root)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			var names []string
+			if rel != "." {
+				names = strings.Split(filepath.ToSlash(rel), "/")
+			}
+			if matchElems(elems, names) {
+				matches = append(matches, path)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match glob pattern %s", pattern)
+	}
+	return matches, nil
+}
+
+// matchElems reports whether the path elements names match the pattern
+// elements, where a `**` element matches zero or more names.
+func matchElems(pattern, names []string) bool {
+	if len(pattern) == 0 {
+		return len(names) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchElems(pattern[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], names[0]); !ok {
+		return false
+	}
+	return matchElems(pattern[1:], names[1:])
+}
@@ -0,0 +1,105 @@
+package query
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob_MatchElems(t *testing.T) {
+	type Case struct {
+		pattern  []string
+		names    []string
+		expected bool
+	}
+
+	cases := []Case{
+		{pattern: []string{"foo"}, names: []string{"foo"}, expected: true},
+		{pattern: []string{"f*"}, names: []string{"foo"}, expected: true},
+		{pattern: []string{"f*"}, names: []string{"foo", "bar"}, expected: false},
+		{pattern: []string{"**"}, names: nil, expected: true},
+		{pattern: []string{"**"}, names: []string{"foo", "bar"}, expected: true},
+		{pattern: []string{"**", "bar"}, names: []string{"bar"}, expected: true},
+		{pattern: []string{"**", "bar"}, names: []string{"foo", "baz", "bar"}, expected: true},
+		{pattern: []string{"**", "bar"}, names: []string{"foo", "bar", "baz"}, expected: false},
+		{pattern: []string{"foo", "**", "[a-c]*"}, names: []string{"foo", "x", "baz"}, expected: true},
+		{pattern: []string{"foo", "**", "[a-c]*"}, names: []string{"foo", "x", "qux"}, expected: false},
+	}
+
+	for _, c := range cases {
+		actual := matchElems(c.pattern, c.names)
+		if actual != c.expected {
+			t.Fatalf("%v, %v\nExpected %v\n     Got %v", c.pattern, c.names, c.expected, actual)
+		}
+	}
+}
+
+func TestGlob_ExpandGlob(t *testing.T) {
+	type Expected struct {
+		matches []string
+		err     error
+	}
+
+	type Case struct {
+		pattern  string
+		expected Expected
+	}
+
+	cases := []Case{
+		{
+			pattern: "../testdata/*/qu*",
+			expected: Expected{matches: []string{
+				filepath.Join("..", "testdata", "foo", "quux"),
+				filepath.Join("..", "testdata", "foo", "quuz"),
+				filepath.Join("..", "testdata", "foo", "qux"),
+			}},
+		},
+		{
+			pattern: "../testdata/**/.gitkeep",
+			expected: Expected{matches: []string{
+				filepath.Join("..", "testdata", "bar", "garply", "xyzzy", "thud", ".gitkeep"),
+				filepath.Join("..", "testdata", "foo", "quuz", "fred", ".gitkeep"),
+			}},
+		},
+		{
+			pattern: "../testdata/**/[b]a*",
+			expected: Expected{matches: []string{
+				filepath.Join("..", "testdata", "bar"),
+				filepath.Join("..", "testdata", "baz"),
+			}},
+		},
+		{
+			pattern: "../testdata/**/nonexistent",
+			expected: Expected{
+				err: errors.New("no files match glob pattern ../testdata/**/nonexistent"),
+			},
+		},
+		{
+			pattern: "../nonexistent/**",
+			expected: Expected{
+				err: errors.New("no files match glob pattern ../nonexistent/**"),
+			},
+		},
+		{
+			pattern: "../testdata/[a",
+			expected: Expected{
+				err: errors.New("invalid glob pattern ../testdata/[a: syntax error in pattern"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		matches, err := expandGlob(c.pattern)
+		if c.expected.err == nil {
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+			if !reflect.DeepEqual(c.expected.matches, matches) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected.matches, matches)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected.err, err)
+		}
+	}
+}
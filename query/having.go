@@ -0,0 +1,154 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kshvmdn/fsql/tokenizer"
+	"github.com/kshvmdn/fsql/transform"
+)
+
+// evaluateGroup evaluates the HAVING condition tree rooted at root against the
+// group g of q, with the same short-circuiting as evaluateTree. An empty tree
+// is satisfied by every group.
+func (root *ConditionNode) evaluateGroup(q *Query, g *group) (bool, error) {
+	if root == nil {
+		return true, nil
+	}
+
+	if root.Condition != nil {
+		return root.Condition.evaluateGroup(q, g)
+	}
+
+	switch *root.Type {
+	case tokenizer.And:
+		if ok, err := root.Left.evaluateGroup(q, g); err != nil || !ok {
+			return false, err
+		}
+		return root.Right.evaluateGroup(q, g)
+	case tokenizer.Or:
+		if ok, err := root.Left.evaluateGroup(q, g); err != nil || ok {
+			return ok, err
+		}
+		return root.Right.evaluateGroup(q, g)
+	case tokenizer.Not:
+		ok, err := root.Left.evaluateGroup(q, g)
+		return !ok && err == nil, err
+	}
+	return false, nil
+}
+
+// evaluateGroup evaluates the condition against the value of its aggregate or
+// GROUP BY key for the group g of q. A nil value (e.g. the AVG of zero files)
+// never satisfies the condition, even if it's negated.
+func (c *Condition) evaluateGroup(q *Query, g *group) (bool, error) {
+	value := c.groupValue(q, g)
+	if value == nil {
+		return false, nil
+	}
+
+	var ok bool
+	switch values := c.Value.(type) {
+	case []string:
+		if c.Operator != tokenizer.In {
+			return false, fmt.Errorf("unexpected list of values for operator %s", c.Operator.String())
+		}
+		for _, literal := range values {
+			cmp, err := c.compareGroupValue(value, literal)
+			if err != nil {
+				return false, err
+			}
+			if cmp == 0 {
+				ok = true
+				break
+			}
+		}
+	case []interface{}:
+		low, err := c.compareGroupValue(value, fmt.Sprint(values[0]))
+		if err != nil {
+			return false, err
+		}
+		high, err := c.compareGroupValue(value, fmt.Sprint(values[1]))
+		if err != nil {
+			return false, err
+		}
+		ok = low >= 0 && high <= 0
+	case string:
+		cmp, err := c.compareGroupValue(value, values)
+		if err != nil {
+			return false, err
+		}
+		switch c.Operator {
+		case tokenizer.Equals:
+			ok = cmp == 0
+		case tokenizer.NotEquals:
+			ok = cmp != 0
+		case tokenizer.GreaterThanEquals:
+			ok = cmp >= 0
+		case tokenizer.GreaterThan:
+			ok = cmp > 0
+		case tokenizer.LessThanEquals:
+			ok = cmp <= 0
+		case tokenizer.LessThan:
+			ok = cmp < 0
+		default:
+			return false, fmt.Errorf("unsupported operator %s in HAVING", c.Operator.String())
+		}
+	default:
+		return false, fmt.Errorf("unsupported value %v in HAVING", c.Value)
+	}
+	return ok != c.Negate, nil
+}
+
+// groupValue returns the value of the condition's aggregate or GROUP BY key
+// for the group g of q.
+func (c *Condition) groupValue(q *Query, g *group) interface{} {
+	if acc, ok := g.accumulators[c.Attribute]; ok {
+		return acc.result()
+	}
+	key := modifiedString(c.Attribute, c.AttributeModifiers)
+	for i, k := range q.GroupBy {
+		if modifiedString(k.Attribute, k.Modifiers) == key {
+			return g.keys[i]
+		}
+	}
+	return nil
+}
+
+// compareGroupValue returns -1, 0, or 1 if value is less than, equal to, or
+// greater than literal. Numbers are compared numerically, with literal parsed
+// as a number (or size, e.g. `1gb`), booleans as booleans, and all other values
+// alphabetically.
+func (c *Condition) compareGroupValue(value interface{}, literal string) (int, error) {
+	switch v := value.(type) {
+	case int64, float64:
+		n, err := transform.ParseSize(literal)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %s for %s: expected a number",
+				literal, c.Attribute)
+		}
+		return compareFloats(toFloat(v), n), nil
+	case bool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %s for %s: expected true or false",
+				literal, c.Attribute)
+		}
+		if v == b {
+			return 0, nil
+		} else if b {
+			return -1, nil
+		}
+		return 1, nil
+	}
+	return strings.Compare(fmt.Sprint(value), literal), nil
+}
+
+// toFloat returns the numeric value n as a float64.
+func toFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
@@ -3,6 +3,7 @@ package query
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/kshvmdn/fsql/transform"
@@ -18,38 +19,98 @@ func (m *Modifier) String() string {
 	return fmt.Sprintf("%s(%s)", m.Name, strings.Join(m.Arguments, ", "))
 }
 
-// applyModifiers iterates through each SELECT attribute for this query
-// and applies the associated modifier to the attribute's output value.
-func (q *Query) applyModifiers(path string, info os.FileInfo) (map[string]interface{}, error) {
+// modifiedString returns the attribute with the modifiers applied as it's
+// written in a query, e.g. `UPPER(FORMAT(name, LOWER))`.
+func modifiedString(attribute string, modifiers []Modifier) string {
+	for _, m := range modifiers {
+		args := append([]string{attribute}, m.Arguments...)
+		attribute = fmt.Sprintf("%s(%s)", m.Name, strings.Join(args, ", "))
+	}
+	return attribute
+}
+
+// applyModifiers iterates through each SELECT attribute for this query and
+// applies the associated modifier (see SelectModifiers) to the attribute's
+// output value. The value of an aggregate attribute is the value it
+// accumulates (including that of each aggregate which is only used in HAVING).
+// If q.Absolute is set, path-like values (`path` and `FULLPATH(name)`) are
+// absolute paths.
+func (q *Query) applyModifiers(path string, info os.FileInfo, depth int64) (map[string]interface{}, error) {
 	results := make(map[string]interface{}, len(q.Attributes))
 
+	output := path
+	if q.Absolute {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		output = abs
+	}
+
 	for _, attribute := range q.Attributes {
-		value, err := transform.DefaultFormatValue(attribute, path, info)
+		var value interface{}
+		var err error
+		if aggregate, ok := q.Aggregates[attribute]; ok {
+			value, err = aggregate.value(path, info, depth, q.ScanBinary)
+		} else if q.Absolute && attribute == "path" {
+			value, err = formatValue("abspath", q.SelectModifiers(attribute), output, info,
+				depth, q.ScanBinary)
+		} else {
+			value, err = formatValue(attribute, q.SelectModifiers(attribute), output, info,
+				depth, q.ScanBinary)
+		}
 		if err != nil {
 			return map[string]interface{}{}, err
 		}
+		results[attribute] = value
+	}
 
-		if _, ok := q.Modifiers[attribute]; !ok {
-			results[attribute] = value
+	// Aggregates which are only used in HAVING are accumulated, but not shown.
+	for column, aggregate := range q.Aggregates {
+		if _, ok := results[column]; ok {
 			continue
 		}
-
-		for _, m := range q.Modifiers[attribute] {
-			value, err = transform.Format(&transform.FormatParams{
-				Attribute: attribute,
-				Path:      path,
-				Info:      info,
-				Value:     value,
-				Name:      m.Name,
-				Args:      m.Arguments,
-			})
-			if err != nil {
-				return map[string]interface{}{}, err
-			}
+		value, err := aggregate.value(path, info, depth, q.ScanBinary)
+		if err != nil {
+			return map[string]interface{}{}, err
 		}
-
-		results[attribute] = value
+		results[column] = value
 	}
 
 	return results, nil
 }
+
+// formatValue returns the default format value of attribute with each of
+// modifiers applied in order. Binary is true iff binary files are scanned for
+// attributes derived from file contents (e.g. `lines` and `words`).
+func formatValue(attribute string, modifiers []Modifier, path string,
+	info os.FileInfo, depth int64, binary bool) (interface{}, error) {
+	var value interface{}
+	var err error
+	if attribute == "lines" {
+		value, err = transform.LineCount(path, info, binary)
+	} else if attribute == "words" {
+		value, err = transform.WordCount(path, info, binary)
+	} else {
+		value, err = transform.DefaultFormatValueAt(attribute, path, info, depth)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range modifiers {
+		value, err = transform.Format(&transform.FormatParams{
+			Attribute: attribute,
+			Path:      path,
+			Info:      info,
+			Value:     value,
+			Name:      m.Name,
+			Args:      m.Arguments,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,141 @@
+package query
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kshvmdn/fsql/transform"
+)
+
+// OrderKey represents a single key of a query's ORDER BY clause.
+type OrderKey struct {
+	Attribute  string
+	Modifiers  []Modifier
+	Descending bool
+}
+
+func (k *OrderKey) String() string {
+	direction := "ASC"
+	if k.Descending {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s", k.Attribute, direction)
+}
+
+// value returns the value of the file that this key orders by. Unmodified
+// times and modes are ordered by their underlying values rather than by their
+// default output format.
+func (k *OrderKey) value(path string, info os.FileInfo, depth int64, binary bool) (interface{}, error) {
+	if len(k.Modifiers) == 0 {
+		switch k.Attribute {
+		case "time", "accessed", "changed", "created":
+			t, _ := transform.FileTime(k.Attribute, info)
+			return t, nil
+		case "mode":
+			return info.Mode(), nil
+		}
+	}
+	return formatValue(k.Attribute, k.Modifiers, path, info, depth, binary)
+}
+
+// result represents a single file which satisfies a query.
+type result struct {
+	path   string
+	info   os.FileInfo
+	depth  int64
+	values map[string]interface{}
+
+	// keys holds the value of each of the query's ORDER BY keys.
+	keys []interface{}
+
+	// group holds the value of each of the query's GROUP BY keys.
+	group []interface{}
+}
+
+// orderResults sorts results by each of the query's ORDER BY keys, falling
+// through to the next key on ties.
+func (q *Query) orderResults(results []*result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		for k, key := range q.OrderBy {
+			cmp := q.compareKeys(results[i].keys[k], results[j].keys[k])
+			if cmp == 0 {
+				continue
+			}
+			if key.Descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+// compareKeys compares the values a and b of an ORDER BY key (see
+// compareValues), with strings compared by the query's collator, if any.
+func (q *Query) compareKeys(a, b interface{}) int {
+	if q.Collator != nil {
+		if a, ok := a.(string); ok {
+			if b, ok := b.(string); ok {
+				return q.Collator.CompareString(a, b)
+			}
+		}
+	}
+	return compareValues(a, b)
+}
+
+// compareValues returns -1, 0, or 1 if a is less than, equal to, or greater
+// than b. Numbers (and sizes formatted as a transform.Size) are compared
+// numerically, even if one is an int64 and the other a float64, times
+// chronologically, and all other values alphabetically.
+func compareValues(a, b interface{}) int {
+	if a, ok := numeric(a); ok {
+		if b, ok := numeric(b); ok {
+			return compareFloats(a, b)
+		}
+	}
+	switch a := a.(type) {
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			if a.Before(b) {
+				return -1
+			} else if a.After(b) {
+				return 1
+			}
+			return 0
+		}
+	case os.FileMode:
+		if b, ok := b.(os.FileMode); ok {
+			return compareFloats(float64(a), float64(b))
+		}
+	case transform.Size:
+		if b, ok := b.(transform.Size); ok {
+			return compareFloats(float64(a.Bytes), float64(b.Bytes))
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// numeric returns value as a float64, if it's an int64 or a float64.
+func numeric(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// compareFloats returns -1, 0, or 1 if a is less than, equal to, or greater
+// than b.
+func compareFloats(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
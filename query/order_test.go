@@ -0,0 +1,178 @@
+package query
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kshvmdn/fsql/transform"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+func TestOrder_CompareValues(t *testing.T) {
+	type Case struct {
+		a        interface{}
+		b        interface{}
+		expected int
+	}
+
+	now := time.Now()
+
+	cases := []Case{
+		{a: int64(2), b: int64(10), expected: -1},
+		{a: int64(10), b: int64(2), expected: 1},
+		{a: int64(2), b: int64(2), expected: 0},
+		{a: 1.5, b: 0.5, expected: 1},
+		{a: int64(10), b: 9.5, expected: 1},
+		{a: 9.5, b: int64(10), expected: -1},
+		{a: 2.0, b: int64(2), expected: 0},
+		{a: "b", b: "a", expected: 1},
+		{a: "a", b: "b", expected: -1},
+		{a: now, b: now.Add(time.Hour), expected: -1},
+		{a: now, b: now, expected: 0},
+		{a: os.FileMode(0755), b: os.FileMode(0644), expected: 1},
+		{a: nil, b: "a", expected: -1},
+		{
+			a:        transform.Size{Bytes: 900, Human: "900 B", Unit: "B"},
+			b:        transform.Size{Bytes: 2000, Human: "2.0 KB", Unit: "KB"},
+			expected: -1,
+		},
+	}
+
+	for _, c := range cases {
+		actual := compareValues(c.a, c.b)
+		if actual != c.expected {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
+
+func TestQuery_Page(t *testing.T) {
+	type Case struct {
+		limit    int
+		offset   int
+		expected []string
+	}
+
+	cases := []Case{
+		{limit: -1, offset: 0, expected: []string{"a", "b", "c"}},
+		{limit: 2, offset: 0, expected: []string{"a", "b"}},
+		{limit: 2, offset: 2, expected: []string{"c"}},
+		{limit: -1, offset: 1, expected: []string{"b", "c"}},
+		{limit: 0, offset: 0, expected: []string{}},
+		{limit: 1, offset: 5, expected: []string{}},
+	}
+
+	for _, c := range cases {
+		q := &Query{Limit: c.limit, Offset: c.offset}
+		results := []*result{{path: "a"}, {path: "b"}, {path: "c"}}
+
+		actual := make([]string, 0)
+		for _, r := range q.page(results) {
+			actual = append(actual, r.path)
+		}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
+
+func TestOrder_OrderResults(t *testing.T) {
+	type Case struct {
+		keys     []OrderKey
+		expected []string
+	}
+
+	results := func() []*result {
+		return []*result{
+			{path: "a", keys: []interface{}{int64(1), "a"}},
+			{path: "b", keys: []interface{}{int64(2), "b"}},
+			{path: "c", keys: []interface{}{int64(1), "c"}},
+		}
+	}
+
+	cases := []Case{
+		{
+			keys:     []OrderKey{{Attribute: "size"}},
+			expected: []string{"a", "c", "b"},
+		},
+		{
+			keys:     []OrderKey{{Attribute: "size", Descending: true}},
+			expected: []string{"b", "a", "c"},
+		},
+		{
+			keys: []OrderKey{
+				{Attribute: "size"},
+				{Attribute: "name", Descending: true},
+			},
+			expected: []string{"c", "a", "b"},
+		},
+	}
+
+	for _, c := range cases {
+		q := &Query{OrderBy: c.keys}
+		ordered := results()
+		q.orderResults(ordered)
+
+		actual := make([]string, len(ordered))
+		for i, r := range ordered {
+			actual[i] = r.path
+		}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
+
+func TestOrder_OrderResultsCollated(t *testing.T) {
+	type Case struct {
+		collator *collate.Collator
+		keys     []OrderKey
+		expected []string
+	}
+
+	results := func() []*result {
+		return []*result{
+			{path: "f", keys: []interface{}{"f"}},
+			{path: "E", keys: []interface{}{"E"}},
+			{path: "\u00e9", keys: []interface{}{"\u00e9"}},
+			{path: "e", keys: []interface{}{"e"}},
+			{path: "10", keys: []interface{}{int64(10)}},
+			{path: "9", keys: []interface{}{int64(9)}},
+		}
+	}
+
+	en := collate.New(language.English)
+	cases := []Case{
+		{
+			keys:     []OrderKey{{Attribute: "name"}},
+			expected: []string{"9", "10", "E", "e", "f", "\u00e9"},
+		},
+		{
+			collator: en,
+			keys:     []OrderKey{{Attribute: "name"}},
+			expected: []string{"9", "10", "e", "E", "\u00e9", "f"},
+		},
+		{
+			collator: en,
+			keys:     []OrderKey{{Attribute: "name", Descending: true}},
+			expected: []string{"f", "\u00e9", "E", "e", "10", "9"},
+		},
+	}
+
+	for _, c := range cases {
+		q := &Query{OrderBy: c.keys, Collator: c.collator}
+		ordered := results()
+		q.orderResults(ordered)
+
+		actual := make([]string, len(ordered))
+		for i, r := range ordered {
+			actual[i] = r.path
+		}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
@@ -1,20 +1,130 @@
 package query
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/kshvmdn/fsql/evaluate"
+	"github.com/kshvmdn/fsql/tokenizer"
+	"github.com/kshvmdn/fsql/transform"
+	"golang.org/x/text/collate"
 )
 
 // Query represents an input query.
 type Query struct {
 	Attributes []string
 	Modifiers  map[string][]Modifier
+	Distinct   bool
+
+	// DefaultModifiers maps attributes (e.g. `size`) to the modifiers applied
+	// to their output values when they're selected without any modifiers of
+	// their own (e.g. `FORMAT(size, MB)`), as set by a default size unit or
+	// time format. Only the selected values are affected, not conditions or
+	// aggregates. GROUP BY keys without modifiers are grouped by their output
+	// values too, so e.g. times which are shown as the same year are a single
+	// group.
+	DefaultModifiers map[string][]Modifier
+
+	// Aggregates maps each aggregate attribute (e.g. `COUNT(*)`) to its
+	// Aggregate.
+	Aggregates map[string]Aggregate
+
+	// AttributeAliases maps each aliased attribute (or aggregate) of the
+	// SELECT clause to its alias (e.g. `SELECT name AS filename`), which names
+	// its output column (see Column).
+	AttributeAliases map[string]string
 
 	Sources       map[string][]string
 	SourceAliases map[string]string
 
+	// SourceQuery is the subquery of the FROM clause (e.g. `FROM (SELECT ...)`),
+	// if any. Its results are the files of this query, in place of Sources.
+	SourceQuery *Query
+
+	// Exclude holds the LIKE patterns of directory names to prune from the
+	// walk (e.g. `node_modules`).
+	Exclude []string
+
 	ConditionTree *ConditionNode
+
+	// Now is the moment which the relative times of the query's conditions
+	// (e.g. `now() - 1h`) are relative to, shared by its subqueries so that
+	// they're all relative to the same moment. Defaults to when the query is
+	// first run.
+	Now time.Time
+
+	GroupBy []GroupKey
+
+	// Having is the condition tree of the HAVING clause, if any, which filters
+	// the query's groups (before ORDER BY and LIMIT). The attribute of each of
+	// its conditions is either the column of an aggregate (which is in
+	// Aggregates, but only in Attributes if it's selected) or the attribute of
+	// a GROUP BY key (with the key's modifiers).
+	Having *ConditionNode
+
+	OrderBy []OrderKey
+
+	// Limit is the maximum number of results, or -1 if there's no limit.
+	Limit  int
+	Offset int
+
+	// Exec is the command (and its arguments) of the EXEC clause, if any,
+	// which is run for the query's results in place of showing them. `{}` is
+	// replaced by the path of each result.
+	Exec []string
+
+	// Jobs is the number of goroutines used to walk each source. With fewer
+	// than 2, sources are walked sequentially (and files are found in lexical
+	// order).
+	Jobs int
+
+	// FollowSymlinks is true iff symbolic links are followed while walking.
+	FollowSymlinks bool
+
+	// MaxDepth is the maximum depth of the files found by walking each source
+	// (e.g. 1 for the source's direct children, like `ls`), or 0 if there's no
+	// maximum. Directories at the maximum depth aren't descended into.
+	MaxDepth int
+
+	// SameDevice is true iff the walk of each source is restricted to the
+	// device (i.e. filesystem) the source is on, like `find -xdev`.
+	// Directories on any other device (e.g. mount points) are skipped.
+	SameDevice bool
+
+	// Absolute is true iff path-like output values (`path` and
+	// `FULLPATH(name)`) are absolute paths, as for `abspath`, regardless of
+	// whether the sources are relative. Conditions and ordering aren't
+	// affected.
+	Absolute bool
+
+	// ScanBinary is true iff the contents of binary files are searched by
+	// conditions on the `content` attribute (and their `lines` and `words`
+	// counted).
+	ScanBinary bool
+
+	// Collator, if set, orders strings in ORDER BY by the collation of its
+	// language (e.g. so `é` sorts next to `e`), instead of byte by byte.
+	Collator *collate.Collator
+
+	// Warnings holds the warnings of parsing the query (e.g. an environment
+	// variable in FROM which isn't set), which don't prevent it from running.
+	Warnings []string
+
+	// Skipped holds the errors of the files which couldn't be read (e.g. due
+	// to permissions) and were skipped while walking.
+	Skipped []error
+
+	// Unreadable holds the errors of the directories whose entries couldn't be
+	// read (e.g. for `is_empty`), which are treated as not empty rather than
+	// skipped.
+	Unreadable []error
+	mu         sync.Mutex
 }
 
 // NewQuery returns a pointer to a Query.
@@ -28,7 +138,27 @@ func NewQuery() *Query {
 		},
 		SourceAliases: make(map[string]string),
 		ConditionTree: nil,
+		Limit:         -1,
+	}
+}
+
+// Column returns the name of the output column of attribute (one of
+// Attributes), which is its alias if it has one.
+func (q *Query) Column(attribute string) string {
+	if alias, ok := q.AttributeAliases[attribute]; ok {
+		return alias
 	}
+	return attribute
+}
+
+// SelectModifiers returns the modifiers applied to the output values of
+// attribute (one of Attributes), which are its DefaultModifiers unless it has
+// modifiers of its own.
+func (q *Query) SelectModifiers(attribute string) []Modifier {
+	if modifiers := q.Modifiers[attribute]; len(modifiers) > 0 {
+		return modifiers
+	}
+	return q.DefaultModifiers[attribute]
 }
 
 // HasAttribute checks if this query contains any of the provided attributes.
@@ -43,36 +173,144 @@ func (q *Query) HasAttribute(attributes ...string) bool {
 	return false
 }
 
+// errLimitReached is returned from the walk function to stop walking once the
+// query's LIMIT has been reached.
+var errLimitReached = errors.New("limit reached")
+
 // Execute runs the query by walking the full path of each source and
 // evaluating the condition tree for each file. This method calls workFunc on
-// each "successful" file.
+// each "successful" file. If the query is DISTINCT, files whose output
+// duplicates that of a previous file are skipped. If the query has an ORDER BY
+// clause, the files are buffered and workFunc is called in order once every
+// source has been walked. Otherwise, walking stops as soon as the query's LIMIT
+// is reached. If the query has aggregates or a GROUP BY clause, workFunc is
+// called once with each group's summary row (with an empty path and nil info).
 func (q *Query) Execute(workFunc interface{}) error {
-	seen := map[string]bool{}
+	work := workFunc.(func(string, os.FileInfo, map[string]interface{}))
+	return q.execute(func(r *result) { work(r.path, r.info, r.values) })
+}
+
+// execute runs the query (see Execute), calling work on each result.
+func (q *Query) execute(work func(*result)) error {
+	results := make([]*result, 0)
+	skipped, emitted := 0, 0
+	rows := make(map[string]bool)
+
+	var agg *aggregator
+	if q.isGrouped() {
+		agg = newAggregator(q)
+	}
+
+	emit := func(r *result) error {
+		if agg != nil {
+			return agg.add(r)
+		}
+		if q.Distinct {
+			row := q.row(r.values)
+			if rows[row] {
+				return nil
+			}
+			rows[row] = true
+		}
+		if len(q.OrderBy) > 0 {
+			results = append(results, r)
+			return nil
+		}
+		if skipped < q.Offset {
+			skipped++
+			return nil
+		}
+		if q.Limit >= 0 && emitted >= q.Limit {
+			return errLimitReached
+		}
+		work(r)
+		if emitted++; q.Limit >= 0 && emitted >= q.Limit {
+			return errLimitReached
+		}
+		return nil
+	}
+
+	if err := q.walk(emit); err != nil && err != errLimitReached {
+		return err
+	}
+
+	if agg != nil {
+		var err error
+		if results, err = agg.results(); err != nil {
+			return err
+		}
+	}
+	q.orderResults(results)
+	for _, r := range q.page(results) {
+		work(r)
+	}
+
+	return nil
+}
+
+// isGrouped returns true iff the query's results are groups of files (i.e. the
+// query has aggregates or a GROUP BY clause).
+func (q *Query) isGrouped() bool {
+	return len(q.Aggregates) > 0 || len(q.GroupBy) > 0
+}
+
+// walk walks each source of the query, calling emit on each "successful"
+// file. Sources are walked in order, and a file reachable from more than one
+// source is only evaluated for the first. If the query has multiple jobs, emit
+// is never called concurrently.
+func (q *Query) walk(emit func(*result) error) error {
+	seen := &visited{paths: make(map[string]bool)}
 	excluder := &regexpExclude{exclusions: q.Sources["exclude"]}
+	excluder.buildRegex()
+
+	// Conditions are parsed up front, so they're not modified while walking.
+	if q.Now.IsZero() {
+		q.Now = time.Now()
+	}
+	if err := q.ConditionTree.prepare(q.ScanBinary, q.Now); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	emitFunc := func(r *result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return emit(r)
+	}
+
+	if q.SourceQuery != nil {
+		return q.walkSourceQuery(emitFunc)
+	}
+
+	// Archives (e.g. `FROM ./backup.zip`) are walked as if they were
+	// directories.
+	walk := func(root string, walkFn filepath.WalkFunc) error {
+		if format := archiveFormat(root); format != "" {
+			if info, err := os.Stat(root); err == nil && info.Mode().IsRegular() {
+				return walkArchive(root, format, info, walkFn)
+			}
+		}
+		return walkTree(root, q.Jobs, q.FollowSymlinks, walkFn)
+	}
 
 	for _, src := range q.Sources["include"] {
-		// TODO: Improve our method of detecting if src is a glob pattern. This
-		// currently doesn't support usage of square brackets, since the tokenizer
-		// doesn't recognize these as part of a directory.
-		//
-		// Pattern reference: https://golang.org/pkg/path/filepath/#Match.
-		if strings.ContainsAny(src, "*?") {
+		if isGlob(src) {
 			// If src does _resemble_ a glob pattern, we find all matches and
 			// evaluate the condition tree against each.
-			matches, err := filepath.Glob(src)
+			matches, err := expandGlob(src)
 			if err != nil {
 				return err
 			}
 
 			for _, match := range matches {
-				if err = filepath.Walk(match, q.walkFunc(seen, excluder, workFunc)); err != nil {
+				if err = walk(match, q.walkFunc(match, seen, excluder, emitFunc)); err != nil {
 					return err
 				}
 			}
 			continue
 		}
 
-		if err := filepath.Walk(src, q.walkFunc(seen, excluder, workFunc)); err != nil {
+		if err := walk(src, q.walkFunc(src, seen, excluder, emitFunc)); err != nil {
 			return err
 		}
 	}
@@ -80,40 +318,284 @@ func (q *Query) Execute(workFunc interface{}) error {
 	return nil
 }
 
+// walkSourceQuery executes the query's SourceQuery and evaluates the condition
+// tree against each of its results in order. Each file is stat'd again, so its
+// attributes are resolved the same way as for a file found by walking, except
+// that depth is the file's depth in the subquery. Directories aren't walked,
+// only the files found by the subquery are evaluated.
+func (q *Query) walkSourceQuery(emit func(*result) error) error {
+	sub := q.SourceQuery
+	sub.Jobs, sub.FollowSymlinks, sub.ScanBinary = q.Jobs, q.FollowSymlinks, q.ScanBinary
+	sub.SameDevice, sub.MaxDepth, sub.Collator = q.SameDevice, q.MaxDepth, q.Collator
+	sub.Now = q.Now
+
+	results := make([]*result, 0)
+	err := sub.execute(func(r *result) { results = append(results, r) })
+	for _, err := range sub.Skipped {
+		q.skip(err)
+	}
+	for _, err := range sub.Unreadable {
+		q.unreadable(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	stat := os.Lstat
+	if q.FollowSymlinks {
+		stat = os.Stat
+	}
+	for _, r := range results {
+		info, err := stat(r.path)
+		if err != nil {
+			// E.g. the file was removed since the subquery found it.
+			q.skip(err)
+			continue
+		}
+		cached := newFileInfo(r.path, info, info)
+		if q.FollowSymlinks {
+			cached = newFileInfo(r.path, info, nil)
+		}
+		if err := q.evaluateFile(r.path, cached, r.depth, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visited holds the set of files which have been visited while walking.
+type visited struct {
+	sync.Mutex
+	paths map[string]bool
+}
+
+// visit marks path as visited. Returns false iff path was already visited.
+func (v *visited) visit(path string) bool {
+	v.Lock()
+	defer v.Unlock()
+	if v.paths[path] {
+		return false
+	}
+	v.paths[path] = true
+	return true
+}
+
+// row returns a key which uniquely identifies the output values of a single
+// result.
+func (q *Query) row(values map[string]interface{}) string {
+	row := make([]interface{}, len(q.Attributes))
+	for i, attribute := range q.Attributes {
+		row[i] = values[attribute]
+	}
+	return rowKey(row)
+}
+
+// rowKey returns a key which uniquely identifies values.
+func rowKey(values []interface{}) string {
+	var buf bytes.Buffer
+	for _, value := range values {
+		fmt.Fprintf(&buf, "%T:%v\x00", value, value)
+	}
+	return buf.String()
+}
+
+// page returns the results remaining after applying the query's OFFSET and
+// LIMIT.
+func (q *Query) page(results []*result) []*result {
+	if q.Offset >= len(results) {
+		return results[:0]
+	}
+	results = results[q.Offset:]
+	if q.Limit >= 0 && q.Limit < len(results) {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
 // walkFunc returns a filepath.WalkFunc which evaluates the condition tree
-// against the given file.
-func (q *Query) walkFunc(seen map[string]bool, excluder Excluder,
-	workFunc interface{}) filepath.WalkFunc {
+// against the given file. src is the root of the walk.
+func (q *Query) walkFunc(src string, seen *visited, excluder Excluder,
+	emit func(*result) error) filepath.WalkFunc {
+	root := resolvePath(src)
+	var device int64
 	return func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return err
+			// Unreadable files and directories are skipped, unless the source
+			// itself can't be read.
+			if path == src {
+				return err
+			}
+			return q.skip(err)
+		}
+
+		// The source is always visited first, so its device is known before
+		// any of its subdirectories are compared to it. The entries of an
+		// archive are on the archive's device.
+		_, inArchive := info.(*archiveInfo)
+		if q.SameDevice && !inArchive {
+			if path == src {
+				device = transform.Device(info)
+			} else if info.IsDir() && transform.Device(info) != device {
+				return filepath.SkipDir
+			}
 		}
 
 		if path == "." {
 			return nil
 		}
 
-		// Avoid walking a single directory more than once.
-		if _, ok := seen[path]; ok {
+		// Unless walkTree followed a symbolic link, info is that of os.Lstat.
+		// The entries of an archive are never stat'd.
+		if _, ok := info.(*fileInfo); !ok && !inArchive {
+			info = newFileInfo(path, info, info)
+		}
+
+		// Avoid walking a single directory more than once. Paths are compared
+		// relative to the resolved source, so overlapping sources (e.g. `., ./foo`)
+		// don't report the same file twice.
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.Join(root, rel)
+		if !seen.visit(key) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-		seen[path] = true
 
 		if excluder.shouldExclude(path) {
 			return nil
 		}
 
-		if ok, err := q.ConditionTree.evaluateTree(path, info); err != nil {
+		// Pruned directories (and their contents) are skipped entirely. The
+		// source itself is never pruned.
+		if info.IsDir() && path != src {
+			if ok, err := q.isPruned(info); err != nil {
+				return err
+			} else if ok {
+				return filepath.SkipDir
+			}
+		}
+
+		depth := relativeDepth(src, path)
+		if err := q.evaluateFile(path, info, depth, emit); err != nil {
 			return err
-		} else if !ok {
-			return nil
 		}
 
-		results, err := q.applyModifiers(path, info)
+		// Directories at the maximum depth are evaluated, but not descended
+		// into.
+		if info.IsDir() && q.MaxDepth > 0 && depth >= int64(q.MaxDepth) {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+}
+
+// evaluateFile evaluates the condition tree against a single file, calling
+// emit with its result iff the file satisfies it.
+func (q *Query) evaluateFile(path string, info os.FileInfo, depth int64,
+	emit func(*result) error) error {
+	if f, ok := info.(*fileInfo); ok {
+		f.warn = q.unreadable
+	}
+	if ok, err := q.ConditionTree.evaluateTree(path, info, depth); os.IsPermission(err) {
+		return q.skip(err)
+	} else if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	values, err := q.applyModifiers(path, info, depth)
+	if os.IsPermission(err) {
+		return q.skip(err)
+	} else if err != nil {
+		return err
+	}
+
+	r := &result{path: path, info: info, depth: depth, values: values}
+	for _, key := range q.GroupBy {
+		if len(key.Modifiers) == 0 {
+			key.Modifiers = q.DefaultModifiers[key.Attribute]
+		}
+		value, err := key.value(path, info, depth, q.ScanBinary)
 		if err != nil {
 			return err
 		}
-		workFunc.(func(string, os.FileInfo, map[string]interface{}))(path, info, results)
-		return nil
+		r.group = append(r.group, value)
+	}
+
+	// Grouped results are ordered by their output values instead (see
+	// aggregator.results).
+	if !q.isGrouped() {
+		for _, key := range q.OrderBy {
+			value, err := key.value(path, info, depth, q.ScanBinary)
+			if err != nil {
+				return err
+			}
+			r.keys = append(r.keys, value)
+		}
+	}
+
+	return emit(r)
+}
+
+// skip records err as the reason a file was skipped. The walk continues, so
+// skip always returns nil.
+func (q *Query) skip(err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Skipped = append(q.Skipped, err)
+	return nil
+}
+
+// unreadable records err as the error of a directory whose entries couldn't
+// be read (see Unreadable).
+func (q *Query) unreadable(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Unreadable = append(q.Unreadable, err)
+}
+
+// isPruned returns true iff the name of the directory matches any of the
+// query's EXCLUDE patterns. Patterns have the same semantics as LIKE.
+func (q *Query) isPruned(info os.FileInfo) (bool, error) {
+	for _, pattern := range q.Exclude {
+		ok, err := evaluate.Evaluate(&evaluate.Opts{
+			File:      info,
+			Attribute: "name",
+			Operator:  tokenizer.Like,
+			Value:     pattern,
+		})
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// resolvePath returns the absolute path of src with any symbolic links
+// evaluated, falling back to the absolute (or original) path if src can't be
+// resolved.
+func resolvePath(src string) string {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return src
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// relativeDepth returns the number of path separators between src and path,
+// so a direct child of src has depth 1 and src itself has depth 0.
+func relativeDepth(src, path string) int64 {
+	rel, err := filepath.Rel(src, path)
+	if err != nil || rel == "." {
+		return 0
 	}
+	return int64(strings.Count(rel, string(filepath.Separator)) + 1)
 }
@@ -1 +1,122 @@
 package query
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestQuery_RelativeDepth(t *testing.T) {
+	type Case struct {
+		src      string
+		path     string
+		expected int64
+	}
+
+	cases := []Case{
+		{src: ".", path: ".", expected: 0},
+		{src: "./testdata", path: "./testdata", expected: 0},
+		{src: "./testdata", path: filepath.Join("testdata", "foo"), expected: 1},
+		{src: "testdata/", path: filepath.Join("testdata", "foo", "quuz"), expected: 2},
+		{src: ".", path: filepath.Join("foo", "quuz", "fred"), expected: 3},
+	}
+
+	for _, c := range cases {
+		actual := relativeDepth(c.src, c.path)
+		if actual != c.expected {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
+
+func TestQuery_ResolvePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(wd)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Case struct {
+		src      string
+		expected string
+	}
+
+	cases := []Case{
+		{src: ".", expected: resolved},
+		{src: "./", expected: resolved},
+		{src: filepath.Join("..", "query"), expected: resolved},
+		{src: "nonexistent", expected: filepath.Join(wd, "nonexistent")},
+	}
+
+	for _, c := range cases {
+		actual := resolvePath(c.src)
+		if actual != c.expected {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
+
+func TestQuery_WalkFuncSkip(t *testing.T) {
+	q := NewQuery()
+	emit := func(r *result) error { return nil }
+	excluder := &regexpExclude{}
+	walkFn := q.walkFunc("src", &visited{paths: make(map[string]bool)}, excluder, emit)
+
+	errDenied := &os.PathError{Op: "open", Path: "src/foo", Err: os.ErrPermission}
+	if err := walkFn("src/foo", nil, errDenied); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if !reflect.DeepEqual([]error{errDenied}, q.Skipped) {
+		t.Fatalf("\nExpected %v\n     Got %v", []error{errDenied}, q.Skipped)
+	}
+
+	// An unreadable source isn't skipped.
+	errSource := &os.PathError{Op: "lstat", Path: "src", Err: os.ErrNotExist}
+	if err := walkFn("src", nil, errSource); !reflect.DeepEqual(errSource, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", errSource, err)
+	}
+	if len(q.Skipped) != 1 {
+		t.Fatalf("\nExpected %v\n     Got %v", 1, len(q.Skipped))
+	}
+}
+
+func TestQuery_Unreadable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A directory which is removed once it's found can't be read, like one
+	// without permissions (which root can read anyway).
+	path := filepath.Join(dir, "removed")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	info := newFileInfo(path, mustStat(t, path), nil)
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	q := NewQuery()
+	q.Attributes = []string{"is_empty"}
+	var values map[string]interface{}
+	emit := func(r *result) error {
+		values = r.values
+		return nil
+	}
+	if err := q.evaluateFile(path, info, 1, emit); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if values["is_empty"] != false {
+		t.Fatalf("\nExpected false\n     Got %v", values["is_empty"])
+	}
+	if len(q.Unreadable) != 1 || len(q.Skipped) != 0 {
+		t.Fatalf("\nExpected 1 unreadable directory\n     Got %v, %v", q.Unreadable, q.Skipped)
+	}
+}
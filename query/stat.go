@@ -0,0 +1,70 @@
+package query
+
+import (
+	"os"
+	"sync"
+)
+
+// statFile and lstatFile stat the file at a path (i.e. os.Stat and os.Lstat),
+// which tests replace to count how often files are stat'd.
+var (
+	statFile  = os.Stat
+	lstatFile = os.Lstat
+)
+
+// fileInfo is the info of a file found by a query, which caches the info of
+// the file's target (if it's a symbolic link) and of the link itself. Each
+// attribute of the file is read from these (see transform), so the file is
+// stat'd at most once for each, however many of its attributes are used (e.g.
+// `hash`, `mime`, and `lines` of a link would otherwise each stat its target).
+type fileInfo struct {
+	os.FileInfo
+	path string
+
+	targetOnce sync.Once
+	target     os.FileInfo
+	targetErr  error
+
+	linkOnce sync.Once
+	link     os.FileInfo
+	linkErr  error
+
+	// warn, if set, records the errors which didn't prevent an attribute of
+	// the file from being read (see Query.Unreadable).
+	warn func(error)
+}
+
+// newFileInfo returns the cached info of the file at path with info. link is
+// the info of the link itself (i.e. of os.Lstat), or nil if it isn't known
+// yet.
+func newFileInfo(path string, info, link os.FileInfo) *fileInfo {
+	return &fileInfo{FileInfo: info, path: path, link: link}
+}
+
+// Target returns the info of the file, following symbolic links.
+func (f *fileInfo) Target() (os.FileInfo, error) {
+	if f.Mode()&os.ModeSymlink == 0 {
+		return f.FileInfo, nil
+	}
+	f.targetOnce.Do(func() {
+		f.target, f.targetErr = statFile(f.path)
+	})
+	return f.target, f.targetErr
+}
+
+// Link returns the info of the file, without following symbolic links.
+func (f *fileInfo) Link() (os.FileInfo, error) {
+	f.linkOnce.Do(func() {
+		if f.link == nil {
+			f.link, f.linkErr = lstatFile(f.path)
+		}
+	})
+	return f.link, f.linkErr
+}
+
+// Warn records err for the file (see warn).
+func (f *fileInfo) Warn(err error) {
+	if f.warn != nil {
+		f.warn(err)
+	}
+}
@@ -0,0 +1,176 @@
+package query
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// linkedFile returns a temporary directory with a file `target` (with a few
+// lines) and a symbolic link `link` to it, which must be removed by the caller.
+func linkedFile(tb testing.TB) string {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		tb.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	target := filepath.Join(dir, "target")
+	if err := ioutil.WriteFile(target, []byte("foo\nbar\n"), 0644); err != nil {
+		tb.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if err := os.Symlink(target, filepath.Join(dir, "link")); err != nil {
+		tb.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	return dir
+}
+
+func TestStat_FileInfo(t *testing.T) {
+	dir := linkedFile(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "link")
+	link, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	info := newFileInfo(path, link, link)
+
+	target, err := info.Target()
+	if err != nil || target.Size() != 8 {
+		t.Fatalf("\nExpected the info of the target\n     Got %v, %v", target, err)
+	}
+
+	// The target isn't stat'd again, so it's still known once removed.
+	if err := os.Remove(filepath.Join(dir, "target")); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if cached, err := info.Target(); err != nil || cached != target {
+		t.Fatalf("\nExpected %v\n     Got %v, %v", target, cached, err)
+	}
+	if actual, err := info.Link(); err != nil || actual != link {
+		t.Fatalf("\nExpected %v\n     Got %v, %v", link, actual, err)
+	}
+	if empty, err := formatValue("is_empty", nil, path, info, 1, false); err != nil || empty != false {
+		t.Fatalf("\nExpected false\n     Got %v, %v", empty, err)
+	}
+
+	// The info of a file which isn't a link is its own target, and its link is
+	// stat'd once it's needed.
+	info = newFileInfo(dir, mustStat(t, dir), nil)
+	if target, err := info.Target(); err != nil || target != info.FileInfo {
+		t.Fatalf("\nExpected %v\n     Got %v, %v", info.FileInfo, target, err)
+	}
+	if actual, err := info.Link(); err != nil || !actual.IsDir() {
+		t.Fatalf("\nExpected the info of %s\n     Got %v, %v", dir, actual, err)
+	}
+}
+
+// uncachedInfo is the info of a file which stats it each time its target or
+// link is needed, like the attributes of a file without a fileInfo.
+type uncachedInfo struct {
+	os.FileInfo
+	path string
+}
+
+func (u *uncachedInfo) Target() (os.FileInfo, error) { return statFile(u.path) }
+
+func (u *uncachedInfo) Link() (os.FileInfo, error) { return lstatFile(u.path) }
+
+// countStats replaces statFile and lstatFile with functions which count
+// their calls in the returned counter, until the returned function is called.
+func countStats() (*int, func()) {
+	count := 0
+	stat, lstat := statFile, lstatFile
+	statFile = func(path string) (os.FileInfo, error) {
+		count++
+		return stat(path)
+	}
+	lstatFile = func(path string) (os.FileInfo, error) {
+		count++
+		return lstat(path)
+	}
+	return &count, func() { statFile, lstatFile = stat, lstat }
+}
+
+// statAttributes are attributes which each stat the target of a link.
+var statAttributes = []string{"is_empty", "mime", "lines", "words", "hash"}
+
+func TestStat_Count(t *testing.T) {
+	dir := linkedFile(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "link")
+	link, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Case struct {
+		info     os.FileInfo
+		expected int
+	}
+
+	cases := []Case{
+		{info: &uncachedInfo{link, path}, expected: len(statAttributes)},
+		{info: newFileInfo(path, link, link), expected: 1},
+	}
+
+	for _, c := range cases {
+		count, restore := countStats()
+		for _, attribute := range statAttributes {
+			if _, err := formatValue(attribute, nil, path, c.info, 1, false); err != nil {
+				restore()
+				t.Fatalf("%s\nExpected no error\n     Got %v", attribute, err)
+			}
+		}
+		restore()
+		if *count != c.expected {
+			t.Fatalf("%T\nExpected %d stat(s)\n     Got %d", c.info, c.expected, *count)
+		}
+	}
+}
+
+func mustStat(tb testing.TB, path string) os.FileInfo {
+	info, err := os.Stat(path)
+	if err != nil {
+		tb.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	return info
+}
+
+// BenchmarkStat_FileInfo compares the attributes of a symbolic link which
+// each stat its target to those which share the cached info of its target,
+// and reports the number of stats of each.
+func BenchmarkStat_FileInfo(b *testing.B) {
+	dir := linkedFile(b)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "link")
+	link, err := os.Lstat(path)
+	if err != nil {
+		b.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	benchmarks := []struct {
+		name string
+		info func() os.FileInfo
+	}{
+		{"uncached", func() os.FileInfo { return &uncachedInfo{link, path} }},
+		{"cached", func() os.FileInfo { return newFileInfo(path, link, link) }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			count, restore := countStats()
+			defer restore()
+			for i := 0; i < b.N; i++ {
+				info := bm.info()
+				for _, attribute := range statAttributes {
+					if _, err := formatValue(attribute, nil, path, info, 1, false); err != nil {
+						b.Fatalf("%s\nExpected no error\n     Got %v", attribute, err)
+					}
+				}
+			}
+			b.ReportMetric(float64(*count)/float64(b.N), "stats/op")
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package query
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// walkTree walks the file tree rooted at root, calling walkFn for each file or
+// directory (including root). With a single job, files are visited in lexical
+// order, like filepath.Walk (which is used if symbolic links aren't followed).
+// Otherwise, directories are read and visited concurrently by up to jobs
+// goroutines, so walkFn must be safe for concurrent use and files aren't
+// visited in any particular order.
+//
+// If follow is true, symbolic links are followed: walkFn is called with the
+// info of the link's target, and linked directories are descended into. Each
+// directory is only walked once, so links to a directory which has already
+// been walked (e.g. a link to a parent directory) aren't descended into.
+//
+// As with filepath.Walk, walkFn is called on a directory once it's been read,
+// with the error (if any) of reading it. Returning filepath.SkipDir from walkFn
+// skips the directory (or the remaining files of the directory, if walkFn was
+// called on a file). Walking stops as soon as walkFn returns any other error,
+// which is returned by walkTree.
+func walkTree(root string, jobs int, follow bool, walkFn filepath.WalkFunc) error {
+	if jobs < 2 && !follow {
+		return filepath.Walk(root, walkFn)
+	}
+
+	w := &walker{walkFn: walkFn, follow: follow}
+	w.cond = sync.NewCond(&w.mu)
+	if follow {
+		w.dirs = &visited{paths: make(map[string]bool)}
+	}
+
+	info, err := w.stat(root)
+	if err != nil {
+		err = walkFn(root, info, err)
+	} else if !info.IsDir() {
+		err = walkFn(root, info, nil)
+	} else if jobs < 2 {
+		err = w.walk(root, info)
+	} else {
+		w.queue = []dirEntry{{root, info}}
+
+		var wg sync.WaitGroup
+		for i := 0; i < jobs; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.work()
+			}()
+		}
+		wg.Wait()
+		err = w.err
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// dirEntry is a directory which is yet to be read.
+type dirEntry struct {
+	path string
+	info os.FileInfo
+}
+
+// walker holds the state of a single walkTree call, which is shared by each
+// of its goroutines.
+type walker struct {
+	walkFn filepath.WalkFunc
+
+	// follow is true iff symbolic links are followed, in which case dirs holds
+	// the resolved path of each directory which has been walked.
+	follow bool
+	dirs   *visited
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []dirEntry
+	active int
+	err    error
+}
+
+// stat returns the info of the file at path, following symbolic links if
+// w.follow is true. The info of a broken link is that of the link itself. The
+// info of a followed link keeps that of the link (see fileInfo).
+func (w *walker) stat(path string) (os.FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err != nil || !w.follow || info.Mode()&os.ModeSymlink == 0 {
+		return info, err
+	}
+	if target, err := os.Stat(path); err == nil {
+		return newFileInfo(path, target, info), nil
+	}
+	return info, nil
+}
+
+// enter returns true iff the directory at path hasn't been walked yet (i.e.
+// it's not part of a cycle of links). Always true if links aren't followed.
+func (w *walker) enter(path string) bool {
+	if !w.follow {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return true
+	}
+	return w.dirs.visit(resolved)
+}
+
+// walk recursively walks the directory at path, calling walkFn on it and each
+// of its files in lexical order.
+func (w *walker) walk(path string, info os.FileInfo) error {
+	if !info.IsDir() {
+		return w.walkFn(path, info, nil)
+	}
+	if !w.enter(path) {
+		return w.walkLink(path)
+	}
+
+	names, readErr := readDirNames(path)
+	if err := w.walkFn(path, info, readErr); err != nil || readErr != nil {
+		return err
+	}
+
+	for _, name := range names {
+		filename := filepath.Join(path, name)
+		fileInfo, err := w.stat(filename)
+		if err != nil {
+			if err = w.walkFn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err = w.walk(filename, fileInfo); err != nil {
+			if !fileInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkLink calls walkFn on the link at path (instead of the directory it
+// links to), since the directory has already been walked.
+func (w *walker) walkLink(path string) error {
+	info, err := os.Lstat(path)
+	if err = w.walkFn(path, info, err); err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// work reads queued directories until every directory has been read or
+// walking has stopped.
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.active > 0 && w.err == nil {
+			w.cond.Wait()
+		}
+		if w.err != nil || len(w.queue) == 0 {
+			w.mu.Unlock()
+			w.cond.Broadcast()
+			return
+		}
+		dir := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.active++
+		w.mu.Unlock()
+
+		err := w.readDir(dir)
+
+		w.mu.Lock()
+		w.active--
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.mu.Unlock()
+		w.cond.Broadcast()
+	}
+}
+
+// readDir reads dir and calls walkFn on it and each of its files, queueing
+// its subdirectories.
+func (w *walker) readDir(dir dirEntry) error {
+	if !w.enter(dir.path) {
+		return w.walkLink(dir.path)
+	}
+
+	names, readErr := readDirNames(dir.path)
+	if err := w.walkFn(dir.path, dir.info, readErr); err != nil || readErr != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	for _, name := range names {
+		if w.stopped() {
+			return nil
+		}
+
+		path := filepath.Join(dir.path, name)
+		info, err := w.stat(path)
+		if err == nil && info.IsDir() {
+			w.mu.Lock()
+			w.queue = append(w.queue, dirEntry{path, info})
+			w.mu.Unlock()
+			w.cond.Signal()
+			continue
+		}
+
+		if err = w.walkFn(path, info, err); err == filepath.SkipDir {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopped returns true iff walking has stopped due to an error.
+func (w *walker) stopped() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err != nil
+}
+
+// readDirNames returns the sorted names of the entries of the directory dir.
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,181 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestWalk_WalkTree(t *testing.T) {
+	type Case struct {
+		root     string
+		skip     string
+		expected []string
+	}
+
+	cases := []Case{
+		{
+			root: filepath.Join("..", "testdata", "foo"),
+			expected: []string{
+				"foo", "foo/quux", "foo/quuz", "foo/quuz/fred", "foo/quuz/fred/.gitkeep",
+				"foo/quuz/waldo", "foo/qux",
+			},
+		},
+		{
+			root:     filepath.Join("..", "testdata", "foo"),
+			skip:     "quuz",
+			expected: []string{"foo", "foo/quux", "foo/quuz", "foo/qux"},
+		},
+		{
+			root:     filepath.Join("..", "testdata", "baz"),
+			expected: []string{"baz"},
+		},
+		{
+			root:     filepath.Join("..", "testdata", "foo"),
+			skip:     "foo",
+			expected: []string{"foo"},
+		},
+	}
+
+	for _, c := range cases {
+		for _, jobs := range []int{1, 4} {
+			var mu sync.Mutex
+			actual := make([]string, 0)
+
+			err := walkTree(c.root, jobs, false, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(filepath.Dir(c.root), path)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				actual = append(actual, filepath.ToSlash(rel))
+				mu.Unlock()
+				if info.Name() == c.skip {
+					return filepath.SkipDir
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+
+			sort.Strings(actual)
+			if !reflect.DeepEqual(c.expected, actual) {
+				t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+			}
+		}
+	}
+}
+
+func TestWalk_WalkTreeError(t *testing.T) {
+	expected := errors.New("stop")
+
+	var mu sync.Mutex
+	calls := 0
+	err := walkTree(filepath.Join("..", "testdata"), 4, false,
+		func(path string, info os.FileInfo, err error) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if calls++; calls == 3 {
+				return expected
+			}
+			return nil
+		})
+	if !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+
+	_, err = os.Lstat("nonexistent")
+	actual := walkTree("nonexistent", 4, false, func(path string, info os.FileInfo, err error) error {
+		return err
+	})
+	if !reflect.DeepEqual(err, actual) {
+		t.Fatalf("\nExpected %v\n     Got %v", err, actual)
+	}
+}
+
+func TestWalk_WalkTreeFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// dir
+	// ├── a -> c
+	// ├── b -> nonexistent
+	// └── c
+	//     ├── d
+	//     ├── e -> d
+	//     └── f
+	//         └── g -> ..
+	for _, path := range []string{"c", filepath.Join("c", "f")} {
+		if err := os.Mkdir(filepath.Join(dir, path), 0755); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "c", "d"), []byte("foo"), 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	links := map[string]string{
+		"a":                          "c",
+		"b":                          "nonexistent",
+		filepath.Join("c", "e"):      "d",
+		filepath.Join("c", "f", "g"): "..",
+	}
+	for path, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, path)); err != nil {
+			t.Skipf("symbolic links aren't supported: %v", err)
+		}
+	}
+
+	type Case struct {
+		follow   bool
+		expected []string
+	}
+
+	cases := []Case{
+		{
+			follow: false,
+			expected: []string{
+				". d", "a L", "b L", "c d", "c/d -", "c/e L", "c/f d", "c/f/g L",
+			},
+		},
+		{
+			follow: true,
+			expected: []string{
+				". d", "a d", "a/d -", "a/e -", "a/f d", "a/f/g L", "b L", "c d",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual := make([]string, 0)
+		err := walkTree(dir, 1, c.follow, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			actual = append(actual, fmt.Sprintf("%s %c", filepath.ToSlash(rel), info.Mode().String()[0]))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("\nExpected %v\n     Got %v", c.expected, actual)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/kshvmdn/fsql"
+	"github.com/kshvmdn/fsql/parser"
 	"github.com/kshvmdn/fsql/terminal/pager"
 
 	"golang.org/x/crypto/ssh/terminal"
@@ -18,7 +19,9 @@ var fd = int(os.Stdin.Fd())
 var query bytes.Buffer
 
 // Start listens for queries via stdin and invokes fsql.Run whenever a
-// semicolon is read.
+// semicolon is read. Lines starting with a period outside of a query are
+// commands (see command), e.g. `.from ~/src`. Previous lines are recalled with
+// the up and down arrow keys.
 func Start() error {
 	if !terminal.IsTerminal(fd) {
 		return errors.New("not a terminal")
@@ -51,6 +54,15 @@ func Start() error {
 			break
 		}
 
+		if query.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ".") {
+			out, err := command(strings.TrimSpace(line))
+			if err != nil {
+				out = err.Error() + "\a"
+			}
+			term.Write([]byte(out + "\n"))
+			continue
+		}
+
 		// TODO: If the previous character was a paren., bracket, or quote, we
 		// don't want to add a space here (although not necessary, since the
 		// tokenizer handles excess whitespace).
@@ -100,6 +112,42 @@ func Start() error {
 	return nil
 }
 
+// command runs the command line (e.g. `.from ~/src`) and returns its output:
+//
+//	.from [dir]  changes the default source (i.e. the working directory, which
+//	             relative sources are found in) to dir, or shows it if dir is
+//	             omitted. dir is the rest of the line, so it may contain
+//	             spaces, and a leading tilde and environment variables are
+//	             expanded like those of FROM (see parser.ExpandSource).
+func command(line string) (string, error) {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i:])
+	}
+	switch name {
+	case ".from":
+		var out strings.Builder
+		if arg != "" {
+			dir, warnings, err := parser.ExpandSource(arg)
+			if err != nil {
+				return "", err
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(&out, "warning: %s\n", warning)
+			}
+			if err := os.Chdir(dir); err != nil {
+				return "", err
+			}
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		return out.String() + wd, nil
+	}
+	return "", fmt.Errorf("unknown command %s", name)
+}
+
 // run invokes fsql.Run with the provided query string.
 func run(query string) (out string, err error) {
 	stdout := os.Stdout
@@ -2,7 +2,11 @@ package terminal
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -50,3 +54,88 @@ func TestRun(t *testing.T) {
 		}
 	}
 }
+
+func TestCommand(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.Chdir(wd)
+
+	type Expected struct {
+		out string
+		err error
+	}
+
+	type Case struct {
+		line     string
+		expected Expected
+	}
+
+	// The working directory may be reached through a symbolic link.
+	root, err := filepath.EvalSymlinks(filepath.Dir(wd))
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	testdata := filepath.Join(root, "testdata")
+
+	// Directories may contain spaces and environment variables.
+	tmp, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	documents := filepath.Join(tmp, "My Documents")
+	if err := os.Mkdir(documents, 0755); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	os.Setenv("FSQL_TEST_DIR", tmp)
+	defer os.Unsetenv("FSQL_TEST_DIR")
+	os.Unsetenv("FSQL_TEST_UNSET")
+
+	cases := []Case{
+		{line: ".from", expected: Expected{out: filepath.Join(root, "terminal")}},
+		{line: ".from ../testdata", expected: Expected{out: testdata}},
+		{line: ".from  foo", expected: Expected{out: filepath.Join(testdata, "foo")}},
+		{line: ".from ..", expected: Expected{out: testdata}},
+		{line: ".from $FSQL_TEST_DIR/My Documents ", expected: Expected{out: documents}},
+		{line: ".from\t..", expected: Expected{out: tmp}},
+		{line: ".from ${FSQL_TEST_DIR}", expected: Expected{out: tmp}},
+		{line: ".help", expected: Expected{err: errors.New("unknown command .help")}},
+		{line: ".help me", expected: Expected{err: errors.New("unknown command .help")}},
+	}
+
+	for _, c := range cases {
+		actual, err := command(c.line)
+		if c.expected.err == nil {
+			if err == nil {
+				actual, err = filepath.EvalSymlinks(actual)
+			}
+			if err != nil {
+				t.Fatalf("%s\nExpected no error\n     Got %v", c.line, err)
+			}
+			if !reflect.DeepEqual(c.expected.out, actual) {
+				t.Fatalf("%s\nExpected %v\n     Got %v", c.line, c.expected.out, actual)
+			}
+		} else if !reflect.DeepEqual(c.expected.err, err) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.line, c.expected.err, err)
+		}
+	}
+
+	// Variables which aren't set are empty, with a warning.
+	expected := "warning: environment variable FSQL_TEST_UNSET in FROM isn't set, so it's empty\n"
+	if out, err := command(".from ./$FSQL_TEST_UNSET"); err != nil || !strings.HasPrefix(out, expected) {
+		t.Fatalf("\nExpected %q\n     Got %q, %v", expected, out, err)
+	}
+
+	// Queries without a FROM clause find the files of the new directory.
+	if _, err := command(".from " + filepath.Join(testdata, "foo")); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if out, err := run("SELECT name WHERE name = quuz"); err != nil || out != "quuz\n" {
+		t.Fatalf("\nExpected quuz\n     Got %q, %v", out, err)
+	}
+}
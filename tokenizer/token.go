@@ -11,10 +11,19 @@ const (
 
 	Identifier
 	Subquery
+	Command
 
 	Select
+	Distinct
 	From
+	Exclude
 	Where
+	GroupBy
+	Having
+	OrderBy
+	Limit
+	Offset
+	Exec
 
 	As
 	Or
@@ -23,10 +32,14 @@ const (
 
 	In
 	Is
+	Between
 	Like
+	ILike
 	RLike
+	Glob
 
 	Equals
+	IEquals
 	NotEquals
 	GreaterThanEquals
 	GreaterThan
@@ -48,14 +61,32 @@ func (t TokenType) String() string {
 		return "identifier"
 	case Subquery:
 		return "subquery"
+	case Command:
+		return "command"
 	case Select:
 		return "select"
+	case Distinct:
+		return "distinct"
 	case From:
 		return "from"
+	case Exclude:
+		return "exclude"
 	case As:
 		return "as"
 	case Where:
 		return "where"
+	case GroupBy:
+		return "group-by"
+	case Having:
+		return "having"
+	case OrderBy:
+		return "order-by"
+	case Limit:
+		return "limit"
+	case Offset:
+		return "offset"
+	case Exec:
+		return "exec"
 	case Or:
 		return "or"
 	case And:
@@ -66,12 +97,20 @@ func (t TokenType) String() string {
 		return "in"
 	case Is:
 		return "is"
+	case Between:
+		return "between"
 	case Like:
 		return "like"
+	case ILike:
+		return "ilike"
 	case RLike:
 		return "RLike"
+	case Glob:
+		return "glob"
 	case Equals:
 		return "equal"
+	case IEquals:
+		return "iequal"
 	case NotEquals:
 		return "not-equal"
 	case GreaterThanEquals:
@@ -116,6 +155,9 @@ func (t *Token) String() string {
 type Tokenizer struct {
 	input  []rune
 	tokens []*Token
+	// list is true while reading a list of values (e.g. `IN (foo, bar)`), whose
+	// words are never keywords.
+	list bool
 }
 
 // NewTokenizer initializes a new Tokenizer.
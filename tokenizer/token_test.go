@@ -11,18 +11,25 @@ func TestToken_TokenTypeString(t *testing.T) {
 	cases := []Case{
 		{tt: Identifier, expected: "identifier"},
 		{tt: Subquery, expected: "subquery"},
+		{tt: Command, expected: "command"},
 		{tt: Select, expected: "select"},
 		{tt: From, expected: "from"},
+		{tt: Exclude, expected: "exclude"},
 		{tt: As, expected: "as"},
 		{tt: Where, expected: "where"},
+		{tt: Exec, expected: "exec"},
 		{tt: Or, expected: "or"},
 		{tt: And, expected: "and"},
 		{tt: Not, expected: "not"},
 		{tt: In, expected: "in"},
 		{tt: Is, expected: "is"},
+		{tt: Between, expected: "between"},
 		{tt: Like, expected: "like"},
+		{tt: ILike, expected: "ilike"},
 		{tt: RLike, expected: "RLike"},
+		{tt: Glob, expected: "glob"},
 		{tt: Equals, expected: "equal"},
+		{tt: IEquals, expected: "iequal"},
 		{tt: NotEquals, expected: "not-equal"},
 		{tt: GreaterThanEquals, expected: "greater-than-or-equal"},
 		{tt: GreaterThan, expected: "greater-than"},
@@ -15,10 +15,16 @@ func (t *Tokenizer) All() []Token {
 	return tokens
 }
 
-// Next finds and returns the next Token in the input string.
+// Next finds and returns the next Token in the input string. Comments are
+// skipped (see skipComment).
 func (t *Tokenizer) Next() *Token {
-	for unicode.IsSpace(t.current()) {
-		t.input = t.input[1:]
+	for {
+		for unicode.IsSpace(t.current()) {
+			t.input = t.input[1:]
+		}
+		if !t.skipComment() {
+			break
+		}
 	}
 
 	current := t.current()
@@ -26,12 +32,25 @@ func (t *Tokenizer) Next() *Token {
 		return nil
 	}
 
+	// The rest of the input following EXEC is the command, which is read
+	// verbatim, except for a trailing `;` which terminates the query (unless
+	// it's escaped, e.g. `EXEC echo {} \;`).
+	if prev := t.getPreviousToken(); prev != nil && prev.Type == Exec {
+		raw := strings.TrimRightFunc(string(t.input), unicode.IsSpace)
+		if strings.HasSuffix(raw, ";") && !strings.HasSuffix(raw, `\;`) {
+			raw = strings.TrimRightFunc(strings.TrimSuffix(raw, ";"), unicode.IsSpace)
+		}
+		t.input = t.input[:0]
+		return t.setToken(&Token{Type: Command, Raw: raw})
+	}
+
 	switch current {
 	case '(':
 		t.input = t.input[1:]
 		return t.setToken(&Token{Type: OpenParen, Raw: "("})
 	case ')':
 		t.input = t.input[1:]
+		t.list = false
 		return t.setToken(&Token{Type: CloseParen, Raw: ")"})
 	case '[':
 		t.input = t.input[1:]
@@ -52,6 +71,17 @@ func (t *Tokenizer) Next() *Token {
 		}
 		return t.setToken(&Token{Type: ExclamationMark, Raw: "!"})
 	case '=':
+		if t.getRuneAt(1) == '~' {
+			t.input = t.input[2:]
+			return t.setToken(&Token{Type: RLike, Raw: "=~"})
+		}
+		// `=i` (followed by the end of the word) is case-insensitive equality.
+		if unicode.ToLower(t.getRuneAt(1)) == 'i' && (unicode.IsSpace(t.getRuneAt(2)) ||
+			t.getRuneAt(2) == -1 || strings.ContainsRune("'\"`(", t.getRuneAt(2))) {
+			raw := string(t.input[:2])
+			t.input = t.input[2:]
+			return t.setToken(&Token{Type: IEquals, Raw: raw})
+		}
 		t.input = t.input[1:]
 		return t.setToken(&Token{Type: Equals, Raw: "="})
 	case '>':
@@ -81,10 +111,36 @@ func (t *Tokenizer) Next() *Token {
 		switch strings.ToUpper(word) {
 		case "SELECT":
 			tok.Type = Select
+		case "DISTINCT":
+			tok.Type = Distinct
 		case "FROM":
 			tok.Type = From
+		case "EXCLUDE":
+			tok.Type = Exclude
 		case "WHERE":
 			tok.Type = Where
+		case "GROUP", "ORDER":
+			// GROUP / ORDER are only keywords when followed by BY, so they're still
+			// usable as plain identifiers (e.g. the `group` attribute).
+			tok.Type = Identifier
+			if strings.ToUpper(t.peekWord()) == "BY" {
+				for unicode.IsSpace(t.current()) {
+					t.input = t.input[1:]
+				}
+				tok.Type = OrderBy
+				if strings.ToUpper(word) == "GROUP" {
+					tok.Type = GroupBy
+				}
+				tok.Raw = fmt.Sprintf("%s %s", word, t.readWord())
+			}
+		case "HAVING":
+			tok.Type = Having
+		case "LIMIT":
+			tok.Type = Limit
+		case "OFFSET":
+			tok.Type = Offset
+		case "EXEC":
+			tok.Type = Exec
 		case "AS":
 			tok.Type = As
 		case "OR":
@@ -97,19 +153,40 @@ func (t *Tokenizer) Next() *Token {
 			tok.Type = In
 		case "IS":
 			tok.Type = Is
+		case "BETWEEN":
+			tok.Type = Between
 		case "LIKE":
 			tok.Type = Like
+		case "ILIKE":
+			tok.Type = ILike
 		case "REGEXP", "RLIKE":
 			tok.Type = RLike
+		case "GLOB", "~":
+			tok.Type = Glob
 		default:
 			tok.Type = Identifier
 		}
+		if t.list || contextualKeywords[tok.Type] && t.isValuePosition() {
+			tok.Type = Identifier
+		}
 
 		if t.getPreviousToken() != nil && t.getPreviousToken().Type == OpenParen &&
-			t.getTokenAt(1) != nil && t.getTokenAt(1).Type == In {
-			// The two previous tokens were: `IN` and `(`, so we're at a subquery.
-			tok.Type = Subquery
-			tok.Raw = fmt.Sprintf("%s %s", word, t.readQuery())
+			t.getTokenAt(1) != nil && (t.getTokenAt(1).Type == In || t.getTokenAt(1).Type == From) {
+			// The two previous tokens were: `IN` (or `FROM`) and `(`, so we're
+			// either at a subquery or a list of values (e.g. `IN (foo, bar)`). Only
+			// the former starts with SELECT or FROM, every word of the latter is a
+			// value (e.g. `IN (from, where)`).
+			input := t.input
+			if raw := fmt.Sprintf("%s %s", word, t.readQuery()); isQuery(raw) {
+				tok.Type = Subquery
+				tok.Raw = raw
+			} else {
+				t.input = input
+				if t.getTokenAt(1).Type == In {
+					t.list = true
+					tok.Type = Identifier
+				}
+			}
 		}
 
 		return t.setToken(tok)
@@ -121,14 +198,45 @@ func (t *Tokenizer) Next() *Token {
 	// reading until we reach the matching closing symbol.
 	if t.currentIs('\'', '"', '`') {
 		t.input = t.input[1:]
-		tok.Raw = t.readWord() + t.readUntil(current)
+		tok.Raw = t.readQuoted(current)
 		tok.Type = Identifier
 	}
 
-	t.input = t.input[1:]
+	if t.current() != -1 {
+		t.input = t.input[1:]
+	}
 	return t.setToken(tok)
 }
 
+// contextualKeywords holds the keywords which are plain identifiers where a
+// value is expected (see isValuePosition), so they're still usable as values
+// without quoting them, e.g. `WHERE name = limit` or `SELECT size AS offset`.
+var contextualKeywords = map[TokenType]bool{
+	Distinct: true, Exclude: true, Having: true, Limit: true, Offset: true,
+	Exec: true, Between: true, ILike: true, RLike: true, Glob: true,
+}
+
+// isValuePosition returns true iff the next word is a value rather than a
+// keyword, judging by the previous token: the operand of a comparison (or
+// either bound of BETWEEN), an element of a list, an alias, a source, or an
+// EXCLUDE pattern. No clause or operator can follow any of these.
+func (t *Tokenizer) isValuePosition() bool {
+	prev := t.getPreviousToken()
+	if prev == nil {
+		return false
+	}
+	switch prev.Type {
+	case Equals, IEquals, NotEquals, GreaterThanEquals, GreaterThan, LessThanEquals,
+		LessThan, Like, ILike, RLike, Glob, Is, Between, Comma, OpenParen, Hyphen,
+		As, From, Exclude:
+		return true
+	case And:
+		// E.g. `size BETWEEN 1 AND limit`.
+		return t.getTokenAt(2) != nil && t.getTokenAt(2).Type == Between
+	}
+	return false
+}
+
 // setToken adds token to the list of this Tokenizer's tokens.
 func (t *Tokenizer) setToken(token *Token) *Token {
 	t.tokens = append(t.tokens, token)
@@ -191,44 +299,124 @@ func (t *Tokenizer) readWord() string {
 	}
 }
 
+// peekWord returns the next word of the input (see readWord) without
+// consuming it.
+func (t *Tokenizer) peekWord() string {
+	input := t.input
+	defer func() { t.input = input }()
+
+	for unicode.IsSpace(t.current()) {
+		t.input = t.input[1:]
+	}
+	return t.readWord()
+}
+
+// isQuery returns true iff raw starts with a SELECT or FROM keyword which
+// is followed by more of the query, rather than by the next value of a list
+// (e.g. `from, bar`) or by its end.
+func isQuery(raw string) bool {
+	fields := strings.Fields(raw)
+	if len(fields) < 2 || strings.HasPrefix(fields[1], ",") {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "FROM":
+		return true
+	}
+	return false
+}
+
 // readQuery reads a full string until reaching a closing parentheses. Counts
-// opening parens to ensure that balance is maintained.
+// opening parens to ensure that balance is maintained. Quoted strings are read
+// verbatim, and any other run of whitespace (or comment) is read as a single
+// space.
 func (t *Tokenizer) readQuery() string {
-	var query string
+	query := []rune{}
 
 	var count = 1
-	for count > 0 {
-		for unicode.IsSpace(t.current()) {
+	var quote rune
+	for t.current() != -1 {
+		current := t.current()
+		if quote == 0 && (len(query) == 0 || strings.ContainsRune(" (),[]=<>!", query[len(query)-1])) &&
+			t.skipComment() {
+			if len(query) > 0 && query[len(query)-1] != ' ' {
+				query = append(query, ' ')
+			}
+			continue
+		}
+		if quote != 0 {
+			if current == '\\' && t.getRuneAt(1) != -1 {
+				// The escaped rune is kept along with the backslash, so it's
+				// unescaped when the subquery is tokenized.
+				query = append(query, current, t.getRuneAt(1))
+				t.input = t.input[2:]
+				continue
+			}
+			if current == quote {
+				quote = 0
+			}
+		} else if current == '\'' || current == '"' || current == '`' {
+			quote = current
+		} else if current == '(' {
+			count++
+		} else if current == ')' {
+			if count--; count == 0 {
+				break
+			}
+		} else if unicode.IsSpace(current) {
 			t.input = t.input[1:]
+			if len(query) > 0 && query[len(query)-1] != ' ' {
+				query = append(query, ' ')
+			}
+			continue
 		}
+		query = append(query, current)
+		t.input = t.input[1:]
+	}
 
-		word := fmt.Sprintf("%s", t.readWord())
+	return strings.TrimSpace(string(query))
+}
 
-		if t.current() == -1 {
-			break
+// skipComment skips the comment at the start of the input, if any, and
+// returns true iff there was one. A comment is either a line comment, from
+// `--` up to the end of the line, or a block comment, from `/*` up to and
+// including the following `*/` (or the end of the input). Comments are only
+// recognized at the start of a word, so e.g. `foo--bar` is a single word.
+func (t *Tokenizer) skipComment() bool {
+	switch {
+	case t.current() == '-' && t.getRuneAt(1) == '-':
+		for !t.currentIs(-1, '\n') {
+			t.input = t.input[1:]
 		}
-
-		if t.current() == '(' {
-			count++
-			word = "("
-		} else if t.current() == ')' {
-			count--
-			if count <= 0 {
-				query += word
-				break
-			}
-			word = ")"
-		} else if t.currentIs('\'', '`') {
-			word += string(t.current())
-		} else {
-			word += " "
+	case t.current() == '/' && t.getRuneAt(1) == '*':
+		t.input = t.input[2:]
+		for t.current() != -1 && !(t.current() == '*' && t.getRuneAt(1) == '/') {
+			t.input = t.input[1:]
 		}
+		if t.current() != -1 {
+			t.input = t.input[2:]
+		}
+	default:
+		return false
+	}
+	return true
+}
 
-		query += word
+// readQuoted reads the input verbatim until reaching the closing quote (or
+// the end of the input), so quoted words may contain any character (e.g.
+// `'./[a-z]*'` or `'My Documents'`). A backslash escapes the quote or another
+// backslash (e.g. `'it\'s'`), any other backslash is kept as is (e.g. in
+// `'\.go$'`).
+func (t *Tokenizer) readQuoted(quote rune) string {
+	word := []rune{}
+	for !t.currentIs(-1, quote) {
+		if t.current() == '\\' && (t.getRuneAt(1) == quote || t.getRuneAt(1) == '\\') {
+			t.input = t.input[1:]
+		}
+		word = append(word, t.current())
 		t.input = t.input[1:]
 	}
-
-	return query
+	return string(word)
 }
 
 // readUnitl reads the input starting at start, until reaching a rune in runes.
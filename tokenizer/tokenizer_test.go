@@ -13,22 +13,42 @@ func TestTokenizer_NextTokenType(t *testing.T) {
 
 	cases := []Case{
 		{input: "SELECT", expected: Select},
+		{input: "DISTINCT", expected: Distinct},
 		{input: "FROM", expected: From},
+		{input: "EXCLUDE", expected: Exclude},
 		{input: "WHERE", expected: Where},
+		{input: "ORDER BY", expected: OrderBy},
+		{input: "order by", expected: OrderBy},
+		{input: "ORDER", expected: Identifier},
+		{input: "GROUP BY", expected: GroupBy},
+		{input: "group", expected: Identifier},
+		{input: "HAVING", expected: Having},
+		{input: "having", expected: Having},
+		{input: "LIMIT", expected: Limit},
+		{input: "OFFSET", expected: Offset},
+		{input: "EXEC", expected: Exec},
 		{input: "AS", expected: As},
 		{input: "OR", expected: Or},
 		{input: "AND", expected: And},
 		{input: "NOT", expected: Not},
 		{input: "IN", expected: In},
 		{input: "IS", expected: Is},
+		{input: "BETWEEN", expected: Between},
 		{input: "LIKE", expected: Like},
+		{input: "ILIKE", expected: ILike},
 		{input: "RLIKE", expected: RLike},
+		{input: "=~", expected: RLike},
+		{input: "GLOB", expected: Glob},
+		{input: "~", expected: Glob},
+		{input: "~/foo", expected: Identifier},
 		{input: "foo", expected: Identifier},
 		{input: "(", expected: OpenParen},
 		{input: ")", expected: CloseParen},
 		{input: ",", expected: Comma},
 		{input: "-", expected: Hyphen},
 		{input: "=", expected: Equals},
+		{input: "=i", expected: IEquals},
+		{input: "=I", expected: IEquals},
 		{input: "<>", expected: NotEquals},
 		{input: "<", expected: LessThan},
 		{input: "<=", expected: LessThanEquals},
@@ -51,15 +71,22 @@ func TestTokenizer_NextRaw(t *testing.T) {
 		expected string
 	}
 
-	// TODO: Fix the last 2 cases, they're currently hanging.
 	cases := []Case{
 		{input: "foo", expected: "foo"},
 		{input: " foo ", expected: "foo"},
 		{input: "\" foo \"", expected: " foo "},
 		{input: "' foo '", expected: " foo "},
 		{input: "` foo `", expected: " foo "},
-		// Case{input: "\"foo'bar\"", expected: "foo'bar"},
-		// Case{input: "\"()\"", expected: "()"},
+		{input: "\"foo'bar\"", expected: "foo'bar"},
+		{input: "\"()\"", expected: "()"},
+		{input: "'./[a-z]*'", expected: "./[a-z]*"},
+		{input: "'foo", expected: "foo"},
+		{input: "'file with spaces.txt'", expected: "file with spaces.txt"},
+		{input: `'it\'s'`, expected: "it's"},
+		{input: `"say \"hi\""`, expected: `say "hi"`},
+		{input: `'C:\\Users'`, expected: `C:\Users`},
+		{input: `'\.go$'`, expected: `\.go$`},
+		{input: `'foo\"bar'`, expected: `foo\"bar`},
 	}
 
 	for _, c := range cases {
@@ -79,6 +106,8 @@ func TestTokenizer_AllSimple(t *testing.T) {
       ~/Desktop
     WHERE
       name LIKE %go
+    ORDER BY
+      size DESC
     `
 
 	actual := NewTokenizer(input).All()
@@ -93,6 +122,9 @@ func TestTokenizer_AllSimple(t *testing.T) {
 		{Type: Identifier, Raw: "name"},
 		{Type: Like, Raw: "LIKE"},
 		{Type: Identifier, Raw: "%go"},
+		{Type: OrderBy, Raw: "ORDER BY"},
+		{Type: Identifier, Raw: "size"},
+		{Type: Identifier, Raw: "DESC"},
 	}
 
 	for i := range expected {
@@ -146,6 +178,174 @@ func TestTokenizer_AllSubquery(t *testing.T) {
 	}
 }
 
+func TestTokenizer_AllExec(t *testing.T) {
+	input := `
+    SELECT name FROM . WHERE name LIKE %.tmp
+    EXEC   mv -n {} "/tmp/old files/"
+    `
+
+	actual := NewTokenizer(input).All()
+	expected := []Token{
+		{Type: Select, Raw: "SELECT"},
+		{Type: Identifier, Raw: "name"},
+		{Type: From, Raw: "FROM"},
+		{Type: Identifier, Raw: "."},
+		{Type: Where, Raw: "WHERE"},
+		{Type: Identifier, Raw: "name"},
+		{Type: Like, Raw: "LIKE"},
+		{Type: Identifier, Raw: "%.tmp"},
+		{Type: Exec, Raw: "EXEC"},
+		{Type: Command, Raw: `mv -n {} "/tmp/old files/"`},
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, actual)
+	}
+}
+
+func TestTokenizer_AllComments(t *testing.T) {
+	input := `
+    -- Go files, /* not a block comment
+    SELECT name -- the name
+    FROM . /* the cwd, -- not a line comment */
+    WHERE name = '--foo' AND name = "/*bar*/" OR name = foo--bar
+    AND name IN (
+      SELECT name -- in (a subquery)
+      FROM ./foo /* ( */
+      WHERE name=--
+      baz)
+    /* unterminated`
+
+	actual := NewTokenizer(input).All()
+	expected := []Token{
+		{Type: Select, Raw: "SELECT"},
+		{Type: Identifier, Raw: "name"},
+		{Type: From, Raw: "FROM"},
+		{Type: Identifier, Raw: "."},
+		{Type: Where, Raw: "WHERE"},
+		{Type: Identifier, Raw: "name"},
+		{Type: Equals, Raw: "="},
+		{Type: Identifier, Raw: "--foo"},
+		{Type: And, Raw: "AND"},
+		{Type: Identifier, Raw: "name"},
+		{Type: Equals, Raw: "="},
+		{Type: Identifier, Raw: "/*bar*/"},
+		{Type: Or, Raw: "OR"},
+		{Type: Identifier, Raw: "name"},
+		{Type: Equals, Raw: "="},
+		{Type: Identifier, Raw: "foo--bar"},
+		{Type: And, Raw: "AND"},
+		{Type: Identifier, Raw: "name"},
+		{Type: In, Raw: "IN"},
+		{Type: OpenParen, Raw: "("},
+		{Type: Subquery, Raw: "SELECT name FROM ./foo WHERE name= baz"},
+		{Type: CloseParen, Raw: ")"},
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, actual)
+	}
+}
+
+func TestTokenizer_AllList(t *testing.T) {
+	input := "WHERE extension IN (go, 'mod', sum) AND size NOT IN (0, 1024)"
+
+	actual := NewTokenizer(input).All()
+	expected := []Token{
+		{Type: Where, Raw: "WHERE"},
+		{Type: Identifier, Raw: "extension"},
+		{Type: In, Raw: "IN"},
+		{Type: OpenParen, Raw: "("},
+		{Type: Identifier, Raw: "go"},
+		{Type: Comma, Raw: ","},
+		{Type: Identifier, Raw: "mod"},
+		{Type: Comma, Raw: ","},
+		{Type: Identifier, Raw: "sum"},
+		{Type: CloseParen, Raw: ")"},
+		{Type: And, Raw: "AND"},
+		{Type: Identifier, Raw: "size"},
+		{Type: Not, Raw: "NOT"},
+		{Type: In, Raw: "IN"},
+		{Type: OpenParen, Raw: "("},
+		{Type: Identifier, Raw: "0"},
+		{Type: Comma, Raw: ","},
+		{Type: Identifier, Raw: "1024"},
+		{Type: CloseParen, Raw: ")"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, actual)
+	}
+}
+
+func TestTokenizer_AllKeywordList(t *testing.T) {
+	input := "WHERE name IN (from, where, select) AND name IN (select)"
+
+	actual := NewTokenizer(input).All()
+	expected := []Token{
+		{Type: Where, Raw: "WHERE"},
+		{Type: Identifier, Raw: "name"},
+		{Type: In, Raw: "IN"},
+		{Type: OpenParen, Raw: "("},
+		{Type: Identifier, Raw: "from"},
+		{Type: Comma, Raw: ","},
+		{Type: Identifier, Raw: "where"},
+		{Type: Comma, Raw: ","},
+		{Type: Identifier, Raw: "select"},
+		{Type: CloseParen, Raw: ")"},
+		{Type: And, Raw: "AND"},
+		{Type: Identifier, Raw: "name"},
+		{Type: In, Raw: "IN"},
+		{Type: OpenParen, Raw: "("},
+		{Type: Identifier, Raw: "select"},
+		{Type: CloseParen, Raw: ")"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, actual)
+	}
+}
+
+func TestTokenizer_AllKeywordValues(t *testing.T) {
+	input := "SELECT DISTINCT name AS limit FROM exec WHERE name = offset OR name IN (having, " +
+		"exclude) OR size BETWEEN glob AND ilike LIMIT 1"
+
+	actual := NewTokenizer(input).All()
+	expected := []Token{
+		{Type: Select, Raw: "SELECT"},
+		{Type: Distinct, Raw: "DISTINCT"},
+		{Type: Identifier, Raw: "name"},
+		{Type: As, Raw: "AS"},
+		{Type: Identifier, Raw: "limit"},
+		{Type: From, Raw: "FROM"},
+		{Type: Identifier, Raw: "exec"},
+		{Type: Where, Raw: "WHERE"},
+		{Type: Identifier, Raw: "name"},
+		{Type: Equals, Raw: "="},
+		{Type: Identifier, Raw: "offset"},
+		{Type: Or, Raw: "OR"},
+		{Type: Identifier, Raw: "name"},
+		{Type: In, Raw: "IN"},
+		{Type: OpenParen, Raw: "("},
+		{Type: Identifier, Raw: "having"},
+		{Type: Comma, Raw: ","},
+		{Type: Identifier, Raw: "exclude"},
+		{Type: CloseParen, Raw: ")"},
+		{Type: Or, Raw: "OR"},
+		{Type: Identifier, Raw: "size"},
+		{Type: Between, Raw: "BETWEEN"},
+		{Type: Identifier, Raw: "glob"},
+		{Type: And, Raw: "AND"},
+		{Type: Identifier, Raw: "ilike"},
+		{Type: Limit, Raw: "LIMIT"},
+		{Type: Identifier, Raw: "1"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, actual)
+	}
+}
+
 func TestTokenizer_ReadWord(t *testing.T) {
 	type Case struct {
 		input    string
@@ -172,8 +372,18 @@ func TestTokenizer_ReadQuery(t *testing.T) {
 		expected string
 	}
 
-	// TODO: Complete these cases.
-	cases := []Case{}
+	cases := []Case{
+		{input: "name FROM . WHERE size > 0)", expected: "name FROM . WHERE size > 0"},
+		{input: "name\n  FROM  .\n)", expected: "name FROM ."},
+		{input: "COUNT(*) FROM .) WHERE", expected: "COUNT(*) FROM ."},
+		{
+			input:    "name FROM . WHERE name IN (SELECT name FROM ./foo)) LIMIT 1",
+			expected: "name FROM . WHERE name IN (SELECT name FROM ./foo)",
+		},
+		{input: "name FROM . WHERE name = ')  ('", expected: "name FROM . WHERE name = ')  ('"},
+		{input: `name FROM . WHERE name = 'it\')s') OR`, expected: `name FROM . WHERE name = 'it\')s'`},
+		{input: "name FROM .", expected: "name FROM ."},
+	}
 
 	for _, c := range cases {
 		actual := NewTokenizer(c.input).readQuery()
@@ -1,15 +1,120 @@
 package transform
 
 import (
+	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
 	"hash"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 )
 
+// toString asserts that value is a string, returning an ErrTypeMismatch for
+// the modifier name and attribute otherwise.
+func toString(name, attribute string, value interface{}) (string, error) {
+	str, ok := value.(string)
+	if !ok {
+		return "", &ErrTypeMismatch{name, attribute, reflect.String,
+			reflect.ValueOf(value).Kind()}
+	}
+	return str, nil
+}
+
+// toNumber returns value as a float64, whether it's already numeric (e.g. the
+// int64 of a `size`) or a string of a decimal number (e.g. `300`), returning
+// an ErrTypeMismatch for the modifier name and attribute otherwise.
+func toNumber(name, attribute string, value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		if number, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return number, nil
+		}
+	}
+	return 0, &ErrTypeMismatch{name, attribute, reflect.Int64, reflect.ValueOf(value).Kind()}
+}
+
+// stringModifiers holds each modifier function which operates on a string
+// value, keyed by modifier name. These are shared by Format and Parse.
+var stringModifiers = map[string]func(str string, args []string) (interface{}, error){
+	"UPPER": func(str string, args []string) (interface{}, error) {
+		return upper(str), nil
+	},
+	"LOWER": func(str string, args []string) (interface{}, error) {
+		return lower(str), nil
+	},
+	"TRIM": func(str string, args []string) (interface{}, error) {
+		return trim(str, args), nil
+	},
+	"LTRIM": func(str string, args []string) (interface{}, error) {
+		return ltrim(str, args), nil
+	},
+	"RTRIM": func(str string, args []string) (interface{}, error) {
+		return rtrim(str, args), nil
+	},
+	"TITLE": func(str string, args []string) (interface{}, error) {
+		return title(str), nil
+	},
+	"CAPITALIZE": func(str string, args []string) (interface{}, error) {
+		return capitalize(str), nil
+	},
+	"LENGTH": func(str string, args []string) (interface{}, error) {
+		return length(str), nil
+	},
+	"LEN": func(str string, args []string) (interface{}, error) {
+		return length(str), nil
+	},
+	"REVERSE": func(str string, args []string) (interface{}, error) {
+		return reverse(str), nil
+	},
+	"REPLACE": replace,
+	"SUBSTR":  substr,
+	"SPLIT":   split,
+	"LPAD": func(str string, args []string) (interface{}, error) {
+		return pad("LPAD", str, args)
+	},
+	"RPAD": func(str string, args []string) (interface{}, error) {
+		return pad("RPAD", str, args)
+	},
+
+	"REGEXP_REPLACE": regexpReplace,
+}
+
+// formatString runs the string modifier function name on value with args.
+// Returns nil if name is not a string modifier.
+func formatString(name, attribute string, value interface{},
+	args []string) (interface{}, error) {
+	fn, ok := stringModifiers[strings.ToUpper(name)]
+	if !ok {
+		return nil, nil
+	}
+	str, err := toString(name, attribute, value)
+	if err != nil {
+		return nil, err
+	}
+	return fn(str, args)
+}
+
 // formatName runs the correct name format function based on the value of arg.
 func formatName(arg, name string) interface{} {
 	switch strings.ToUpper(arg) {
@@ -31,6 +136,229 @@ func lower(name string) interface{} {
 	return strings.ToLower(name)
 }
 
+// title returns the title-cased version of name, e.g. `My_Report.txt` for
+// `my_report.txt`. Words are split according to the Unicode word boundary
+// rules, and also by underscores, hyphens, and dots. The extension (see
+// Extension) is kept as is.
+func title(name string) interface{} {
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	base := name[:len(name)-len(ext)]
+
+	caser := cases.Title(language.Und)
+	var b strings.Builder
+	start := 0
+	for i, r := range base {
+		if r == '_' || r == '-' || r == '.' {
+			b.WriteString(caser.String(base[start:i]))
+			b.WriteRune(r)
+			start = i + 1
+		}
+	}
+	b.WriteString(caser.String(base[start:]))
+	return b.String() + ext
+}
+
+// capitalize returns name with its first character uppercased.
+func capitalize(name string) interface{} {
+	r, n := utf8.DecodeRuneInString(name)
+	if r == utf8.RuneError {
+		return name
+	}
+	return string(unicode.ToUpper(r)) + name[n:]
+}
+
+// length returns the number of characters (not bytes) in str.
+func length(str string) interface{} {
+	return int64(utf8.RuneCountInString(str))
+}
+
+// reverse returns str with its characters (not bytes) in reverse order.
+func reverse(str string) interface{} {
+	runes := []rune(str)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// trim returns str with all leading and trailing characters contained in
+// args[0] removed. If no cutset is provided, whitespace is removed.
+func trim(str string, args []string) interface{} {
+	if len(args) == 0 || args[0] == "" {
+		return strings.TrimSpace(str)
+	}
+	return strings.Trim(str, args[0])
+}
+
+// ltrim returns str with all leading characters contained in args[0]
+// removed. If no cutset is provided, whitespace is removed.
+func ltrim(str string, args []string) interface{} {
+	if len(args) == 0 || args[0] == "" {
+		return strings.TrimLeftFunc(str, unicode.IsSpace)
+	}
+	return strings.TrimLeft(str, args[0])
+}
+
+// rtrim returns str with all trailing characters contained in args[0]
+// removed. If no cutset is provided, whitespace is removed.
+func rtrim(str string, args []string) interface{} {
+	if len(args) == 0 || args[0] == "" {
+		return strings.TrimRightFunc(str, unicode.IsSpace)
+	}
+	return strings.TrimRight(str, args[0])
+}
+
+// replace returns str with occurrences of args[0] replaced by args[1]. If
+// args[2] is provided, at most that many occurrences are replaced.
+func replace(str string, args []string) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, &ErrArgumentCount{"REPLACE", 2, len(args)}
+	}
+	n := -1
+	if len(args) > 2 {
+		var err error
+		if n, err = strconv.Atoi(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	return strings.Replace(str, args[0], args[1], n), nil
+}
+
+// patterns caches each compiled regular expression, keyed by its source, so
+// that patterns aren't recompiled for every file.
+var patterns = struct {
+	sync.Mutex
+	cache map[string]*regexp.Regexp
+}{cache: make(map[string]*regexp.Regexp)}
+
+// compilePattern returns the compiled regular expression for expr, using the
+// cached value if expr has already been compiled.
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	patterns.Lock()
+	defer patterns.Unlock()
+
+	if re, ok := patterns.cache[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patterns.cache[expr] = re
+	return re, nil
+}
+
+// regexpReplace returns str with matches of the regular expression args[0]
+// replaced by args[1]. The replacement may reference submatches (e.g. `$1`).
+func regexpReplace(str string, args []string) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, &ErrArgumentCount{"REGEXP_REPLACE", 2, len(args)}
+	}
+	re, err := compilePattern(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re.ReplaceAllString(str, args[1]), nil
+}
+
+// substr returns the substring of str starting at the rune offset args[0],
+// optionally limited to args[1] runes. A negative offset is relative to the
+// end of str. Out-of-range offsets are clamped to the bounds of str.
+func substr(str string, args []string) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, &ErrArgumentCount{"SUBSTR", 1, len(args)}
+	}
+	start, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(str)
+	if start < 0 {
+		start += len(runes)
+	}
+	start = clamp(start, 0, len(runes))
+
+	end := len(runes)
+	if len(args) > 1 {
+		length, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, err
+		}
+		end = clamp(start+length, start, len(runes))
+	}
+
+	return string(runes[start:end]), nil
+}
+
+// split splits str by the separator args[0] and returns the element at index
+// args[1]. A negative index is relative to the end of the split. Returns an
+// empty string if the index is out of range.
+func split(str string, args []string) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, &ErrArgumentCount{"SPLIT", 2, len(args)}
+	}
+	i, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(str, args[0])
+	if i < 0 {
+		i += len(parts)
+	}
+	if i < 0 || i >= len(parts) {
+		return "", nil
+	}
+	return parts[i], nil
+}
+
+// pad pads str to a width of args[0] characters with the character args[1]
+// (a space by default). The padding is added to the start of str for `LPAD`
+// and to the end for `RPAD`. If str is already at least args[0] characters
+// long, it's returned unchanged.
+func pad(name, str string, args []string) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, &ErrArgumentCount{name, 1, len(args)}
+	}
+	width, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid width: %w", err)
+	}
+
+	char := " "
+	if len(args) > 1 && args[1] != "" {
+		if utf8.RuneCountInString(args[1]) != 1 {
+			return nil, fmt.Errorf("invalid padding %s: expected a single character",
+				args[1])
+		}
+		char = args[1]
+	}
+
+	n := width - utf8.RuneCountInString(str)
+	if n <= 0 {
+		return str, nil
+	}
+	if name == "LPAD" {
+		return strings.Repeat(char, n) + str, nil
+	}
+	return str + strings.Repeat(char, n), nil
+}
+
+// clamp limits n to the range [lo, hi].
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
 // truncate returns the first n characters of str. If n is greater than the
 // length of str or less than 0, return str.
 func truncate(str string, n int) string {
@@ -41,11 +369,151 @@ func truncate(str string, n int) string {
 	return str[0:n]
 }
 
+// sizeUnits holds the decimal size units in increasing order.
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+// sizeUnitBytes maps each (upper case) size unit to its number of bytes.
+var sizeUnitBytes = map[string]float64{
+	"B":   1,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"PB":  1e15,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+	"PIB": 1 << 50,
+}
+
+// IsSizeUnit returns true iff unit is a size unit (see ParseSize), case
+// insensitive.
+func IsSizeUnit(unit string) bool {
+	_, ok := sizeUnitBytes[strings.ToUpper(unit)]
+	return ok
+}
+
+// humanize returns size formatted with the largest decimal unit for which the
+// value is at least 1 (e.g. `1.4 GB`). Sizes under 1 KB are shown in bytes.
+func humanize(size int64) string {
+	value, unit := humanUnit(size)
+	if unit == "B" {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
+
+// humanUnit returns size in the largest decimal unit for which the value is
+// at least 1, and that unit (see humanize).
+func humanUnit(size int64) (float64, string) {
+	value, i := float64(size), 0
+	for ; i < len(sizeUnits)-1 && (value >= 1e3 || value <= -1e3); i++ {
+		value /= 1e3
+	}
+	return value, sizeUnits[i]
+}
+
+// Size is a size formatted by `FORMAT(size, AUTO)`, which is shown as Human
+// (e.g. `1.4 GB`), except in the `json` format, where each field is kept.
+type Size struct {
+	Bytes int64  `json:"bytes"`
+	Human string `json:"human"`
+	Unit  string `json:"unit"`
+}
+
+func (s Size) String() string {
+	return s.Human
+}
+
+// timeUnits holds the units used by relativeTime, in decreasing order. Months
+// and years are approximated as 30 and 365 days, respectively.
+var timeUnits = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+}
+
+// relativeTime returns a coarse description of t relative to now, using the
+// largest fitting unit (e.g. `3 days ago` or `in 2 hours`).
+func relativeTime(t, now time.Time) string {
+	delta := now.Sub(t)
+	future := delta < 0
+	if future {
+		delta = -delta
+	}
+
+	for _, unit := range timeUnits {
+		if delta < unit.duration {
+			continue
+		}
+		n := int64(delta / unit.duration)
+		phrase := fmt.Sprintf("%d %s", n, unit.name)
+		if n != 1 {
+			phrase += "s"
+		}
+		if future {
+			return "in " + phrase
+		}
+		return phrase + " ago"
+	}
+	return "just now"
+}
+
+// loadLocation returns the time zone with the provided name, e.g. `UTC`,
+// `Local`, or `America/New_York`.
+func loadLocation(name string) (*time.Location, error) {
+	switch strings.ToUpper(name) {
+	case "UTC":
+		return time.UTC, nil
+	case "LOCAL":
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, &ErrTimeZone{Name: name, Cause: err}
+	}
+	return loc, nil
+}
+
+// Extension returns the lowercased extension of name, without the leading
+// dot. Returns an empty string if name has no extension (including dotfiles
+// such as `.gitignore`).
+func Extension(name string) string {
+	ext := filepath.Ext(name)
+	if ext == name {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// StripExtension returns path without the extension of its last element (see
+// Extension), e.g. `report.tar` for `report.tar.gz`. Paths without an
+// extension (including dotfiles) are returned unchanged.
+func StripExtension(path string) string {
+	if Extension(filepath.Base(path)) == "" {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
 // FindHash returns a func to create a new hash based on the provided name.
 func FindHash(name string) func() hash.Hash {
 	switch strings.ToUpper(name) {
+	case "MD5":
+		return md5.New
 	case "SHA1":
 		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
 	}
 	return nil
 }
@@ -59,24 +527,27 @@ func ComputeHash(info os.FileInfo, path string, h hash.Hash) (interface{}, error
 	// stat the resultant file. If either process fails, ignore the error and
 	// return the fallback.
 	if info.Mode()&os.ModeSymlink == os.ModeSymlink {
-		var err error
-		if path, err = filepath.EvalSymlinks(path); err != nil {
+		target, err := stat(path, info)
+		if err != nil {
 			return fallback, nil
 		}
-		if info, err = os.Stat(path); err != nil {
+		if path, err = filepath.EvalSymlinks(path); err != nil {
 			return fallback, nil
 		}
+		info = target
 	}
 
 	if info.IsDir() {
 		return fallback, nil
 	}
 
-	b, err := ioutil.ReadFile(path)
+	f, err := open(path, info)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := h.Write(b); err != nil {
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
 		return nil, err
 	}
 	return hex.EncodeToString(h.Sum(nil)), nil
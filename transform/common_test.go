@@ -1,12 +1,21 @@
 package transform
 
 import (
+	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
 	"hash"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp/syntax"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCommon_FormatName(t *testing.T) {
@@ -69,6 +78,335 @@ func TestCommon_Lower(t *testing.T) {
 	}
 }
 
+func TestCommon_FormatString(t *testing.T) {
+	type Case struct {
+		name     string
+		value    interface{}
+		args     []string
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{name: "upper", value: "foo", args: []string{}, expected: "FOO"},
+		{name: "LOWER", value: "FOO", args: []string{}, expected: "foo"},
+		{name: "trim", value: " foo ", args: []string{}, expected: "foo"},
+		{name: "foo", value: "foo", args: []string{}, expected: nil},
+		{
+			name:     "upper",
+			value:    int64(1),
+			args:     []string{},
+			expected: nil,
+			err:      &ErrTypeMismatch{"upper", "name", reflect.String, reflect.Int64},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := formatString(c.name, "name", c.value, c.args)
+		if !(reflect.DeepEqual(c.expected, actual) && reflect.DeepEqual(c.err, err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, c.err,
+				actual, err)
+		}
+	}
+}
+
+func TestCommon_Title(t *testing.T) {
+	type Case struct {
+		name     string
+		expected string
+	}
+
+	cases := []Case{
+		{name: "foo bar", expected: "Foo Bar"},
+		{name: "FOO-BAR", expected: "Foo-Bar"},
+		{name: "my_report.txt", expected: "My_Report.txt"},
+		{name: "annual.sales_q1-final.csv", expected: "Annual.Sales_Q1-Final.csv"},
+		{name: "README", expected: "Readme"},
+		{name: ".gitignore", expected: ".Gitignore"},
+		{name: "src/my_file.go", expected: "Src/My_File.go"},
+		{name: "café crème", expected: "Café Crème"},
+		{name: "", expected: ""},
+	}
+
+	for _, c := range cases {
+		result := title(c.name)
+		if result != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, result)
+		}
+	}
+}
+
+func TestCommon_Capitalize(t *testing.T) {
+	type Case struct {
+		name     string
+		expected string
+	}
+
+	cases := []Case{
+		{name: "foo bar", expected: "Foo bar"},
+		{name: "Foo", expected: "Foo"},
+		{name: "éCOLE", expected: "ÉCOLE"},
+		{name: "", expected: ""},
+	}
+
+	for _, c := range cases {
+		result := capitalize(c.name)
+		if result != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, result)
+		}
+	}
+}
+
+func TestCommon_Length(t *testing.T) {
+	type Case struct {
+		str      string
+		expected int64
+	}
+
+	cases := []Case{
+		{str: "foo", expected: 3},
+		{str: "café", expected: 4},
+		{str: "", expected: 0},
+	}
+
+	for _, c := range cases {
+		result := length(c.str)
+		if result != c.expected {
+			t.Fatalf("\nExpected: %d\n     Got: %v", c.expected, result)
+		}
+	}
+}
+
+func TestCommon_Reverse(t *testing.T) {
+	type Case struct {
+		str      string
+		expected string
+	}
+
+	cases := []Case{
+		{str: "foo.go", expected: "og.oof"},
+		{str: "café", expected: "éfac"},
+		{str: "a", expected: "a"},
+		{str: "", expected: ""},
+	}
+
+	for _, c := range cases {
+		result := reverse(c.str)
+		if result != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, result)
+		}
+	}
+}
+
+func TestCommon_Trim(t *testing.T) {
+	type Case struct {
+		fn       func(string, []string) interface{}
+		str      string
+		args     []string
+		expected string
+	}
+
+	cases := []Case{
+		{fn: trim, str: " \tfoo \n", args: []string{}, expected: "foo"},
+		{fn: trim, str: "._foo_.", args: []string{"._"}, expected: "foo"},
+		{fn: trim, str: " foo ", args: []string{""}, expected: "foo"},
+		{fn: ltrim, str: " foo ", args: []string{}, expected: "foo "},
+		{fn: ltrim, str: "._foo_.", args: []string{"._"}, expected: "foo_."},
+		{fn: rtrim, str: " foo ", args: []string{}, expected: " foo"},
+		{fn: rtrim, str: "._foo_.", args: []string{"._"}, expected: "._foo"},
+	}
+
+	for _, c := range cases {
+		actual := c.fn(c.str, c.args)
+		if actual != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestCommon_Replace(t *testing.T) {
+	type Case struct {
+		str      string
+		args     []string
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{str: "foo_bar_baz", args: []string{"_", "-"}, expected: "foo-bar-baz"},
+		{str: "foo_bar_baz", args: []string{"_", "-", "1"}, expected: "foo-bar_baz"},
+		{str: "foo_bar_baz", args: []string{"_", ""}, expected: "foobarbaz"},
+		{str: "foo", args: []string{"x", "y"}, expected: "foo"},
+		{
+			str:      "foo",
+			args:     []string{"_"},
+			expected: nil,
+			err:      &ErrArgumentCount{"REPLACE", 2, 1},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := replace(c.str, c.args)
+		if !(reflect.DeepEqual(c.expected, actual) && reflect.DeepEqual(c.err, err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, c.err,
+				actual, err)
+		}
+	}
+}
+
+func TestCommon_RegexpReplace(t *testing.T) {
+	type Case struct {
+		str      string
+		args     []string
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{str: "foo123bar45", args: []string{"\\d+", "N"}, expected: "fooNbarN"},
+		{str: "foo.tar.gz", args: []string{"^([^.]*)\\..*$", "$1"}, expected: "foo"},
+		{str: "foo", args: []string{"x", "y"}, expected: "foo"},
+		{
+			str:      "foo",
+			args:     []string{"("},
+			expected: nil,
+			err:      &ErrArgumentCount{"REGEXP_REPLACE", 2, 1},
+		},
+		{
+			str:      "foo",
+			args:     []string{"(", "y"},
+			expected: nil,
+			err: fmt.Errorf("invalid pattern: %w",
+				&syntax.Error{Code: syntax.ErrMissingParen, Expr: "("}),
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := regexpReplace(c.str, c.args)
+		if !(reflect.DeepEqual(c.expected, actual) && reflect.DeepEqual(c.err, err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, c.err,
+				actual, err)
+		}
+	}
+}
+
+func TestCommon_Substr(t *testing.T) {
+	type Case struct {
+		str      string
+		args     []string
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{str: "foo-bar-baz", args: []string{"0", "3"}, expected: "foo"},
+		{str: "foo-bar-baz", args: []string{"4"}, expected: "bar-baz"},
+		{str: "foo-bar-baz", args: []string{"-3"}, expected: "baz"},
+		{str: "foo-bar-baz", args: []string{"-7", "3"}, expected: "bar"},
+		{str: "foo-bar-baz", args: []string{"-100", "3"}, expected: "foo"},
+		{str: "foo-bar-baz", args: []string{"100"}, expected: ""},
+		{str: "foo-bar-baz", args: []string{"8", "100"}, expected: "baz"},
+		{str: "foo-bar-baz", args: []string{"0", "-1"}, expected: ""},
+		{str: "café-crème", args: []string{"3", "4"}, expected: "é-cr"},
+		{
+			str:      "foo",
+			args:     []string{},
+			expected: nil,
+			err:      &ErrArgumentCount{"SUBSTR", 1, 0},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := substr(c.str, c.args)
+		if !(reflect.DeepEqual(c.expected, actual) && reflect.DeepEqual(c.err, err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, c.err,
+				actual, err)
+		}
+	}
+}
+
+func TestCommon_Split(t *testing.T) {
+	type Case struct {
+		str      string
+		args     []string
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{str: "foo.tar.gz", args: []string{".", "0"}, expected: "foo"},
+		{str: "foo.tar.gz", args: []string{".", "1"}, expected: "tar"},
+		{str: "foo.tar.gz", args: []string{".", "-1"}, expected: "gz"},
+		{str: "foo.tar.gz", args: []string{".", "-3"}, expected: "foo"},
+		{str: "foo.tar.gz", args: []string{".", "3"}, expected: ""},
+		{str: "foo.tar.gz", args: []string{".", "-4"}, expected: ""},
+		{str: "foo", args: []string{".", "-1"}, expected: "foo"},
+		{
+			str:      "foo",
+			args:     []string{"."},
+			expected: nil,
+			err:      &ErrArgumentCount{"SPLIT", 2, 1},
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := split(c.str, c.args)
+		if !(reflect.DeepEqual(c.expected, actual) && reflect.DeepEqual(c.err, err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, c.err,
+				actual, err)
+		}
+	}
+}
+
+func TestCommon_Pad(t *testing.T) {
+	type Case struct {
+		name     string
+		str      string
+		args     []string
+		expected interface{}
+		err      error
+	}
+
+	cases := []Case{
+		{name: "LPAD", str: "foo", args: []string{"5"}, expected: "  foo"},
+		{name: "RPAD", str: "foo", args: []string{"5"}, expected: "foo  "},
+		{name: "LPAD", str: "7", args: []string{"3", "0"}, expected: "007"},
+		{name: "RPAD", str: "café", args: []string{"6", "é"}, expected: "cafééé"},
+		{name: "LPAD", str: "foobar", args: []string{"3"}, expected: "foobar"},
+		{name: "RPAD", str: "foo", args: []string{"-1"}, expected: "foo"},
+		{
+			name:     "LPAD",
+			str:      "foo",
+			args:     []string{},
+			expected: nil,
+			err:      &ErrArgumentCount{"LPAD", 1, 0},
+		},
+		{
+			name:     "LPAD",
+			str:      "foo",
+			args:     []string{"wide"},
+			expected: nil,
+			err: fmt.Errorf("invalid width: %w",
+				&strconv.NumError{Func: "Atoi", Num: "wide", Err: strconv.ErrSyntax}),
+		},
+		{
+			name:     "RPAD",
+			str:      "foo",
+			args:     []string{"5", "ab"},
+			expected: nil,
+			err:      errors.New("invalid padding ab: expected a single character"),
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := pad(c.name, c.str, c.args)
+		if !(reflect.DeepEqual(c.expected, actual) && reflect.DeepEqual(c.err, err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, c.err,
+				actual, err)
+		}
+	}
+}
+
 func TestCommon_Truncate(t *testing.T) {
 	input := "foo-bar-baz"
 
@@ -94,6 +432,110 @@ func TestCommon_Truncate(t *testing.T) {
 	}
 }
 
+func TestCommon_Humanize(t *testing.T) {
+	type Case struct {
+		size     int64
+		expected string
+	}
+
+	cases := []Case{
+		{size: 0, expected: "0 B"},
+		{size: 999, expected: "999 B"},
+		{size: 1000, expected: "1.0 KB"},
+		{size: 1450, expected: "1.4 KB"},
+		{size: 4096, expected: "4.1 KB"},
+		{size: 1400000000, expected: "1.4 GB"},
+		{size: 3e15, expected: "3.0 PB"},
+		{size: 3e18, expected: "3000.0 PB"},
+	}
+
+	for _, c := range cases {
+		actual := humanize(c.size)
+		if actual != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestCommon_RelativeTime(t *testing.T) {
+	now := time.Date(2017, time.July, 6, 12, 0, 0, 0, time.UTC)
+
+	type Case struct {
+		t        time.Time
+		expected string
+	}
+
+	cases := []Case{
+		{t: now, expected: "just now"},
+		{t: now.Add(-500 * time.Millisecond), expected: "just now"},
+		{t: now.Add(-1 * time.Second), expected: "1 second ago"},
+		{t: now.Add(-59 * time.Second), expected: "59 seconds ago"},
+		{t: now.Add(-2 * time.Hour), expected: "2 hours ago"},
+		{t: now.Add(-5*24*time.Hour - time.Hour), expected: "5 days ago"},
+		{t: now.Add(-45 * 24 * time.Hour), expected: "1 month ago"},
+		{t: now.Add(-3 * 365 * 24 * time.Hour), expected: "3 years ago"},
+		{t: now.Add(90 * time.Minute), expected: "in 1 hour"},
+		{t: now.Add(3 * time.Minute), expected: "in 3 minutes"},
+	}
+
+	for _, c := range cases {
+		actual := relativeTime(c.t, now)
+		if actual != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestCommon_Extension(t *testing.T) {
+	type Case struct {
+		name     string
+		expected string
+	}
+
+	cases := []Case{
+		{name: "main.go", expected: "go"},
+		{name: "README.MD", expected: "md"},
+		{name: "archive.tar.gz", expected: "gz"},
+		{name: "Makefile", expected: ""},
+		{name: ".gitignore", expected: ""},
+		{name: ".eslintrc.json", expected: "json"},
+		{name: "foo.", expected: ""},
+	}
+
+	for _, c := range cases {
+		result := Extension(c.name)
+		if result != c.expected {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, result)
+		}
+	}
+}
+
+func TestCommon_StripExtension(t *testing.T) {
+	type Case struct {
+		path     string
+		expected string
+	}
+
+	cases := []Case{
+		{path: "main.go", expected: "main"},
+		{path: "report.tar.gz", expected: "report.tar"},
+		{path: "Makefile", expected: "Makefile"},
+		{path: ".gitignore", expected: ".gitignore"},
+		{path: ".eslintrc.json", expected: ".eslintrc"},
+		{path: "foo.", expected: "foo."},
+		{path: "foo/bar.txt", expected: "foo/bar"},
+		{path: "foo.d/.gitkeep", expected: "foo.d/.gitkeep"},
+		{path: "foo.d/bar", expected: "foo.d/bar"},
+	}
+
+	for _, c := range cases {
+		result := StripExtension(filepath.FromSlash(c.path))
+		if result != filepath.FromSlash(c.expected) {
+			t.Fatalf("\nExpected: %s\n     Got: %s", c.expected, result)
+		}
+	}
+}
+
 func TestCommon_FindHash(t *testing.T) {
 	type Case struct {
 		name     string
@@ -101,7 +543,10 @@ func TestCommon_FindHash(t *testing.T) {
 	}
 
 	cases := []Case{
+		{name: "MD5", expected: md5.New()},
 		{name: "SHA1", expected: sha1.New()},
+		{name: "sha256", expected: sha256.New()},
+		{name: "SHA512", expected: sha512.New()},
 		{name: "FOO", expected: nil},
 	}
 
@@ -0,0 +1,135 @@
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// binarySniffLen is the number of leading bytes checked for a NUL byte to
+// decide whether a file is binary (the same heuristic as git and grep).
+const binarySniffLen = 8000
+
+// openText opens the file located at path for reading its contents. Returns
+// a nil reader if the file doesn't have contents, i.e. if it isn't a regular
+// file (or a symbolic link to one), or if it's binary (i.e. has a NUL byte
+// near the start) and binary is false. The returned file must be closed by
+// the caller.
+func openText(path string, info os.FileInfo, binary bool) (io.Closer, *bufio.Reader, error) {
+	if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		var err error
+		if info, err = stat(path, info); err != nil {
+			return nil, nil, nil
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil, nil
+	}
+
+	f, err := open(path, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := bufio.NewReaderSize(f, binarySniffLen)
+	if !binary {
+		head, err := r.Peek(binarySniffLen)
+		if err != nil && err != io.EOF {
+			f.Close()
+			return nil, nil, err
+		}
+		if bytes.IndexByte(head, 0) != -1 {
+			f.Close()
+			return nil, nil, nil
+		}
+	}
+	return f, r, nil
+}
+
+// MatchContent reports whether any line of the file located at path satisfies
+// match. The file is read line by line, so only the longest line is held in
+// memory at once. Lines are passed to match without their line ending.
+//
+// Only regular files (or symbolic links to them) have contents, anything else
+// never matches. Binary files (i.e. files with a NUL byte near the start)
+// never match either, unless binary is true.
+func MatchContent(path string, info os.FileInfo, binary bool,
+	match func(line string) (bool, error)) (bool, error) {
+	f, r, err := openText(path, info, binary)
+	if r == nil {
+		return false, err
+	}
+	defer f.Close()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if len(line) > 0 || err == nil {
+			ok, matchErr := match(strings.TrimRight(line, "\r\n"))
+			if matchErr != nil || ok {
+				return ok, matchErr
+			}
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+	}
+}
+
+// LineCount returns the number of newlines in the file located at path. Files
+// without contents (see MatchContent) have 0 lines, binary files included
+// unless binary is true.
+func LineCount(path string, info os.FileInfo, binary bool) (int64, error) {
+	f, r, err := openText(path, info, binary)
+	if r == nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var count int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		count += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// WordCount returns the number of words in the file located at path, i.e. the
+// runs of characters separated by whitespace (the same as bufio.ScanWords,
+// except that words of any length are counted without holding them in
+// memory). Files without contents (see MatchContent) have 0 words, binary
+// files included unless binary is true.
+func WordCount(path string, info os.FileInfo, binary bool) (int64, error) {
+	f, r, err := openText(path, info, binary)
+	if r == nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var count int64
+	inWord := false
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if unicode.IsSpace(c) {
+			inWord = false
+		} else if !inWord {
+			count++
+			inWord = true
+		}
+	}
+}
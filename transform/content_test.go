@@ -0,0 +1,169 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContent_MatchContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"text":   "foo\r\n// TODO: bar\nbaz",
+		"binary": "TODO\x00",
+		"long":   strings.Repeat("x", 1<<16) + "\nTODO",
+		"empty":  "",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	type Case struct {
+		name     string
+		binary   bool
+		line     string
+		expected bool
+	}
+
+	cases := []Case{
+		{name: "text", line: "foo", expected: true},
+		{name: "text", line: "// TODO: bar", expected: true},
+		{name: "text", line: "baz", expected: true},
+		{name: "text", line: "TODO", expected: false},
+		{name: "binary", line: "TODO", expected: false},
+		{name: "binary", binary: true, line: "TODO\x00", expected: true},
+		{name: "long", line: "TODO", expected: true},
+		{name: "empty", line: "", expected: false},
+		{name: ".", line: "", expected: false},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual, err := MatchContent(path, info, c.binary, func(line string) (bool, error) {
+			return line == c.line, nil
+		})
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("%s, %q\nExpected: %v\n     Got: %v", c.name, c.line, c.expected, actual)
+		}
+	}
+}
+
+func TestContent_LineCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"text":   "foo\r\nbar\nbaz",
+		"binary": "\x00\n\n",
+		"long":   strings.Repeat("x\n", 1<<16),
+		"empty":  "",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	type Case struct {
+		name     string
+		binary   bool
+		expected int64
+	}
+
+	cases := []Case{
+		{name: "text", expected: 2},
+		{name: "binary", expected: 0},
+		{name: "binary", binary: true, expected: 2},
+		{name: "long", expected: 1 << 16},
+		{name: "empty", expected: 0},
+		{name: ".", expected: 0},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual, err := LineCount(path, info, c.binary)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("%s\nExpected: %d\n     Got: %d", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestContent_WordCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"text":    "  foo bar\r\n\tbaz\n\nqux",
+		"unicode": "héllo wörld ✓",
+		"binary":  "\x00 foo bar",
+		"long":    strings.Repeat("x", 1<<17) + " y",
+		"empty":   "",
+		"spaces":  " \n\t ",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+
+	type Case struct {
+		name     string
+		binary   bool
+		expected int64
+	}
+
+	cases := []Case{
+		{name: "text", expected: 4},
+		{name: "unicode", expected: 3},
+		{name: "binary", expected: 0},
+		{name: "binary", binary: true, expected: 3},
+		{name: "long", expected: 2},
+		{name: "empty", expected: 0},
+		{name: "spaces", expected: 0},
+		{name: ".", expected: 0},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual, err := WordCount(path, info, c.binary)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("%s\nExpected: %d\n     Got: %d", c.name, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package transform
+
+import (
+	"io"
+	"os"
+)
+
+// IsEmpty returns true iff the file is an empty regular file or a directory
+// without any entries. Symbolic links are resolved first, and other files
+// (e.g. devices) are never empty. A directory which can't be read isn't
+// empty, so that it isn't mistaken for an empty one; the error is recorded
+// for the file instead (see warner).
+func IsEmpty(path string, info os.FileInfo) (bool, error) {
+	orig := info
+	if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		var err error
+		if info, err = stat(path, info); err != nil {
+			return false, nil
+		}
+	}
+	if info.Mode().IsRegular() {
+		return info.Size() == 0, nil
+	}
+	if !info.IsDir() {
+		return false, nil
+	}
+	if v, ok := info.(virtualFile); ok {
+		return v.Entries() == 0, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		warn(orig, err)
+		return false, nil
+	}
+	defer f.Close()
+
+	if _, err = f.Readdirnames(1); err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		warn(orig, err)
+	}
+	return false, nil
+}
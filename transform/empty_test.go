@@ -0,0 +1,121 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmpty_IsEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "empty"), nil, 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), []byte("foo"), 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir"), 0755); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Case struct {
+		path     string
+		expected bool
+	}
+
+	cases := []Case{
+		{path: filepath.Join(dir, "empty"), expected: true},
+		{path: filepath.Join(dir, "file"), expected: false},
+		{path: filepath.Join(dir, "dir"), expected: true},
+		{path: dir, expected: false},
+	}
+
+	for _, c := range cases {
+		info, err := os.Stat(c.path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual, err := IsEmpty(c.path, info)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", c.path, c.expected, actual)
+		}
+	}
+}
+
+// warnings is the info of a file which records its warnings (see warner).
+type warnings struct {
+	os.FileInfo
+	errs []error
+}
+
+func (w *warnings) Warn(err error) {
+	w.errs = append(w.errs, err)
+}
+
+func TestEmpty_Unreadable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	locked := filepath.Join(dir, "locked")
+	removed := filepath.Join(dir, "removed")
+	for _, path := range []string{locked, removed} {
+		if err := os.Mkdir(path, 0755); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+	lockedInfo, err := os.Stat(locked)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	removedInfo, err := os.Stat(removed)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if err := os.Chmod(locked, 0000); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+	if err := os.Remove(removed); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	paths := []string{removed}
+	if f, err := os.Open(locked); err == nil {
+		// E.g. as root, which can read any directory.
+		f.Close()
+		t.Logf("%s is readable despite its mode", locked)
+	} else {
+		paths = append(paths, locked)
+	}
+
+	for _, path := range paths {
+		info := &warnings{FileInfo: removedInfo}
+		if path == locked {
+			info.FileInfo = lockedInfo
+		}
+		actual, err := IsEmpty(path, info)
+		if err != nil || actual {
+			t.Fatalf("%s\nExpected false, <nil>\n     Got %v, %v", path, actual, err)
+		}
+		if len(info.errs) != 1 {
+			t.Fatalf("%s\nExpected a warning\n     Got %v", path, info.errs)
+		}
+
+		// Without a warner, the error is dropped.
+		if actual, err := IsEmpty(path, info.FileInfo); err != nil || actual {
+			t.Fatalf("%s\nExpected false, <nil>\n     Got %v, %v", path, actual, err)
+		}
+	}
+}
@@ -2,18 +2,65 @@ package transform
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 )
 
-// ErrNotImplemented used for non-implemented modifier functions.
+// ErrModifier wraps each error returned by Format and Parse with the modifier
+// function and attribute it was returned for, e.g. the error of parsing a
+// malformed time in `FORMAT(time, ISO) = ...`. Use errors.As to inspect it,
+// or errors.Is / errors.As on its cause (e.g. an ErrTypeMismatch, or a
+// *time.ParseError).
+type ErrModifier struct {
+	Modifier  string
+	Attribute string
+	Cause     error
+}
+
+func (e *ErrModifier) Error() string {
+	// The errors of this package already describe which modifier (or
+	// attribute) they're for.
+	switch e.Cause.(type) {
+	case *ErrNotImplemented, *ErrUnsupportedFormat, *ErrTypeMismatch, *ErrArgumentCount:
+		return e.Cause.Error()
+	}
+	return fmt.Sprintf("function %s failed for attribute %s: %v",
+		strings.ToUpper(e.Modifier), e.Attribute, e.Cause)
+}
+
+// Unwrap returns the cause of e.
+func (e *ErrModifier) Unwrap() error {
+	return e.Cause
+}
+
+// wrapError wraps err (if any) in an ErrModifier for the modifier name and
+// attribute, unless it's already wrapped (e.g. by a nested call of Parse).
+func wrapError(name, attribute string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ErrModifier); ok {
+		return err
+	}
+	return &ErrModifier{Modifier: name, Attribute: attribute, Cause: err}
+}
+
+// ErrNotImplemented used for non-implemented modifier functions. If the
+// function was applied to an element of a list or map, Element identifies the
+// offending index or key.
 type ErrNotImplemented struct {
 	Name      string
 	Attribute string
+	Element   string
 }
 
 func (e *ErrNotImplemented) Error() string {
-	return fmt.Sprintf("function %s is not implemented for attribute %s",
+	msg := fmt.Sprintf("function %s is not implemented for attribute %s",
 		strings.ToUpper(e.Name), e.Attribute)
+	if e.Element != "" {
+		msg = fmt.Sprintf("%s (at %s)", msg, e.Element)
+	}
+	return msg
 }
 
 // ErrUnsupportedFormat used for unsupport arguments for FORMAT functions.
@@ -26,3 +73,45 @@ func (e *ErrUnsupportedFormat) Error() string {
 	return fmt.Sprintf("unsupported format type %s for attribute %s",
 		e.Format, e.Attribute)
 }
+
+// ErrTypeMismatch used when a modifier function receives a value of an
+// unexpected type.
+type ErrTypeMismatch struct {
+	Name      string
+	Attribute string
+	Expected  reflect.Kind
+	Actual    reflect.Kind
+}
+
+func (e *ErrTypeMismatch) Error() string {
+	return fmt.Sprintf("function %s expected %s for attribute %s; got %s",
+		strings.ToUpper(e.Name), e.Expected, e.Attribute, e.Actual)
+}
+
+// ErrArgumentCount used when a modifier function receives too few arguments.
+type ErrArgumentCount struct {
+	Name     string
+	Expected int
+	Actual   int
+}
+
+func (e *ErrArgumentCount) Error() string {
+	return fmt.Sprintf("function %s expected at least %d argument(s); got %d",
+		strings.ToUpper(e.Name), e.Expected, e.Actual)
+}
+
+// ErrTimeZone used when a time zone argument (e.g. of `FORMAT(time, ISO,
+// Not/AZone)`) can't be loaded. Cause is the error of time.LoadLocation.
+type ErrTimeZone struct {
+	Name  string
+	Cause error
+}
+
+func (e *ErrTimeZone) Error() string {
+	return fmt.Sprintf("invalid time zone %s: %v", e.Name, e.Cause)
+}
+
+// Unwrap returns the cause of e.
+func (e *ErrTimeZone) Unwrap() error {
+	return e.Cause
+}
@@ -1,14 +1,149 @@
 package transform
 
-import "testing"
+import (
+	"errors"
+	"reflect"
+	"regexp/syntax"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// causeOf returns the cause of err, which is either nil or an ErrModifier
+// (e.g. returned by Format or Parse).
+func causeOf(t *testing.T, err error) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(*ErrModifier)
+	if !ok {
+		t.Fatalf("\nExpected an ErrModifier\n     Got %#v", err)
+	}
+	return e.Cause
+}
+
+func TestTransform_ErrModifier(t *testing.T) {
+	cause := &ErrTypeMismatch{"n", "a", reflect.String, reflect.Int64}
+	err := error(&ErrModifier{Modifier: "n", Attribute: "a", Cause: cause})
+	expected := "function N expected string for attribute a; got int64"
+	if actual := err.Error(); expected != actual {
+		t.Fatalf("\nExpected: %s\n     Got: %s", expected, actual)
+	}
+	var mismatch *ErrTypeMismatch
+	if !errors.As(err, &mismatch) || mismatch != cause {
+		t.Fatalf("\nExpected: %v\n     Got: %v", cause, mismatch)
+	}
+
+	err = &ErrModifier{Modifier: "n", Attribute: "a", Cause: strconv.ErrSyntax}
+	expected = "function N failed for attribute a: invalid syntax"
+	if actual := err.Error(); expected != actual {
+		t.Fatalf("\nExpected: %s\n     Got: %s", expected, actual)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", strconv.ErrSyntax, errors.Unwrap(err))
+	}
+
+	// Errors of the standard library are wrapped with the modifier and
+	// attribute they're for.
+	_, err = Parse(&ParseParams{
+		Attribute: "time",
+		Value:     "yesterday",
+		Name:      "format",
+		Args:      []string{"ISO"},
+	})
+	var e *ErrModifier
+	if !errors.As(err, &e) || e.Modifier != "format" || e.Attribute != "time" {
+		t.Fatalf("\nExpected an ErrModifier for FORMAT(time)\n     Got %#v", err)
+	}
+	var parseErr *time.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("\nExpected a *time.ParseError\n     Got %#v", e.Cause)
+	}
+
+	// So are the errors of the standard library wrapped by a modifier.
+	_, err = Format(&FormatParams{
+		Attribute: "name",
+		Value:     "foo",
+		Name:      "regexp_replace",
+		Args:      []string{"(", "bar"},
+	})
+	var syntaxErr *syntax.Error
+	if !errors.As(err, &e) || e.Modifier != "regexp_replace" || !errors.As(err, &syntaxErr) {
+		t.Fatalf("\nExpected a *syntax.Error for REGEXP_REPLACE(name)\n     Got %#v", err)
+	}
+	if syntaxErr.Code != syntax.ErrMissingParen {
+		t.Fatalf("\nExpected: %v\n     Got: %v", syntax.ErrMissingParen, syntaxErr.Code)
+	}
+
+	_, err = Format(&FormatParams{
+		Attribute: "name",
+		Value:     "foo",
+		Name:      "lpad",
+		Args:      []string{"wide"},
+	})
+	var numErr *strconv.NumError
+	if !errors.As(err, &e) || e.Modifier != "lpad" || !errors.As(err, &numErr) {
+		t.Fatalf("\nExpected a *strconv.NumError for LPAD(name)\n     Got %#v", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", strconv.ErrSyntax, err)
+	}
+
+	_, err = Format(&FormatParams{Attribute: "name", Value: "foo", Name: "round"})
+	if !errors.As(err, &mismatch) || mismatch.Name != "round" || mismatch.Actual != reflect.String {
+		t.Fatalf("\nExpected an ErrTypeMismatch for ROUND(name)\n     Got %#v", err)
+	}
+	if expected := "function ROUND expected float64 for attribute name; got string"; err.Error() != expected {
+		t.Fatalf("\nExpected: %s\n     Got: %v", expected, err)
+	}
+
+	_, err = Format(&FormatParams{
+		Attribute: "depth",
+		Value:     int64(1),
+		Name:      "round",
+		Args:      []string{"two"},
+	})
+	if !errors.As(err, &numErr) || !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("\nExpected a *strconv.NumError for ROUND(depth, two)\n     Got %#v", err)
+	}
+}
+
+func TestTransform_ErrTimeZone(t *testing.T) {
+	_, err := Parse(&ParseParams{
+		Attribute: "time",
+		Value:     "2017-07-06 05:10",
+		Name:      "format",
+		Args:      []string{"2006-01-02 15:04", "Not/AZone"},
+	})
+	expected := "function FORMAT failed for attribute time: " +
+		"invalid time zone Not/AZone: unknown time zone Not/AZone"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("\nExpected: %s\n     Got: %v", expected, err)
+	}
+	var tz *ErrTimeZone
+	if !errors.As(err, &tz) || tz.Name != "Not/AZone" {
+		t.Fatalf("\nExpected an ErrTimeZone for Not/AZone\n     Got %#v", err)
+	}
+	_, cause := time.LoadLocation("Not/AZone")
+	if !reflect.DeepEqual(errors.Unwrap(tz), cause) {
+		t.Fatalf("\nExpected: %#v\n     Got: %#v", cause, errors.Unwrap(tz))
+	}
+}
 
 func TestTransform_ErrNotImplemented(t *testing.T) {
-	err := &ErrNotImplemented{"n", "a"}
+	err := &ErrNotImplemented{Name: "n", Attribute: "a"}
 	expected := "function N is not implemented for attribute a"
 	actual := err.Error()
 	if expected != actual {
 		t.Fatalf("\nExpected: %s\n     Got: %s", expected, actual)
 	}
+
+	err = &ErrNotImplemented{Name: "n", Attribute: "a", Element: "index 2"}
+	expected = "function N is not implemented for attribute a (at index 2)"
+	actual = err.Error()
+	if expected != actual {
+		t.Fatalf("\nExpected: %s\n     Got: %s", expected, actual)
+	}
 }
 
 func TestTransform_ErrUnsupportedFormat(t *testing.T) {
@@ -20,3 +155,21 @@ func TestTransform_ErrUnsupportedFormat(t *testing.T) {
 	}
 
 }
+
+func TestTransform_ErrTypeMismatch(t *testing.T) {
+	err := &ErrTypeMismatch{"n", "a", reflect.String, reflect.Int64}
+	expected := "function N expected string for attribute a; got int64"
+	actual := err.Error()
+	if expected != actual {
+		t.Fatalf("\nExpected: %s\n     Got: %s", expected, actual)
+	}
+}
+
+func TestTransform_ErrArgumentCount(t *testing.T) {
+	err := &ErrArgumentCount{"n", 2, 1}
+	expected := "function N expected at least 2 argument(s); got 1"
+	actual := err.Error()
+	if expected != actual {
+		t.Fatalf("\nExpected: %s\n     Got: %s", expected, actual)
+	}
+}
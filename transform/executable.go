@@ -0,0 +1,8 @@
+package transform
+
+import "os"
+
+// IsExecutable returns true iff the file is executable. On Windows, this is a
+// file whose extension is in PATHEXT (e.g. `.exe`), elsewhere it's a file with
+// any execute bit set (including most directories).
+func IsExecutable(info os.FileInfo) bool { return isExecutable(info) }
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package transform
+
+import "os"
+
+// isExecutable returns true iff any of the file's execute bits (for its owner,
+// group, or others) is set.
+func isExecutable(info os.FileInfo) bool {
+	return info.Mode().Perm()&0111 != 0
+}
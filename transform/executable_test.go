@@ -0,0 +1,56 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExecutable_IsExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	type Case struct {
+		name     string
+		perm     os.FileMode
+		expected bool
+	}
+
+	cases := []Case{
+		{name: "owner", perm: 0744, expected: true},
+		{name: "group", perm: 0654, expected: true},
+		{name: "other", perm: 0645, expected: true},
+		{name: "none", perm: 0644, expected: false},
+	}
+	if runtime.GOOS == "windows" {
+		cases = []Case{
+			{name: "foo.exe", perm: 0644, expected: true},
+			{name: "foo.BAT", perm: 0644, expected: true},
+			{name: "foo.txt", perm: 0755, expected: false},
+			{name: "foo", perm: 0755, expected: false},
+		}
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		if err := ioutil.WriteFile(path, nil, c.perm); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		// The permissions of a new file are subject to the umask.
+		if err := os.Chmod(path, c.perm); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual := IsExecutable(info); actual != c.expected {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", c.name, c.expected, actual)
+		}
+	}
+}
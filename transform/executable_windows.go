@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultPathExt holds the executable extensions used if PATHEXT isn't set.
+const defaultPathExt = ".com;.exe;.bat;.cmd"
+
+// isExecutable returns true iff the file isn't a directory and its extension
+// is one of the executable extensions in PATHEXT, since Windows doesn't have
+// execute bits.
+func isExecutable(info os.FileInfo) bool {
+	ext := filepath.Ext(info.Name())
+	if info.IsDir() || ext == "" {
+		return false
+	}
+
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = defaultPathExt
+	}
+	for _, executable := range strings.Split(pathExt, ";") {
+		if strings.EqualFold(ext, executable) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,11 +2,13 @@ package transform
 
 import (
 	"fmt"
-	"hash"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 const defaultHashLength = 7
@@ -23,26 +25,55 @@ type FormatParams struct {
 }
 
 // Format runs the respective format function on the provided parameters.
+// Each error is an ErrModifier.
 func Format(p *FormatParams) (val interface{}, err error) {
+	defer func() { err = wrapError(p.Name, p.Attribute, err) }()
+
 	switch strings.ToUpper(p.Name) {
 	case "FORMAT":
 		val, err = p.format()
-	case "UPPER":
-		val = upper(p.Value.(string))
-	case "LOWER":
-		val = lower(p.Value.(string))
 	case "FULLPATH":
 		val, err = p.fullPath()
 	case "SHORTPATH":
 		val, err = p.shortPath()
-	case "SHA1":
-		val, err = p.hash(FindHash(p.Name)())
+	case "BASENAME", "DIRNAME":
+		val, err = p.pathElement()
+	case "STRIPEXT":
+		var str string
+		if str, err = toString(p.Name, p.Attribute, p.Value); err == nil {
+			val = StripExtension(str)
+		}
+	case "ROUND":
+		val, err = p.round()
+	case "XATTR":
+		if len(p.Args) == 0 {
+			err = &ErrArgumentCount{p.Name, 1, 0}
+		} else {
+			val, err = Xattr(p.Path, p.Info, p.Args[0])
+		}
+	case "HASH":
+		if len(p.Args) == 0 {
+			err = &ErrArgumentCount{p.Name, 1, 0}
+		} else {
+			val, err = p.hash(p.Args[0], p.Args[1:])
+		}
+	case "MD5", "SHA1", "SHA256", "SHA512":
+		val, err = p.hash(p.Name, p.Args)
+	default:
+		if val, err = formatString(p.Name, p.Attribute, p.Value, p.Args); val == nil && err == nil {
+			val, err = runCustomModifier(&ParseParams{
+				Attribute: p.Attribute,
+				Value:     p.Value,
+				Name:      p.Name,
+				Args:      p.Args,
+			})
+		}
 	}
 	if err != nil {
 		return nil, err
 	}
 	if val == nil {
-		return nil, &ErrNotImplemented{p.Name, p.Attribute}
+		return nil, &ErrNotImplemented{Name: p.Name, Attribute: p.Attribute}
 	}
 	return val, nil
 }
@@ -51,11 +82,16 @@ func Format(p *FormatParams) (val interface{}, err error) {
 func (p *FormatParams) format() (val interface{}, err error) {
 	switch p.Attribute {
 	case "name":
-		val = formatName(p.Args[0], p.Value.(string))
+		var str string
+		if str, err = toString(p.Name, p.Attribute, p.Value); err == nil {
+			val = formatName(p.Args[0], str)
+		}
 	case "size":
 		val, err = p.formatSize()
-	case "time":
+	case "time", "accessed", "changed", "created":
 		val, err = p.formatTime()
+	case "mode":
+		val, err = p.formatMode()
 	}
 	if err != nil {
 		return nil, err
@@ -66,33 +102,76 @@ func (p *FormatParams) format() (val interface{}, err error) {
 	return val, nil
 }
 
-// formatSize formats a size. Valid arguments include `KB`, `MB`, `GB` (case
-// insensitive).
+// formatSize formats a size. Valid arguments include any size unit (see
+// ParseSize), where the size is followed by the lower case unit (so it can be
+// parsed again), `HUMAN`, which picks the largest decimal unit for the size,
+// and `AUTO`, which is like `HUMAN` but returns a Size (so e.g. the `json`
+// format keeps the number of bytes), all case insensitive. The size is either
+// numeric or a string of a number of bytes (see toNumber).
 func (p *FormatParams) formatSize() (interface{}, error) {
-	size := p.Value.(int64)
-	switch strings.ToUpper(p.Args[0]) {
-	case "KB":
-		return fmt.Sprintf("%fkb", float64(size)/(1<<10)), nil
-	case "MB":
-		return fmt.Sprintf("%fmb", float64(size)/(1<<20)), nil
-	case "GB":
-		return fmt.Sprintf("%fgb", float64(size)/(1<<30)), nil
+	size, err := toNumber(p.Name, p.Attribute, p.Value)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	unit := strings.ToUpper(p.Args[0])
+	switch unit {
+	case "HUMAN":
+		return humanize(int64(size)), nil
+	case "AUTO":
+		_, unit := humanUnit(int64(size))
+		return Size{Bytes: int64(size), Human: humanize(int64(size)), Unit: unit}, nil
+	}
+	if !IsSizeUnit(unit) {
+		return nil, nil
+	}
+	return fmt.Sprintf("%f%s", size/sizeUnitBytes[unit], strings.ToLower(unit)), nil
 }
 
-// formatTime formats a time. Valid arguments include `UNIX` and `ISO` (case
-// insensitive), or a custom layout layout. If a custom layout is provided, it
-// must be set according to 2006-01-02T15:04:05.999999-07:00.
+// formatTime formats a time. Valid arguments include `UNIX`, `ISO`, and
+// `RELATIVE` / `AGO` (case insensitive), or a custom layout layout. If a custom
+// layout is provided, it must be set according to
+// 2006-01-02T15:04:05.999999-07:00. An optional second argument specifies the
+// time zone to display the time in (e.g. `UTC` or `America/New_York`).
 func (p *FormatParams) formatTime() (interface{}, error) {
+	t, ok := FileTime(p.Attribute, p.Info)
+	if !ok {
+		return "", nil
+	}
+	if len(p.Args) > 1 {
+		loc, err := loadLocation(p.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		t = t.In(loc)
+	}
+
 	switch strings.ToUpper(p.Args[0]) {
+	case "RELATIVE", "AGO":
+		return relativeTime(t, time.Now()), nil
 	case "ISO":
-		return p.Info.ModTime().Format(time.RFC3339), nil
+		return t.Format(time.RFC3339), nil
 	case "UNIX":
-		return p.Info.ModTime().Format(time.UnixDate), nil
+		return t.Format(time.UnixDate), nil
 	default:
-		return p.Info.ModTime().Format(p.Args[0]), nil
+		return t.Format(p.Args[0]), nil
+	}
+}
+
+// formatMode formats a mode. Valid arguments include `OCTAL` (e.g. `0644`) and
+// `SYMBOLIC` (e.g. `-rw-r--r--`), case insensitive.
+func (p *FormatParams) formatMode() (interface{}, error) {
+	mode, ok := p.Value.(os.FileMode)
+	if !ok {
+		return nil, &ErrTypeMismatch{p.Name, p.Attribute, reflect.Uint32,
+			reflect.ValueOf(p.Value).Kind()}
+	}
+	switch strings.ToUpper(p.Args[0]) {
+	case "OCTAL":
+		return fmt.Sprintf("%04o", mode.Perm()), nil
+	case "SYMBOLIC":
+		return mode.String(), nil
 	}
+	return nil, nil
 }
 
 // fullPath returns the full path of the current file. Only supports the
@@ -113,41 +192,171 @@ func (p *FormatParams) shortPath() (interface{}, error) {
 	return p.Info.Name(), nil
 }
 
-// hash applies the provided hash algorithm h with ComputeHash.
-func (p *FormatParams) hash(h hash.Hash) (interface{}, error) {
+// pathElement returns the last element (for BASENAME) or all but the last
+// element (for DIRNAME) of a path-valued attribute, e.g. `DIRNAME(path)` or
+// `BASENAME(symlink_target)`, as returned by filepath.Base and filepath.Dir.
+func (p *FormatParams) pathElement() (interface{}, error) {
+	str, err := toString(p.Name, p.Attribute, p.Value)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToUpper(p.Name) == "BASENAME" {
+		return filepath.Base(str), nil
+	}
+	return filepath.Dir(str), nil
+}
+
+// round rounds a numeric value to the number of decimals in args[0] (0 by
+// default), e.g. `ROUND(depth)` or `ROUND(FORMAT(size, MB), 2)`. The value is
+// either numeric, or a string of a number followed by a unit (like the output
+// of FORMAT for `size`, e.g. `3.051758mb`), in which case the unit is kept
+// (e.g. `3.05mb`).
+func (p *FormatParams) round() (interface{}, error) {
+	decimals := 0
+	if len(p.Args) > 0 && p.Args[0] != "" {
+		var err error
+		if decimals, err = strconv.Atoi(p.Args[0]); err != nil {
+			return nil, fmt.Errorf("invalid decimals: %w", err)
+		}
+		if decimals < 0 {
+			return nil, fmt.Errorf("invalid decimals %s: expected a non-negative integer",
+				p.Args[0])
+		}
+	}
+
+	var number float64
+	var unit string
+	switch v := p.Value.(type) {
+	case int64:
+		number = float64(v)
+	case float64:
+		number = v
+	case string:
+		i := len(v)
+		for i > 0 && !unicode.IsDigit(rune(v[i-1])) && v[i-1] != '.' {
+			i--
+		}
+		var err error
+		if number, err = strconv.ParseFloat(v[:i], 64); err != nil {
+			return nil, &ErrTypeMismatch{p.Name, p.Attribute, reflect.Float64, reflect.String}
+		}
+		unit = v[i:]
+	default:
+		return nil, &ErrTypeMismatch{p.Name, p.Attribute, reflect.Float64,
+			reflect.ValueOf(p.Value).Kind()}
+	}
+	return strconv.FormatFloat(number, 'f', decimals, 64) + unit, nil
+}
+
+// hash applies the hash algorithm name with ComputeHash. The result is
+// truncated to the length provided in args[0], if any.
+func (p *FormatParams) hash(name string, args []string) (interface{}, error) {
 	var (
 		err    error
 		n      int
 		result interface{}
 	)
 
-	if len(p.Args) == 0 || p.Args[0] == "" {
+	newHash := FindHash(name)
+	if newHash == nil {
+		return nil, &ErrUnsupportedFormat{name, p.Attribute}
+	}
+
+	if len(args) == 0 || args[0] == "" {
 		n = defaultHashLength
-	} else if strings.ToUpper(p.Args[0]) == "FULL" {
+	} else if strings.ToUpper(args[0]) == "FULL" {
 		n = -1
-	} else if n, err = strconv.Atoi(p.Args[0]); err != nil {
+	} else if n, err = strconv.Atoi(args[0]); err != nil {
 		return nil, err
 	}
 
-	if result, err = ComputeHash(p.Info, p.Path, h); err != nil {
+	if result, err = ComputeHash(p.Info, p.Path, newHash()); err != nil {
 		return nil, err
 	}
 
 	return truncate(result.(string), n), nil
 }
 
+// Attributes holds every attribute of a file (see DefaultFormatValueAt), in
+// the order they're selected by `SELECT *`.
+var Attributes = []string{
+	"name", "extension", "size", "depth", "mode", "time", "accessed", "changed",
+	"created", "hash", "owner", "group", "uid", "gid", "inode", "nlink", "device",
+	"is_dir", "is_file", "is_symlink", "is_hidden", "is_empty", "is_executable",
+	"has_xattr", "symlink_target", "path", "abspath", "lines", "words", "mime",
+	"kind",
+}
+
 // DefaultFormatValue returns the default format value for the provided
-// attribute attr based on path and info.
-func DefaultFormatValue(attr, path string, info os.FileInfo) (value interface{}, err error) {
+// attribute attr based on path and info, as if the file were a source of the
+// query (i.e. at depth 0). Use DefaultFormatValueAt for a file found at a
+// greater depth.
+func DefaultFormatValue(attr, path string, info os.FileInfo) (interface{}, error) {
+	return DefaultFormatValueAt(attr, path, info, 0)
+}
+
+// DefaultFormatValueAt returns the default format value for the provided
+// attribute attr based on path and info, for a file at the provided depth
+// below its source.
+func DefaultFormatValueAt(attr, path string, info os.FileInfo, depth int64) (value interface{}, err error) {
 	switch attr {
 	case "mode":
 		value = info.Mode()
 	case "name":
 		value = info.Name()
+	case "extension":
+		value = Extension(info.Name())
 	case "size":
 		value = info.Size()
-	case "time":
-		value = info.ModTime().Format(time.Stamp)
+	case "depth":
+		value = depth
+	case "owner":
+		value = Owner(info)
+	case "group":
+		value = Group(info)
+	case "uid":
+		value = UID(info)
+	case "gid":
+		value = GID(info)
+	case "inode":
+		value = Inode(info)
+	case "nlink":
+		value = Nlink(info)
+	case "device":
+		value = Device(info)
+	case "is_dir":
+		value = info.IsDir()
+	case "is_file":
+		value = info.Mode().IsRegular()
+	case "is_symlink":
+		value = IsSymlink(path, info)
+	case "is_hidden":
+		value = IsHidden(info)
+	case "is_empty":
+		value, err = IsEmpty(path, info)
+	case "is_executable":
+		value = IsExecutable(info)
+	case "has_xattr":
+		value, err = HasXattr(path, info)
+	case "symlink_target":
+		value = SymlinkTarget(path, info)
+	case "path":
+		value = RelativePath(path, depth)
+	case "abspath":
+		value, err = AbsolutePath(path)
+	case "lines":
+		value, err = LineCount(path, info, false)
+	case "words":
+		value, err = WordCount(path, info, false)
+	case "mime":
+		value = MIMEType(path, info)
+	case "kind":
+		value = Kind(path, info)
+	case "time", "accessed", "changed", "created":
+		value = ""
+		if t, ok := FileTime(attr, info); ok {
+			value = t.Format(time.Stamp)
+		}
 	case "hash":
 		if value, err = ComputeHash(info, path, FindHash("SHA1")()); value != nil {
 			value = truncate(value.(string), defaultHashLength)
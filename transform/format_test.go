@@ -1,9 +1,13 @@
 package transform
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestTransform_Format(t *testing.T) {
@@ -17,8 +21,163 @@ func TestTransform_Format(t *testing.T) {
 		expected Expected
 	}
 
-	// TODO: Add tests for the time and hash attribute.
 	cases := []Case{
+		{
+			params: &FormatParams{
+				Attribute: "path",
+				Value:     "foo/quuz/fred",
+				Name:      "basename",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("fred"), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "path",
+				Value:     "foo/quuz/fred",
+				Name:      "dirname",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("foo/quuz"), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "path",
+				Value:     "foo/quuz/",
+				Name:      "basename",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("quuz"), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "path",
+				Value:     "foo/quuz/",
+				Name:      "dirname",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("foo/quuz"), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "abspath",
+				Value:     "/",
+				Name:      "basename",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("/"), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "abspath",
+				Value:     "/",
+				Name:      "dirname",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("/"), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "path",
+				Value:     ".",
+				Name:      "dirname",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("."), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "path",
+				Value:     "",
+				Name:      "basename",
+				Args:      []string{},
+			},
+			expected: Expected{val: filepath.FromSlash("."), err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Value:     int64(3),
+				Name:      "dirname",
+				Args:      []string{},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrTypeMismatch{"dirname", "size", reflect.String, reflect.Int64},
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Value:     "3.051758mb",
+				Name:      "round",
+				Args:      []string{"2"},
+			},
+			expected: Expected{val: "3.05mb", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Value:     "1.4 GB",
+				Name:      "round",
+				Args:      []string{},
+			},
+			expected: Expected{val: "1 GB", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "depth",
+				Value:     int64(3),
+				Name:      "round",
+				Args:      []string{"1"},
+			},
+			expected: Expected{val: "3.0", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Value:     2.675,
+				Name:      "round",
+				Args:      []string{"1"},
+			},
+			expected: Expected{val: "2.7", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "name",
+				Value:     "foo",
+				Name:      "round",
+				Args:      []string{},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrTypeMismatch{"round", "name", reflect.Float64, reflect.String},
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "is_dir",
+				Value:     true,
+				Name:      "round",
+				Args:      []string{},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrTypeMismatch{"round", "is_dir", reflect.Float64, reflect.Bool},
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Value:     int64(3),
+				Name:      "round",
+				Args:      []string{"-1"},
+			},
+			expected: Expected{
+				val: nil,
+				err: errors.New("invalid decimals -1: expected a non-negative integer"),
+			},
+		},
 		{
 			params: &FormatParams{
 				Attribute: "size",
@@ -29,7 +188,57 @@ func TestTransform_Format(t *testing.T) {
 				Args:      []string{"kb"},
 			},
 			expected: Expected{
-				val: fmt.Sprintf("%fkb", float64(300)/(1<<10)),
+				val: fmt.Sprintf("%fkb", float64(300)/1e3),
+				err: nil,
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     int64(1400000000),
+				Name:      "format",
+				Args:      []string{"human"},
+			},
+			expected: Expected{val: "1.4 GB", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     int64(1400000000),
+				Name:      "format",
+				Args:      []string{"auto"},
+			},
+			expected: Expected{
+				val: Size{Bytes: 1400000000, Human: "1.4 GB", Unit: "GB"},
+				err: nil,
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     int64(512),
+				Name:      "format",
+				Args:      []string{"AUTO"},
+			},
+			expected: Expected{val: Size{Bytes: 512, Human: "512 B", Unit: "B"}, err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     int64(300),
+				Name:      "format",
+				Args:      []string{"kib"},
+			},
+			expected: Expected{
+				val: fmt.Sprintf("%fkib", float64(300)/(1<<10)),
 				err: nil,
 			},
 		},
@@ -80,15 +289,292 @@ func TestTransform_Format(t *testing.T) {
 			},
 			expected: Expected{val: "path", err: nil},
 		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     int64(300),
+				Name:      "upper",
+				Args:      []string{},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrTypeMismatch{"upper", "size", reflect.String, reflect.Int64},
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     "300",
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: Expected{
+				val: fmt.Sprintf("%fkb", float64(300)/1e3),
+				err: nil,
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     float64(1500),
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: Expected{
+				val: fmt.Sprintf("%fkb", float64(1.5)),
+				err: nil,
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     "1400000000",
+				Name:      "format",
+				Args:      []string{"human"},
+			},
+			expected: Expected{val: "1.4 GB", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     "foo",
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrTypeMismatch{"format", "size", reflect.Int64, reflect.String},
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "size",
+				Path:      "path",
+				Info:      nil,
+				Value:     true,
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrTypeMismatch{"format", "size", reflect.Int64, reflect.Bool},
+			},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "mode",
+				Path:      "path",
+				Info:      nil,
+				Value:     os.FileMode(0644),
+				Name:      "format",
+				Args:      []string{"octal"},
+			},
+			expected: Expected{val: "0644", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "mode",
+				Path:      "path",
+				Info:      nil,
+				Value:     os.ModeDir | 0755,
+				Name:      "format",
+				Args:      []string{"octal"},
+			},
+			expected: Expected{val: "0755", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "mode",
+				Path:      "path",
+				Info:      nil,
+				Value:     os.FileMode(0644),
+				Name:      "format",
+				Args:      []string{"symbolic"},
+			},
+			expected: Expected{val: "-rw-r--r--", err: nil},
+		},
+		{
+			params: &FormatParams{
+				Attribute: "mode",
+				Path:      "path",
+				Info:      nil,
+				Value:     os.FileMode(0644),
+				Name:      "format",
+				Args:      []string{"hex"},
+			},
+			expected: Expected{
+				val: nil,
+				err: &ErrUnsupportedFormat{"hex", "mode"},
+			},
+		},
 	}
 
 	for _, c := range cases {
 		val, err := Format(c.params)
 		if !(reflect.DeepEqual(val, c.expected.val) &&
-			reflect.DeepEqual(err, c.expected.err)) {
+			reflect.DeepEqual(causeOf(t, err), c.expected.err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v",
+				c.expected.val, c.expected.err,
+				val, err)
+		}
+	}
+}
+
+func TestTransform_FormatTime(t *testing.T) {
+	info, err := os.Stat("../testdata/baz")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got: %s", err.Error())
+	}
+
+	type Case struct {
+		layout   string
+		zone     string
+		expected string
+	}
+
+	cases := []Case{
+		{layout: "iso", expected: info.ModTime().Format(time.RFC3339)},
+		{layout: "unix", expected: info.ModTime().Format(time.UnixDate)},
+		{layout: "2006-01-02", expected: info.ModTime().Format("2006-01-02")},
+		{
+			layout:   "iso",
+			zone:     "utc",
+			expected: info.ModTime().UTC().Format(time.RFC3339),
+		},
+	}
+
+	for _, c := range cases {
+		args := []string{c.layout}
+		if c.zone != "" {
+			args = append(args, c.zone)
+		}
+		val, err := Format(&FormatParams{
+			Attribute: "time",
+			Path:      "../testdata/baz",
+			Info:      info,
+			Value:     info.ModTime().Format(time.Stamp),
+			Name:      "format",
+			Args:      args,
+		})
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got: %s", err.Error())
+		}
+		if !reflect.DeepEqual(c.expected, val) {
+			t.Fatalf("\nExpected: %v\n     Got: %v", c.expected, val)
+		}
+	}
+}
+
+func TestTransform_FormatHash(t *testing.T) {
+	info, err := os.Stat("../testdata/baz")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got: %s", err.Error())
+	}
+
+	type Expected struct {
+		val interface{}
+		err error
+	}
+
+	type Case struct {
+		name     string
+		args     []string
+		expected Expected
+	}
+
+	cases := []Case{
+		{name: "sha1", args: []string{}, expected: Expected{val: "da39a3e"}},
+		{name: "sha1", args: []string{"10"}, expected: Expected{val: "da39a3ee5e"}},
+		{
+			name:     "md5",
+			args:     []string{"full"},
+			expected: Expected{val: "d41d8cd98f00b204e9800998ecf8427e"},
+		},
+		{name: "hash", args: []string{"sha256"}, expected: Expected{val: "e3b0c44"}},
+		{name: "hash", args: []string{"sha256", "3"}, expected: Expected{val: "e3b"}},
+		{
+			name:     "hash",
+			args:     []string{"crc32"},
+			expected: Expected{err: &ErrUnsupportedFormat{"crc32", "hash"}},
+		},
+		{
+			name:     "hash",
+			args:     []string{},
+			expected: Expected{err: &ErrArgumentCount{"hash", 1, 0}},
+		},
+	}
+
+	for _, c := range cases {
+		val, err := Format(&FormatParams{
+			Attribute: "hash",
+			Path:      "../testdata/baz",
+			Info:      info,
+			Value:     "da39a3e",
+			Name:      c.name,
+			Args:      c.args,
+		})
+		if !(reflect.DeepEqual(val, c.expected.val) &&
+			reflect.DeepEqual(causeOf(t, err), c.expected.err)) {
 			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v",
 				c.expected.val, c.expected.err,
 				val, err)
 		}
 	}
 }
+
+func TestTransform_DefaultFormatValue(t *testing.T) {
+	path := filepath.Join("..", "testdata", "foo", "quuz")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got: %s", err.Error())
+	}
+
+	type Case struct {
+		attr     string
+		depth    int64
+		expected interface{}
+	}
+
+	cases := []Case{
+		{attr: "name", depth: 0, expected: "quuz"},
+		{attr: "depth", depth: 0, expected: int64(0)},
+		{attr: "path", depth: 0, expected: "."},
+		{attr: "depth", depth: 2, expected: int64(2)},
+		{attr: "path", depth: 2, expected: filepath.Join("foo", "quuz")},
+	}
+
+	for _, c := range cases {
+		val, err := DefaultFormatValueAt(c.attr, path, info, c.depth)
+		if err != nil || !reflect.DeepEqual(val, c.expected) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, nil, val, err)
+		}
+		if c.depth != 0 {
+			continue
+		}
+		val, err = DefaultFormatValue(c.attr, path, info)
+		if err != nil || !reflect.DeepEqual(val, c.expected) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v", c.expected, nil, val, err)
+		}
+	}
+
+	// Every attribute has a default value, and only those do.
+	for _, attr := range Attributes {
+		if _, err := DefaultFormatValueAt(attr, path, info, 2); err != nil {
+			t.Fatalf("%s\nExpected no error\n     Got %v", attr, err)
+		}
+	}
+	expected := errors.New("unknown attribute content")
+	if _, err := DefaultFormatValue("content", path, info); !reflect.DeepEqual(expected, err) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+}
@@ -0,0 +1,8 @@
+package transform
+
+import "os"
+
+// IsHidden returns true iff the file is hidden. On Windows, this is the
+// file's hidden attribute, elsewhere it's a name beginning with a dot (other
+// than `.` and `..` themselves).
+func IsHidden(info os.FileInfo) bool { return isHidden(info) }
@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package transform
+
+import "os"
+
+// isHidden returns true iff the file's name begins with a dot, excluding the
+// special names `.` and `..`.
+func isHidden(info os.FileInfo) bool {
+	name := info.Name()
+	return len(name) > 1 && name[0] == '.' && name != ".."
+}
@@ -0,0 +1,49 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestHidden_IsHidden(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hidden files are marked by an attribute on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	type Case struct {
+		path     string
+		expected bool
+	}
+
+	cases := []Case{
+		{path: filepath.Join(dir, ".foo"), expected: true},
+		{path: filepath.Join(dir, "foo"), expected: false},
+		{path: filepath.Join(dir, "foo.bar"), expected: false},
+		{path: ".", expected: false},
+		{path: "..", expected: false},
+	}
+
+	for _, c := range cases {
+		if c.path != "." && c.path != ".." {
+			if err := ioutil.WriteFile(c.path, nil, 0644); err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+		}
+		info, err := os.Lstat(c.path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual := IsHidden(info); actual != c.expected {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", c.path, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package transform
+
+import (
+	"os"
+	"syscall"
+)
+
+// isHidden returns true iff the file has the hidden attribute.
+func isHidden(info os.FileInfo) bool {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false
+	}
+	return data.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
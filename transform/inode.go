@@ -0,0 +1,33 @@
+package transform
+
+import "os"
+
+// Inode returns the inode number of the file, or 0 if it isn't available on
+// this platform.
+func Inode(info os.FileInfo) int64 {
+	ino, _, ok := inodeInfo(info)
+	if !ok {
+		return 0
+	}
+	return int64(ino)
+}
+
+// Nlink returns the number of hard links to the file, or 0 if it isn't
+// available on this platform.
+func Nlink(info os.FileInfo) int64 {
+	_, nlink, ok := inodeInfo(info)
+	if !ok {
+		return 0
+	}
+	return int64(nlink)
+}
+
+// Device returns the id of the device (i.e. filesystem) containing the file,
+// or 0 if it isn't available on this platform.
+func Device(info os.FileInfo) int64 {
+	dev, ok := deviceID(info)
+	if !ok {
+		return 0
+	}
+	return int64(dev)
+}
@@ -0,0 +1,16 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package transform
+
+import "os"
+
+// inodeInfo reports that inodes aren't available on this platform.
+func inodeInfo(info os.FileInfo) (ino, nlink uint64, ok bool) {
+	return 0, 0, false
+}
+
+// deviceID reports that device ids aren't available on this platform.
+func deviceID(info os.FileInfo) (dev uint64, ok bool) {
+	return 0, false
+}
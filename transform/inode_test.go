@@ -0,0 +1,85 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInode_NoSys(t *testing.T) {
+	info := noSysInfo{}
+	if inode := Inode(info); inode != 0 {
+		t.Fatalf("\nExpected: %d\n     Got: %d", 0, inode)
+	}
+	if nlink := Nlink(info); nlink != 0 {
+		t.Fatalf("\nExpected: %d\n     Got: %d", 0, nlink)
+	}
+	if device := Device(info); device != 0 {
+		t.Fatalf("\nExpected: %d\n     Got: %d", 0, device)
+	}
+}
+
+func TestInode_HardLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inodes aren't available on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	other := filepath.Join(dir, "other")
+	if err := ioutil.WriteFile(other, nil, 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Link(file, link); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	stat := func(path string) os.FileInfo {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		return info
+	}
+
+	if inode := Inode(stat(file)); inode == 0 {
+		t.Fatalf("\nExpected a non-zero inode\n     Got: %d", inode)
+	}
+	if expected, actual := Inode(stat(file)), Inode(stat(link)); expected != actual {
+		t.Fatalf("\nExpected: %d\n     Got: %d", expected, actual)
+	}
+	if Inode(stat(file)) == Inode(stat(other)) {
+		t.Fatalf("\nExpected distinct inodes\n     Got: %d", Inode(stat(other)))
+	}
+	if expected, actual := Device(stat(dir)), Device(stat(other)); expected != actual {
+		t.Fatalf("\nExpected: %d\n     Got: %d", expected, actual)
+	}
+
+	type Case struct {
+		path     string
+		expected int64
+	}
+
+	cases := []Case{
+		{path: file, expected: 2},
+		{path: link, expected: 2},
+		{path: other, expected: 1},
+	}
+
+	for _, c := range cases {
+		if actual := Nlink(stat(c.path)); actual != c.expected {
+			t.Fatalf("%s\nExpected: %d\n     Got: %d", c.path, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package transform
+
+import (
+	"os"
+	"syscall"
+)
+
+// inodeInfo returns the inode number and hard link count of the file. The
+// width of both fields varies by platform.
+func inodeInfo(info os.FileInfo) (ino, nlink uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(stat.Ino), uint64(stat.Nlink), true
+}
+
+// deviceID returns the id of the device containing the file. The width (and
+// signedness) of the field varies by platform.
+func deviceID(info os.FileInfo) (dev uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
@@ -0,0 +1,34 @@
+package transform
+
+import "os"
+
+// IsSymlink returns true iff the file at path is a symbolic link. The link
+// itself is checked, even if info is that of the link's target.
+func IsSymlink(path string, info os.FileInfo) bool {
+	if info.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	if path == "" {
+		return false
+	}
+	link, err := lstat(path, info)
+	return err == nil && link.Mode()&os.ModeSymlink != 0
+}
+
+// SymlinkTarget returns the target of the symbolic link at path, as written
+// in the link (so the target of a broken link is still returned). Returns an
+// empty string if the file isn't a symbolic link.
+func SymlinkTarget(path string, info os.FileInfo) string {
+	if !IsSymlink(path, info) {
+		return ""
+	}
+	readlink := os.Readlink
+	if v, ok := info.(virtualFile); ok {
+		readlink = func(string) (string, error) { return v.Readlink() }
+	}
+	target, err := readlink(path)
+	if err != nil {
+		return ""
+	}
+	return target
+}
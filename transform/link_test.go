@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLink_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require elevated privileges on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("file", link); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	broken := filepath.Join(dir, "broken")
+	if err := os.Symlink("nowhere", broken); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Case struct {
+		path    string
+		stat    func(string) (os.FileInfo, error)
+		symlink bool
+		target  string
+	}
+
+	cases := []Case{
+		{path: file, stat: os.Lstat, symlink: false, target: ""},
+		{path: link, stat: os.Lstat, symlink: true, target: "file"},
+		// The link is still detected when info is that of its target.
+		{path: link, stat: os.Stat, symlink: true, target: "file"},
+		{path: broken, stat: os.Lstat, symlink: true, target: "nowhere"},
+	}
+
+	for _, c := range cases {
+		info, err := c.stat(c.path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual := IsSymlink(c.path, info); actual != c.symlink {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", c.path, c.symlink, actual)
+		}
+		if actual := SymlinkTarget(c.path, info); actual != c.target {
+			t.Fatalf("%s\nExpected: %q\n     Got: %q", c.path, c.target, actual)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package transform
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLen is the number of leading bytes used to detect a file's MIME type
+// (see http.DetectContentType).
+const sniffLen = 512
+
+// defaultMIME is the MIME type of files whose type can't be detected.
+const defaultMIME = "application/octet-stream"
+
+// archiveMIMEs holds the detectable MIME types of archives.
+var archiveMIMEs = map[string]bool{
+	"application/zip":              true,
+	"application/x-gzip":           true,
+	"application/x-rar-compressed": true,
+}
+
+// MIMEType returns the MIME type of the file located at path (without any
+// parameters, e.g. `text/plain` rather than `text/plain; charset=utf-8`),
+// which is detected from its first 512 bytes. Directories are
+// `inode/directory`, and files which are empty or can't be read are
+// `application/octet-stream`.
+func MIMEType(path string, info os.FileInfo) string {
+	if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		var err error
+		if info, err = stat(path, info); err != nil {
+			return defaultMIME
+		}
+	}
+	if info.IsDir() {
+		return "inode/directory"
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return defaultMIME
+	}
+
+	f, err := open(path, info)
+	if err != nil {
+		return defaultMIME
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return defaultMIME
+	}
+	mime := http.DetectContentType(buf[:n])
+	if i := strings.IndexByte(mime, ';'); i != -1 {
+		mime = strings.TrimSpace(mime[:i])
+	}
+	return mime
+}
+
+// Kind returns the kind of the file located at path based on its MIME type:
+// one of `image`, `video`, `audio`, `text`, `archive`, or `other`.
+func Kind(path string, info os.FileInfo) string {
+	mime := MIMEType(path, info)
+	if archiveMIMEs[mime] {
+		return "archive"
+	}
+	switch kind := mime[:strings.IndexByte(mime, '/')]; kind {
+	case "image", "video", "audio", "text":
+		return kind
+	}
+	return "other"
+}
@@ -0,0 +1,52 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMIME_MIMEType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	type Case struct {
+		name    string
+		content string
+		mime    string
+		kind    string
+	}
+
+	cases := []Case{
+		{name: "text", content: "foo\n", mime: "text/plain", kind: "text"},
+		{name: "html", content: "<html></html>", mime: "text/html", kind: "text"},
+		{name: "png", content: "\x89PNG\r\n\x1a\n", mime: "image/png", kind: "image"},
+		{name: "zip", content: "PK\x03\x04", mime: "application/zip", kind: "archive"},
+		{name: "binary", content: "\x00\x01", mime: "application/octet-stream", kind: "other"},
+		{name: "empty", content: "", mime: "application/octet-stream", kind: "other"},
+		{name: ".", mime: "inode/directory", kind: "other"},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		if c.name != "." {
+			if err := ioutil.WriteFile(path, []byte(c.content), 0644); err != nil {
+				t.Fatalf("\nExpected no error\n     Got %v", err)
+			}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual := MIMEType(path, info); actual != c.mime {
+			t.Fatalf("%s\nExpected: %s\n     Got: %s", c.name, c.mime, actual)
+		}
+		if actual := Kind(path, info); actual != c.kind {
+			t.Fatalf("%s\nExpected: %s\n     Got: %s", c.name, c.kind, actual)
+		}
+	}
+}
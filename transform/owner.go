@@ -0,0 +1,88 @@
+package transform
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+// owners caches the user and group names for each looked-up id, since the
+// same handful of ids are typically looked up for every file.
+var owners = struct {
+	sync.Mutex
+	users  map[uint32]string
+	groups map[uint32]string
+}{users: make(map[uint32]string), groups: make(map[uint32]string)}
+
+// UID returns the numeric user id of the file's owner, or 0 if it isn't
+// available on this platform.
+func UID(info os.FileInfo) int64 {
+	uid, _, ok := ownerIDs(info)
+	if !ok {
+		return 0
+	}
+	return int64(uid)
+}
+
+// GID returns the numeric group id of the file's group, or 0 if it isn't
+// available on this platform.
+func GID(info os.FileInfo) int64 {
+	_, gid, ok := ownerIDs(info)
+	if !ok {
+		return 0
+	}
+	return int64(gid)
+}
+
+// Owner returns the name of the file's owner, falling back to the numeric
+// user id if it can't be resolved. Returns an empty string if the owner isn't
+// available on this platform.
+func Owner(info os.FileInfo) string {
+	uid, _, ok := ownerIDs(info)
+	if !ok {
+		return ""
+	}
+	return lookupName(owners.users, uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+// Group returns the name of the file's group, falling back to the numeric
+// group id if it can't be resolved. Returns an empty string if the group
+// isn't available on this platform.
+func Group(info os.FileInfo) string {
+	_, gid, ok := ownerIDs(info)
+	if !ok {
+		return ""
+	}
+	return lookupName(owners.groups, gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+// lookupName returns the cached name for id, resolving it with lookup on a
+// cache miss.
+func lookupName(cache map[uint32]string, id uint32,
+	lookup func(string) (string, error)) string {
+	owners.Lock()
+	defer owners.Unlock()
+
+	if name, ok := cache[id]; ok {
+		return name
+	}
+	name, err := lookup(strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		name = strconv.FormatUint(uint64(id), 10)
+	}
+	cache[id] = name
+	return name
+}
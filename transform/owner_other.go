@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package transform
+
+import "os"
+
+// ownerIDs reports that file ownership isn't available on this platform.
+func ownerIDs(info os.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
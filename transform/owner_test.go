@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// noSysInfo is an os.FileInfo without any underlying data source.
+type noSysInfo struct{}
+
+func (noSysInfo) Name() string       { return "foo" }
+func (noSysInfo) Size() int64        { return 0 }
+func (noSysInfo) Mode() os.FileMode  { return 0 }
+func (noSysInfo) ModTime() time.Time { return time.Time{} }
+func (noSysInfo) IsDir() bool        { return false }
+func (noSysInfo) Sys() interface{}   { return nil }
+
+func TestOwner_NoSys(t *testing.T) {
+	info := noSysInfo{}
+	if uid := UID(info); uid != 0 {
+		t.Fatalf("\nExpected: %d\n     Got: %d", 0, uid)
+	}
+	if gid := GID(info); gid != 0 {
+		t.Fatalf("\nExpected: %d\n     Got: %d", 0, gid)
+	}
+	if owner := Owner(info); owner != "" {
+		t.Fatalf("\nExpected: %q\n     Got: %q", "", owner)
+	}
+	if group := Group(info); group != "" {
+		t.Fatalf("\nExpected: %q\n     Got: %q", "", group)
+	}
+}
+
+func TestOwner_File(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file ownership isn't available on windows")
+	}
+
+	info, err := os.Stat("../testdata/baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, expected := UID(info), int64(os.Getuid()); uid != expected {
+		t.Fatalf("\nExpected: %d\n     Got: %d", expected, uid)
+	}
+	if owner := Owner(info); owner == "" {
+		t.Fatalf("\nExpected a user name or id\n     Got: %q", owner)
+	}
+	if group := Group(info); group == "" {
+		t.Fatalf("\nExpected a group name or id\n     Got: %q", group)
+	}
+}
+
+func TestOwner_LookupName(t *testing.T) {
+	cache := make(map[uint32]string)
+	lookup := func(id string) (string, error) {
+		return "", strconv.ErrSyntax
+	}
+
+	if name := lookupName(cache, 12345, lookup); name != "12345" {
+		t.Fatalf("\nExpected: %s\n     Got: %s", "12345", name)
+	}
+	if name, ok := cache[12345]; !ok || name != "12345" {
+		t.Fatalf("\nExpected: %s to be cached\n     Got: %q", "12345", name)
+	}
+}
@@ -1,12 +1,14 @@
 package transform
 
 import (
-	"hash"
-	"os"
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // ParseParams holds the params for a parse-modifier function.
@@ -20,37 +22,71 @@ type ParseParams struct {
 
 // Parse runs the associated modifier function for the provided parameters.
 // Depending on the type of p.Value, we may recursively run this method
-// on every element of the structure.
+// on every element of the structure. Neither p nor p.Value are modified, so
+// Parse is safe to call concurrently with shared parameters. Each error is an
+// ErrModifier.
 //
 // We're using reflect _quite_ heavily for this, meaning it's kind of unsafe,
 // it'd be great if we could find another solution while keeping it as
 // abstract as it is.
 func Parse(p *ParseParams) (val interface{}, err error) {
-	kind := reflect.TypeOf(p.Value).Kind()
+	defer func() { err = wrapError(p.Name, p.Attribute, err) }()
 
-	// If we have a slice/array, recursively run Parse on each element.
+	kind := reflect.ValueOf(p.Value).Kind()
+
+	// If we have a slice/array, recursively run Parse on each element and
+	// create a new slice/array out of the return values. If the modifier
+	// changes the type of the elements (e.g. `FORMAT(size, KB) IN (1, 2)`
+	// parses each one to a number of bytes), they're collected in a set for
+	// the IN operator instead (see SetKey).
 	if kind == reflect.Slice || kind == reflect.Array {
 		s := reflect.ValueOf(p.Value)
+		values := make([]reflect.Value, s.Len())
+		assignable := true
 		for i := 0; i < s.Len(); i++ {
-			p.Value = s.Index(i).Interface()
-			if val, err = Parse(p); err != nil {
-				return nil, err
+			if val, err = p.parseElement(s.Index(i).Interface()); err != nil {
+				return nil, atElement(err, fmt.Sprintf("index %d", i))
+			}
+			values[i] = reflect.ValueOf(val)
+			assignable = assignable && values[i].Type().AssignableTo(s.Type().Elem())
+		}
+
+		if !assignable {
+			set := make(map[interface{}]bool, len(values))
+			for _, v := range values {
+				set[SetKey(v.Interface())] = true
 			}
-			s.Index(i).Set(reflect.ValueOf(val))
+			return set, nil
+		}
+
+		result := reflect.New(s.Type()).Elem()
+		if kind == reflect.Slice {
+			result = reflect.MakeSlice(s.Type(), s.Len(), s.Len())
 		}
-		return s.Interface(), nil
+		for i, v := range values {
+			result.Index(i).Set(v)
+		}
+		return result.Interface(), nil
 	}
 
 	// If we have a map, recursively run Parse on each *key* and create a new
-	// map out of the return values.
+	// map out of the return values, carrying over the original map values.
 	if kind == reflect.Map {
-		result := reflect.MakeMap(reflect.TypeOf(p.Value))
-		for _, key := range reflect.ValueOf(p.Value).MapKeys() {
-			p.Value = key.Interface()
-			if val, err = Parse(p); err != nil {
-				return nil, err
+		m := reflect.ValueOf(p.Value)
+		result := reflect.MakeMap(m.Type())
+		for _, key := range m.MapKeys() {
+			if val, err = p.parseElement(key.Interface()); err != nil {
+				return nil, atElement(err, fmt.Sprintf("key %v", key.Interface()))
+			}
+			if m.Type().Key().Kind() == reflect.Interface {
+				val = SetKey(val)
 			}
-			result.SetMapIndex(reflect.ValueOf(val), reflect.ValueOf(true))
+			v := reflect.ValueOf(val)
+			if !v.Type().AssignableTo(m.Type().Key()) {
+				return nil, &ErrTypeMismatch{p.Name, p.Attribute,
+					m.Type().Key().Kind(), v.Kind()}
+			}
+			result.SetMapIndex(v, m.MapIndex(key))
 		}
 		return result.Interface(), nil
 	}
@@ -59,31 +95,69 @@ func Parse(p *ParseParams) (val interface{}, err error) {
 	switch strings.ToUpper(p.Name) {
 	case "FORMAT":
 		val, err = p.format()
-	case "UPPER":
-		val = upper(p.Value.(string))
-	case "LOWER":
-		val = lower(p.Value.(string))
-	case "SHA1":
-		val, err = p.hash(FindHash(p.Name)())
+	case "HASH":
+		if len(p.Args) == 0 {
+			err = &ErrArgumentCount{p.Name, 1, 0}
+		} else {
+			val, err = p.hash(p.Args[0])
+		}
+	case "MD5", "SHA1", "SHA256", "SHA512":
+		val, err = p.hash(p.Name)
+	default:
+		if val, err = formatString(p.Name, p.Attribute, p.Value, p.Args); val == nil && err == nil {
+			val, err = runCustomModifier(p)
+		}
 	}
 
 	if err != nil {
 		return nil, err
 	}
 	if val == nil {
-		return nil, &ErrNotImplemented{p.Name, p.Attribute}
+		return nil, &ErrNotImplemented{Name: p.Name, Attribute: p.Attribute}
 	}
 	return val, nil
 }
 
+// SetKey returns value as a key of a set of values for the IN operator.
+// Integral numbers are converted to int64, since numeric attributes (e.g.
+// `size`) are looked up in the set as an int64. Fractions are kept as is, so
+// they never match (e.g. `depth IN (1.9)`).
+func SetKey(value interface{}) interface{} {
+	if f, ok := value.(float64); ok && f == math.Trunc(f) {
+		return int64(f)
+	}
+	return value
+}
+
+// parseElement runs Parse on a copy of p with its value replaced by value.
+func (p *ParseParams) parseElement(value interface{}) (interface{}, error) {
+	params := *p
+	params.Value = value
+	return Parse(&params)
+}
+
+// atElement records element as the location of err, if err is an
+// ErrNotImplemented that hasn't already been located (i.e. by a nested
+// collection).
+func atElement(err error, element string) error {
+	var e *ErrNotImplemented
+	if errors.As(err, &e) && e.Element == "" {
+		e.Element = element
+	}
+	return err
+}
+
 // format runs the correct format function based on the provided attribute.
 func (p *ParseParams) format() (val interface{}, err error) {
 	switch p.Attribute {
 	case "name":
-		val = formatName(p.Args[0], p.Value.(string))
+		var str string
+		if str, err = toString(p.Name, p.Attribute, p.Value); err == nil {
+			val = formatName(p.Args[0], str)
+		}
 	case "size":
 		val, err = p.formatSize()
-	case "time":
+	case "time", "accessed", "changed", "created":
 		val, err = p.formatTime()
 	}
 	if err != nil {
@@ -95,43 +169,86 @@ func (p *ParseParams) format() (val interface{}, err error) {
 	return val, nil
 }
 
-// formatSize formats the size attribute. Valid arguments include `B`, `KB`,
-// `MB`, and `GB` (case insensitive).
+// formatSize formats the size attribute. Valid arguments include any size
+// unit (see ParseSize). The value is either a string of a decimal number (e.g.
+// `3` in `FORMAT(size, mb) > 3`) or already numeric.
 func (p *ParseParams) formatSize() (interface{}, error) {
-	size, err := strconv.ParseFloat(p.Value.(string), 64)
+	if !IsSizeUnit(p.Args[0]) {
+		return nil, nil
+	}
+	if str, ok := p.Value.(string); ok {
+		return parseSize(str, p.Args[0])
+	}
+	size, err := toNumber(p.Name, p.Attribute, p.Value)
 	if err != nil {
 		return nil, err
 	}
-	switch strings.ToUpper(p.Args[0]) {
-	case "B":
-		size *= 1
-	case "KB":
-		size *= 1 << 10
-	case "MB":
-		size *= 1 << 20
-	case "GB":
-		size *= 1 << 30
-	default:
-		return nil, nil
+	return size * sizeUnitBytes[strings.ToUpper(p.Args[0])], nil
+}
+
+// ParseSize parses a size literal, i.e. a number optionally followed by a unit
+// (with or without a space before it, e.g. `1mb` or `1.5 GiB`), and returns
+// the size in bytes. Valid units include `B`, the decimal units `KB`, `MB`,
+// `GB`, `TB`, and `PB` (powers of 1000), and the binary units `KIB`, `MIB`,
+// `GIB`, `TIB`, and `PIB` (powers of 1024), all case insensitive. A number
+// without a unit is in bytes.
+func ParseSize(literal string) (float64, error) {
+	literal = strings.TrimSpace(literal)
+	i := len(literal)
+	for i > 0 && unicode.IsLetter(rune(literal[i-1])) {
+		i--
+	}
+	number, unit := strings.TrimSpace(literal[:i]), literal[i:]
+	if unit == "" {
+		unit = "B"
+	}
+	if !IsSizeUnit(unit) {
+		return 0, fmt.Errorf("invalid size %s: unknown unit %s", literal, unit)
+	}
+	return parseSize(number, unit)
+}
+
+// parseSize returns the number of bytes in number (a decimal number) of the
+// size unit unit.
+func parseSize(number, unit string) (float64, error) {
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, err
 	}
-	return size, nil
+	return size * sizeUnitBytes[strings.ToUpper(unit)], nil
 }
 
 // formatTime formats the time attribute. Valid arguments include `ISO`,
 // `UNIX`, (case insensitive) or a custom layout. If a custom layout is
 // provided, it must be set according to 2006-01-02T15:04:05.999999-07:00.
+//
+// An optional second argument specifies the time zone of times which don't
+// include one (UTC by default).
+//
+// Unlike FormatParams.formatTime, this returns the parsed time.Time rather
+// than a string, since the result is compared against each file's time (e.g.
+// its modification time).
 func (p *ParseParams) formatTime() (interface{}, error) {
-	var (
-		t   time.Time
-		err error
-	)
+	str, err := toString(p.Name, p.Attribute, p.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.UTC
+	if len(p.Args) > 1 {
+		if loc, err = loadLocation(p.Args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	var t time.Time
 	switch strings.ToUpper(p.Args[0]) {
 	case "ISO":
-		t, err = time.Parse(time.RFC3339, p.Value.(string))
+		t, err = time.ParseInLocation(time.RFC3339, str, loc)
 	case "UNIX":
-		t, err = time.Parse(time.UnixDate, p.Value.(string))
+		t, err = time.ParseInLocation(time.UnixDate, str, loc)
 	default:
-		t, err = time.Parse(p.Args[0], p.Value.(string))
+		t, err = time.ParseInLocation(p.Args[0], str, loc)
 	}
 	if err != nil {
 		return nil, err
@@ -139,10 +256,11 @@ func (p *ParseParams) formatTime() (interface{}, error) {
 	return t, nil
 }
 
-func (p *ParseParams) hash(h hash.Hash) (interface{}, error) {
-	info, err := os.Stat(p.Value.(string))
-	if err != nil {
-		return nil, err
+// hash validates the hash algorithm name. The hash is computed for each file
+// when the condition is evaluated, so the value itself is left unchanged.
+func (p *ParseParams) hash(name string) (interface{}, error) {
+	if FindHash(name) == nil {
+		return nil, &ErrUnsupportedFormat{name, p.Attribute}
 	}
-	return ComputeHash(info, p.Value.(string), h)
+	return p.Value, nil
 }
@@ -1,8 +1,13 @@
 package transform
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type ParseOutput struct {
@@ -17,15 +22,530 @@ type ParseCase struct {
 
 func TestTransform_Parse(t *testing.T) {
 	// TODO: Complete this.
-	cases := []ParseCase{}
+	cases := []ParseCase{
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     []string{"Foo", "bAR"},
+				Name:      "lower",
+				Args:      []string{},
+			},
+			expected: ParseOutput{val: []string{"foo", "bar"}, err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     map[string]int{"foo": 1, "bar": 2},
+				Name:      "upper",
+				Args:      []string{},
+			},
+			expected: ParseOutput{val: map[string]int{"FOO": 1, "BAR": 2}, err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     map[interface{}]bool{"1": true, "2": false},
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: ParseOutput{
+				val: map[interface{}]bool{int64(1000): true, int64(2000): false},
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     [2]string{"Foo", "bAR"},
+				Name:      "upper",
+				Args:      []string{},
+			},
+			expected: ParseOutput{val: [2]string{"FOO", "BAR"}, err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     []string{"1", "2.5"},
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: ParseOutput{
+				val: map[interface{}]bool{int64(1000): true, int64(2500): true},
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     []string{"foo", "bar"},
+				Name:      "foo",
+				Args:      []string{},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrNotImplemented{Name: "foo", Attribute: "name", Element: "index 0"},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     [][]string{{"foo"}, {"bar", "baz"}},
+				Name:      "foo",
+				Args:      []string{},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrNotImplemented{Name: "foo", Attribute: "name", Element: "index 0"},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     map[interface{}]bool{"foo": true},
+				Name:      "foo",
+				Args:      []string{},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrNotImplemented{Name: "foo", Attribute: "name", Element: "key foo"},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     "foo",
+				Name:      "foo",
+				Args:      []string{},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrNotImplemented{Name: "foo", Attribute: "name"},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     int64(300),
+				Name:      "upper",
+				Args:      []string{},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrTypeMismatch{"upper", "size", reflect.String, reflect.Int64},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "name",
+				Value:     []interface{}{"foo", 1},
+				Name:      "upper",
+				Args:      []string{},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrTypeMismatch{"upper", "name", reflect.String, reflect.Int},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     map[interface{}]bool{float64(1): true},
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: ParseOutput{
+				val: map[interface{}]bool{int64(1000): true},
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "time",
+				Value:     nil,
+				Name:      "format",
+				Args:      []string{"iso"},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrTypeMismatch{"format", "time", reflect.String, reflect.Invalid},
+			},
+		},
+	}
 
 	for _, c := range cases {
 		val, err := Parse(c.params)
 		if !(reflect.DeepEqual(val, c.expected.val) &&
-			reflect.DeepEqual(err, c.expected.err)) {
+			reflect.DeepEqual(causeOf(t, err), c.expected.err)) {
 			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v",
 				c.expected.val, c.expected.err,
 				val, err)
 		}
 	}
 }
+
+func TestTransform_ParseConcurrent(t *testing.T) {
+	template := ParseParams{
+		Attribute: "name",
+		Value:     []string{"Foo", "bAR"},
+		Args:      []string{},
+	}
+
+	expected := map[string][]string{
+		"upper": {"FOO", "BAR"},
+		"lower": {"foo", "bar"},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*len(expected))
+	for i := 0; i < 2; i++ {
+		for name, result := range expected {
+			wg.Add(1)
+			go func(name string, result []string) {
+				defer wg.Done()
+				params := template
+				params.Name = name
+				val, err := Parse(&params)
+				if err != nil {
+					errs <- err
+				} else if !reflect.DeepEqual(val, result) {
+					errs <- fmt.Errorf("\nExpected: %v\n     Got: %v", result, val)
+				}
+			}(name, result)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(template.Value, []string{"Foo", "bAR"}) {
+		t.Fatalf("\nExpected template to be unchanged\n     Got: %v", template.Value)
+	}
+}
+
+func TestTransform_ParseSize(t *testing.T) {
+	cases := []ParseCase{
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     int64(3),
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: ParseOutput{val: float64(3000), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     1.5,
+				Name:      "format",
+				Args:      []string{"kib"},
+			},
+			expected: ParseOutput{val: float64(1536), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     true,
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrTypeMismatch{"format", "size", reflect.Int64, reflect.Bool},
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"b"},
+			},
+			expected: ParseOutput{val: float64(3), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"kb"},
+			},
+			expected: ParseOutput{val: float64(3000), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"mb"},
+			},
+			expected: ParseOutput{val: float64(3000000), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"gb"},
+			},
+			expected: ParseOutput{val: float64(3000000000), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"tb"},
+			},
+			expected: ParseOutput{val: float64(3000000000000), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"pb"},
+			},
+			expected: ParseOutput{val: float64(3000000000000000), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"kib"},
+			},
+			expected: ParseOutput{val: float64(3072), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"mib"},
+			},
+			expected: ParseOutput{val: float64(3145728), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"gib"},
+			},
+			expected: ParseOutput{val: float64(3221225472), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"tib"},
+			},
+			expected: ParseOutput{val: float64(3298534883328), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"pib"},
+			},
+			expected: ParseOutput{val: float64(3377699720527872), err: nil},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "size",
+				Value:     "3",
+				Name:      "format",
+				Args:      []string{"kilobytes"},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrUnsupportedFormat{"kilobytes", "size"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		val, err := Parse(c.params)
+		if !(reflect.DeepEqual(val, c.expected.val) &&
+			reflect.DeepEqual(causeOf(t, err), c.expected.err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v",
+				c.expected.val, c.expected.err,
+				val, err)
+		}
+	}
+}
+
+func TestTransform_ParseSizeLiteral(t *testing.T) {
+	type Expected struct {
+		size float64
+		err  error
+	}
+
+	type Case struct {
+		literal  string
+		expected Expected
+	}
+
+	cases := []Case{
+		{literal: "512", expected: Expected{size: 512}},
+		{literal: "3b", expected: Expected{size: 3}},
+		{literal: "1kb", expected: Expected{size: 1e3}},
+		{literal: "1 KB", expected: Expected{size: 1e3}},
+		{literal: "1.5mb", expected: Expected{size: 1.5e6}},
+		{literal: "2 Gb", expected: Expected{size: 2e9}},
+		{literal: "1tb", expected: Expected{size: 1e12}},
+		{literal: "1.5 KiB", expected: Expected{size: 1536}},
+		{
+			literal:  "1xb",
+			expected: Expected{err: errors.New("invalid size 1xb: unknown unit xb")},
+		},
+	}
+
+	for _, c := range cases {
+		size, err := ParseSize(c.literal)
+		if !(size == c.expected.size && reflect.DeepEqual(err, c.expected.err)) {
+			t.Fatalf("%s\nExpected: %v, %v\n     Got: %v, %v", c.literal,
+				c.expected.size, c.expected.err, size, err)
+		}
+	}
+
+	if _, err := ParseSize("mb"); err == nil {
+		t.Fatalf("\nExpected an error\n     Got nil")
+	}
+}
+
+// TestTransform_SizeUnitsAgree asserts that a size literal in a condition
+// (e.g. `size > 3 mb`), the FORMAT modifier in a condition (e.g.
+// `FORMAT(size, mb) > 3`), and the output of the FORMAT modifier (e.g.
+// `3.000000mb`) agree on the number of bytes of each unit.
+func TestTransform_SizeUnitsAgree(t *testing.T) {
+	for unit, bytes := range sizeUnitBytes {
+		expected := 3 * bytes
+
+		for _, literal := range []string{"3" + unit, "3 " + strings.ToLower(unit)} {
+			if size, err := ParseSize(literal); err != nil || size != expected {
+				t.Fatalf("%s\nExpected: %v\n     Got: %v, %v", literal, expected, size, err)
+			}
+		}
+
+		parsed, err := Parse(&ParseParams{Attribute: "size", Value: "3", Name: "format",
+			Args: []string{unit}})
+		if err != nil || parsed != expected {
+			t.Fatalf("FORMAT(size, %s)\nExpected: %v\n     Got: %v, %v", unit, expected,
+				parsed, err)
+		}
+
+		formatted, err := Format(&FormatParams{Attribute: "size", Value: int64(expected),
+			Name: "format", Args: []string{unit}})
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if size, err := ParseSize(formatted.(string)); err != nil || size != expected {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v, %v", formatted, expected, size, err)
+		}
+	}
+}
+
+func TestTransform_ParseTime(t *testing.T) {
+	cases := []ParseCase{
+		{
+			params: &ParseParams{
+				Attribute: "time",
+				Value:     "2017-07-06T05:10:49Z",
+				Name:      "format",
+				Args:      []string{"iso"},
+			},
+			expected: ParseOutput{
+				val: time.Date(2017, time.July, 6, 5, 10, 49, 0, time.UTC),
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "time",
+				Value:     "2017-07-06",
+				Name:      "format",
+				Args:      []string{"2006-01-02"},
+			},
+			expected: ParseOutput{
+				val: time.Date(2017, time.July, 6, 0, 0, 0, 0, time.UTC),
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "time",
+				Value:     "06/07/2017 05:10",
+				Name:      "format",
+				Args:      []string{"02/01/2006 15:04"},
+			},
+			expected: ParseOutput{
+				val: time.Date(2017, time.July, 6, 5, 10, 0, 0, time.UTC),
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "time",
+				Value:     "2017-07-06 05:10",
+				Name:      "format",
+				Args:      []string{"2006-01-02 15:04", "utc"},
+			},
+			expected: ParseOutput{
+				val: time.Date(2017, time.July, 6, 5, 10, 0, 0, time.UTC),
+				err: nil,
+			},
+		},
+		{
+			params: &ParseParams{
+				Attribute: "time",
+				Value:     "2017-07-06 05:10",
+				Name:      "format",
+				Args:      []string{"2006-01-02 15:04", "Not/AZone"},
+			},
+			expected: ParseOutput{
+				val: nil,
+				err: &ErrTimeZone{
+					Name:  "Not/AZone",
+					Cause: errors.New("unknown time zone Not/AZone"),
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		val, err := Parse(c.params)
+		if !(reflect.DeepEqual(val, c.expected.val) &&
+			reflect.DeepEqual(causeOf(t, err), c.expected.err)) {
+			t.Fatalf("\nExpected: %v, %v\n     Got: %v, %v",
+				c.expected.val, c.expected.err,
+				val, err)
+		}
+	}
+}
+
+func TestTransform_ParseTimeLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %s", err.Error())
+	}
+
+	val, err := Parse(&ParseParams{
+		Attribute: "time",
+		Value:     "2017-07-06 05:10",
+		Name:      "format",
+		Args:      []string{"2006-01-02 15:04", "America/New_York"},
+	})
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got: %s", err.Error())
+	}
+	expected := time.Date(2017, time.July, 6, 5, 10, 0, 0, loc)
+	if !expected.Equal(val.(time.Time)) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, val)
+	}
+}
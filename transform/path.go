@@ -0,0 +1,26 @@
+package transform
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RelativePath returns the path of the file at path relative to the root of
+// its walk, given its depth (i.e. the last depth elements of path). Returns
+// `.` for the root itself.
+func RelativePath(path string, depth int64) string {
+	if depth <= 0 {
+		return "."
+	}
+	elems := strings.Split(filepath.Clean(path), string(filepath.Separator))
+	if int64(len(elems)) > depth {
+		elems = elems[int64(len(elems))-depth:]
+	}
+	return filepath.Join(elems...)
+}
+
+// AbsolutePath returns the absolute path of the file at path, see
+// filepath.Abs.
+func AbsolutePath(path string) (string, error) {
+	return filepath.Abs(path)
+}
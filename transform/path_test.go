@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath_RelativePath(t *testing.T) {
+	type Case struct {
+		path     string
+		depth    int64
+		expected string
+	}
+
+	cases := []Case{
+		{path: "./testdata", depth: 0, expected: "."},
+		{path: "testdata/foo", depth: 1, expected: "foo"},
+		{path: "testdata/foo/quuz/fred", depth: 3, expected: filepath.Join("foo", "quuz", "fred")},
+		{path: "../testdata/foo/quuz", depth: 1, expected: "quuz"},
+		{path: "/foo", depth: 1, expected: "foo"},
+	}
+
+	for _, c := range cases {
+		actual := RelativePath(filepath.FromSlash(c.path), c.depth)
+		if actual != c.expected {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.path, c.expected, actual)
+		}
+	}
+}
+
+func TestPath_AbsolutePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Case struct {
+		path     string
+		expected string
+	}
+
+	cases := []Case{
+		{path: ".", expected: wd},
+		{path: "./foo/../bar", expected: filepath.Join(wd, "bar")},
+		{path: wd, expected: wd},
+	}
+
+	for _, c := range cases {
+		actual, err := AbsolutePath(filepath.FromSlash(c.path))
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.path, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ModifierFunc is a custom modifier function (see RegisterModifier), which
+// returns the modified value of p.Value.
+type ModifierFunc func(p *ParseParams) (interface{}, error)
+
+// builtinModifiers holds the names of the modifier functions of Format and
+// Parse which aren't string modifiers (see stringModifiers), i.e. the cases of
+// their switch on the modifier name. It must be kept in sync with them, which
+// TestTransform_BuiltinModifiers checks.
+var builtinModifiers = []string{
+	"FORMAT", "FULLPATH", "SHORTPATH", "BASENAME", "DIRNAME", "STRIPEXT",
+	"ROUND", "XATTR", "HASH", "MD5", "SHA1", "SHA256", "SHA512",
+}
+
+// AggregateFunctions holds the names of each supported aggregate function
+// (e.g. `SUM(size)`), which can't be registered as modifiers either.
+var AggregateFunctions = []string{"COUNT", "SUM", "AVG", "MIN", "MAX"}
+
+// customModifiers holds each registered modifier function, keyed by its
+// (upper case) name.
+var customModifiers = struct {
+	sync.RWMutex
+	m map[string]ModifierFunc
+}{m: make(map[string]ModifierFunc)}
+
+// RegisterModifier registers fn as the modifier function name (case
+// insensitive), which is then run by both Format and Parse, e.g. so
+// `SELECT SLUG(name)` runs the function registered as `SLUG`. The name of a
+// built-in modifier or aggregate function, or of an already registered
+// modifier, can't be registered.
+func RegisterModifier(name string, fn ModifierFunc) error {
+	name = strings.ToUpper(name)
+	if name == "" || fn == nil {
+		return fmt.Errorf("invalid modifier %s", name)
+	}
+	if isBuiltinModifier(name) {
+		return fmt.Errorf("cannot register built-in modifier %s", name)
+	}
+	for _, aggregate := range AggregateFunctions {
+		if name == aggregate {
+			return fmt.Errorf("cannot register aggregate function %s", name)
+		}
+	}
+
+	customModifiers.Lock()
+	defer customModifiers.Unlock()
+	if _, ok := customModifiers.m[name]; ok {
+		return fmt.Errorf("modifier %s is already registered", name)
+	}
+	customModifiers.m[name] = fn
+	return nil
+}
+
+// UnregisterModifier removes the modifier function registered as name, if
+// any.
+func UnregisterModifier(name string) {
+	customModifiers.Lock()
+	defer customModifiers.Unlock()
+	delete(customModifiers.m, strings.ToUpper(name))
+}
+
+func isBuiltinModifier(name string) bool {
+	if _, ok := stringModifiers[name]; ok {
+		return true
+	}
+	for _, builtin := range builtinModifiers {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// runCustomModifier runs the modifier function registered as p.Name on p.
+// Returns nil if no such function is registered.
+func runCustomModifier(p *ParseParams) (interface{}, error) {
+	customModifiers.RLock()
+	fn, ok := customModifiers.m[strings.ToUpper(p.Name)]
+	customModifiers.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return fn(p)
+}
@@ -0,0 +1,136 @@
+package transform
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTransform_RegisterModifier(t *testing.T) {
+	slug := func(p *ParseParams) (interface{}, error) {
+		str, err := toString(p.Name, p.Attribute, p.Value)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Replace(strings.ToLower(str), " ", "-", -1), nil
+	}
+	defer UnregisterModifier("slug")
+
+	type Case struct {
+		name     string
+		expected error
+	}
+
+	cases := []Case{
+		{name: "slug", expected: nil},
+		{name: "SLUG", expected: errors.New("modifier SLUG is already registered")},
+		{name: "upper", expected: errors.New("cannot register built-in modifier UPPER")},
+		{name: "Format", expected: errors.New("cannot register built-in modifier FORMAT")},
+		{name: "sum", expected: errors.New("cannot register aggregate function SUM")},
+		{name: "COUNT", expected: errors.New("cannot register aggregate function COUNT")},
+		{name: "", expected: errors.New("invalid modifier ")},
+	}
+
+	for _, c := range cases {
+		actual := RegisterModifier(c.name, slug)
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", c.name, c.expected, actual)
+		}
+	}
+
+	val, err := Parse(&ParseParams{
+		Attribute: "name",
+		Value:     []string{"Foo Bar", "baz"},
+		Name:      "slug",
+	})
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if expected := []string{"foo-bar", "baz"}; !reflect.DeepEqual(expected, val) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, val)
+	}
+
+	val, err = Format(&FormatParams{Attribute: "name", Value: "Foo Bar", Name: "Slug"})
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	if expected := "foo-bar"; !reflect.DeepEqual(expected, val) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, val)
+	}
+
+	UnregisterModifier("slug")
+	_, err = Format(&FormatParams{Attribute: "name", Value: "Foo Bar", Name: "slug"})
+	expected := &ErrNotImplemented{Name: "slug", Attribute: "name"}
+	if !reflect.DeepEqual(expected, causeOf(t, err)) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, err)
+	}
+}
+
+// TestTransform_BuiltinModifiers checks that builtinModifiers holds exactly the
+// modifier names of the switch in Format, and that Parse doesn't handle any
+// other name.
+func TestTransform_BuiltinModifiers(t *testing.T) {
+	format := modifierCases(t, "format.go", "Format")
+	expected := append([]string{}, builtinModifiers...)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(expected, format) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", expected, format)
+	}
+
+	parse := modifierCases(t, "parse.go", "Parse")
+	if len(parse) == 0 {
+		t.Fatalf("\nExpected the modifier names of Parse\n     Got none")
+	}
+	for _, name := range parse {
+		if !isBuiltinModifier(name) {
+			t.Fatalf("\nExpected %s to be a built-in modifier", name)
+		}
+	}
+}
+
+// modifierCases returns the sorted case values of the switch on
+// `strings.ToUpper(p.Name)` in the function fn of file.
+func modifierCases(t *testing.T, file, fn string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	names := []string{}
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.FuncDecl); !ok || d.Name.Name != fn || d.Recv != nil {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			s, ok := n.(*ast.SwitchStmt)
+			if !ok || s.Tag == nil {
+				return true
+			}
+			call, ok := s.Tag.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if sel, ok := call.Args[0].(*ast.SelectorExpr); !ok || sel.Sel.Name != "Name" {
+				return true
+			}
+			for _, stmt := range s.Body.List {
+				for _, expr := range stmt.(*ast.CaseClause).List {
+					name, err := strconv.Unquote(expr.(*ast.BasicLit).Value)
+					if err != nil {
+						t.Fatalf("\nExpected no error\n     Got %v", err)
+					}
+					names = append(names, name)
+				}
+			}
+			return false
+		})
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,68 @@
+package transform
+
+import (
+	"io"
+	"os"
+)
+
+// statCache is implemented by the info of a file which caches the info of its
+// target (if it's a symbolic link) and of the link itself (see query), so
+// that a file is stat'd at most once per query, however many of its
+// attributes are used.
+type statCache interface {
+	Target() (os.FileInfo, error)
+	Link() (os.FileInfo, error)
+}
+
+// stat returns the info of the file at path (with info), following symbolic
+// links, like os.Stat.
+func stat(path string, info os.FileInfo) (os.FileInfo, error) {
+	if c, ok := info.(statCache); ok {
+		return c.Target()
+	}
+	return os.Stat(path)
+}
+
+// lstat returns the info of the file at path (with info), without following
+// symbolic links, like os.Lstat.
+func lstat(path string, info os.FileInfo) (os.FileInfo, error) {
+	if c, ok := info.(statCache); ok {
+		return c.Link()
+	}
+	return os.Lstat(path)
+}
+
+// warner is implemented by the info of a file which records the errors which
+// didn't prevent its attributes from being read (see query), e.g. of a
+// directory whose entries can't be read, which isn't empty.
+type warner interface {
+	Warn(err error)
+}
+
+// warn records err for the file with info, if its info records errors.
+func warn(info os.FileInfo, err error) {
+	if w, ok := info.(warner); ok {
+		w.Warn(err)
+	}
+}
+
+// virtualFile is implemented by the info of a file which isn't on the
+// filesystem, e.g. an entry of an archive (see query).
+type virtualFile interface {
+	// Open opens the contents of the file, which is a regular file.
+	Open() (io.ReadCloser, error)
+
+	// Entries returns the number of entries of the file, which is a directory.
+	Entries() int
+
+	// Readlink returns the target of the file, which is a symbolic link.
+	Readlink() (string, error)
+}
+
+// open opens the contents of the file at path (with info) for reading.
+func open(path string, info os.FileInfo) (io.ReadCloser, error) {
+	if v, ok := info.(virtualFile); ok {
+		return v.Open()
+	}
+	return os.Open(path)
+}
@@ -0,0 +1,40 @@
+package transform
+
+import (
+	"os"
+	"time"
+)
+
+// IsTimeAttribute returns true iff attr is one of the file time attributes,
+// i.e. `time`, `accessed`, `changed`, or `created`.
+func IsTimeAttribute(attr string) bool {
+	switch attr {
+	case "time", "accessed", "changed", "created":
+		return true
+	}
+	return false
+}
+
+// FileTime returns the time of the file time attribute attr (`time` being the
+// modification time). Returns false if the time isn't available on this
+// platform (or, in the case of `created`, on this file system).
+func FileTime(attr string, info os.FileInfo) (time.Time, bool) {
+	if attr == "time" {
+		return info.ModTime(), true
+	}
+
+	atime, ctime, btime, ok := statTimes(info)
+	if !ok {
+		return time.Time{}, false
+	}
+	var t time.Time
+	switch attr {
+	case "accessed":
+		t = atime
+	case "changed":
+		t = ctime
+	case "created":
+		t = btime
+	}
+	return t, !t.IsZero()
+}
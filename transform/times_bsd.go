@@ -0,0 +1,25 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package transform
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statTimes returns the access, status change, and birth times of the file.
+func statTimes(info os.FileInfo) (atime, ctime, btime time.Time, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return atime, ctime, btime, false
+	}
+	atime = time.Unix(stat.Atimespec.Unix())
+	ctime = time.Unix(stat.Ctimespec.Unix())
+	// File systems without birth times report a zero (or negative) time.
+	if stat.Birthtimespec.Sec > 0 {
+		btime = time.Unix(stat.Birthtimespec.Unix())
+	}
+	return atime, ctime, btime, true
+}
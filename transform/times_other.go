@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package transform
+
+import (
+	"os"
+	"time"
+)
+
+// statTimes reports that access, status change, and birth times aren't
+// available on this platform.
+func statTimes(info os.FileInfo) (atime, ctime, btime time.Time, ok bool) {
+	return atime, ctime, btime, false
+}
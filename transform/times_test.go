@@ -0,0 +1,38 @@
+package transform
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestTimes_NoSys(t *testing.T) {
+	info := noSysInfo{}
+	if _, ok := FileTime("time", info); !ok {
+		t.Fatalf("\nExpected: %v\n     Got: %v", true, ok)
+	}
+	for _, attr := range []string{"accessed", "changed", "created"} {
+		if actual, ok := FileTime(attr, info); ok || !actual.IsZero() {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", attr, false, ok)
+		}
+	}
+}
+
+func TestTimes_File(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("access and change times aren't available on windows")
+	}
+
+	info, err := os.Stat("../testdata/baz")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	for _, attr := range []string{"time", "accessed", "changed"} {
+		if actual, ok := FileTime(attr, info); !ok || actual.IsZero() {
+			t.Fatalf("%s\nExpected: %v\n     Got: %v", attr, true, ok)
+		}
+	}
+	if actual, _ := FileTime("time", info); !actual.Equal(info.ModTime()) {
+		t.Fatalf("\nExpected: %v\n     Got: %v", info.ModTime(), actual)
+	}
+}
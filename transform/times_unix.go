@@ -0,0 +1,22 @@
+//go:build dragonfly || linux || openbsd || solaris
+// +build dragonfly linux openbsd solaris
+
+package transform
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statTimes returns the access and status change times of the file. Birth
+// times aren't reported by stat(2) on these platforms, so btime is zero.
+func statTimes(info os.FileInfo) (atime, ctime, btime time.Time, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return atime, ctime, btime, false
+	}
+	atime = time.Unix(stat.Atim.Unix())
+	ctime = time.Unix(stat.Ctim.Unix())
+	return atime, ctime, btime, true
+}
@@ -0,0 +1,31 @@
+package transform
+
+import "os"
+
+// HasXattr returns true iff the file at path (with info) has any extended
+// attributes, or false if they aren't available on this platform (or
+// filesystem). Files which aren't on the filesystem (e.g. an entry of an
+// archive) have none. If info is of a symbolic link (i.e. it isn't followed),
+// the attributes are the link's own rather than its target's.
+func HasXattr(path string, info os.FileInfo) (bool, error) {
+	if _, ok := info.(virtualFile); ok {
+		return false, nil
+	}
+	names, err := listXattrs(path, info != nil && info.Mode()&os.ModeSymlink != 0)
+	if err != nil {
+		return false, err
+	}
+	return len(names) > 0, nil
+}
+
+// Xattr returns the value of the extended attribute name (e.g. `user.tag`) of
+// the file at path (with info), or an empty string if the file doesn't have it
+// or extended attributes aren't available on this platform (or filesystem).
+// Files which aren't on the filesystem (e.g. an entry of an archive) have none,
+// and a symbolic link's attributes are its own (see HasXattr).
+func Xattr(path string, info os.FileInfo, name string) (string, error) {
+	if _, ok := info.(virtualFile); ok {
+		return "", nil
+	}
+	return getXattr(path, name, info != nil && info.Mode()&os.ModeSymlink != 0)
+}
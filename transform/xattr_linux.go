@@ -0,0 +1,96 @@
+package transform
+
+import (
+	"bytes"
+	"syscall"
+	"unsafe"
+)
+
+// listXattrs returns the names of the extended attributes of the file at path.
+// If link is true, the file is a symbolic link whose own attributes are listed,
+// rather than its target's.
+func listXattrs(path string, link bool) ([]string, error) {
+	buf, err := readXattr(func(dest []byte) (int, error) {
+		if link {
+			return xattrSyscall(syscall.SYS_LLISTXATTR, path, nil, dest)
+		}
+		return syscall.Listxattr(path, dest)
+	})
+	if err != nil || len(buf) == 0 {
+		return nil, err
+	}
+	names := bytes.Split(bytes.TrimSuffix(buf, []byte{0}), []byte{0})
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = string(name)
+	}
+	return result, nil
+}
+
+// getXattr returns the value of the extended attribute name of the file at
+// path. If link is true, the file is a symbolic link whose own attribute is
+// read, rather than its target's.
+func getXattr(path, name string, link bool) (string, error) {
+	buf, err := readXattr(func(dest []byte) (int, error) {
+		if link {
+			attr, err := syscall.BytePtrFromString(name)
+			if err != nil {
+				return 0, err
+			}
+			return xattrSyscall(syscall.SYS_LGETXATTR, path, attr, dest)
+		}
+		return syscall.Getxattr(path, name, dest)
+	})
+	return string(buf), err
+}
+
+// xattrSyscall runs the system call trap (i.e. llistxattr or lgetxattr, which
+// the syscall package doesn't provide) on path and dest, preceded by the
+// attribute name for lgetxattr.
+func xattrSyscall(trap uintptr, path string, name *byte, dest []byte) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var buf unsafe.Pointer
+	if len(dest) > 0 {
+		buf = unsafe.Pointer(&dest[0])
+	}
+
+	var size uintptr
+	var errno syscall.Errno
+	if name == nil {
+		size, _, errno = syscall.Syscall(trap, uintptr(unsafe.Pointer(p)), uintptr(buf),
+			uintptr(len(dest)))
+	} else {
+		size, _, errno = syscall.Syscall6(trap, uintptr(unsafe.Pointer(p)),
+			uintptr(unsafe.Pointer(name)), uintptr(buf), uintptr(len(dest)), 0, 0)
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(size), nil
+}
+
+// readXattr calls read (i.e. Listxattr or Getxattr) with a buffer sized by a
+// first call without one, retrying if the result grows in between. The result
+// is empty if the file doesn't have the attribute, extended attributes aren't
+// supported by its filesystem, or it's a broken symbolic link.
+func readXattr(read func([]byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := read(nil)
+		if err == nil && size > 0 {
+			buf := make([]byte, size)
+			if size, err = read(buf); err == nil {
+				return buf[:size], nil
+			}
+		}
+		switch err {
+		case nil, syscall.ENODATA, syscall.ENOTSUP, syscall.ENOENT:
+			return nil, nil
+		case syscall.ERANGE:
+			continue
+		}
+		return nil, err
+	}
+}
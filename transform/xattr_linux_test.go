@@ -0,0 +1,101 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestXattr_Linux(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsql")
+	if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "plain")
+	tagged := filepath.Join(dir, "tagged")
+	for _, path := range []string{plain, tagged} {
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+	}
+	if err := syscall.Setxattr(tagged, "user.tag", []byte("red"), 0); err == syscall.ENOTSUP {
+		t.Skip("extended attributes aren't supported by the temporary directory's filesystem")
+	} else if err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+
+	type Expected struct {
+		has   bool
+		value string
+	}
+
+	type Case struct {
+		path     string
+		expected Expected
+	}
+
+	cases := []Case{
+		{path: plain, expected: Expected{has: false, value: ""}},
+		{path: tagged, expected: Expected{has: true, value: "red"}},
+		{path: filepath.Join(dir, "nonexistent"), expected: Expected{has: false, value: ""}},
+	}
+
+	for _, c := range cases {
+		has, err := HasXattr(c.path, nil)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		value, err := Format(&FormatParams{
+			Attribute: "path",
+			Path:      c.path,
+			Value:     c.path,
+			Name:      "XATTR",
+			Args:      []string{"user.tag"},
+		})
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		actual := Expected{has: has, value: value.(string)}
+		if !reflect.DeepEqual(c.expected, actual) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", c.path, c.expected, actual)
+		}
+	}
+
+	// A symbolic link's own attributes are read, unless it's followed (i.e.
+	// info is of its target).
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(tagged, link); err != nil {
+		t.Fatalf("\nExpected no error\n     Got %v", err)
+	}
+	for _, stat := range []func(string) (os.FileInfo, error){os.Lstat, os.Stat} {
+		info, err := stat(link)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		has, err := HasXattr(link, info)
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		value, err := Xattr(link, info, "user.tag")
+		if err != nil {
+			t.Fatalf("\nExpected no error\n     Got %v", err)
+		}
+		expected := Expected{has: false, value: ""}
+		if info.Mode()&os.ModeSymlink == 0 {
+			expected = Expected{has: true, value: "red"}
+		}
+		if actual := (Expected{has: has, value: value}); !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("%s\nExpected %v\n     Got %v", link, expected, actual)
+		}
+	}
+
+	_, err = Format(&FormatParams{Attribute: "path", Path: tagged, Value: tagged, Name: "XATTR"})
+	if expected := (&ErrArgumentCount{"XATTR", 1, 0}); !reflect.DeepEqual(expected, causeOf(t, err)) {
+		t.Fatalf("\nExpected %v\n     Got %v", expected, err)
+	}
+}
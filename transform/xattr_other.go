@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package transform
+
+// listXattrs reports that extended attributes aren't available on this
+// platform.
+func listXattrs(path string, link bool) ([]string, error) {
+	return nil, nil
+}
+
+// getXattr reports that extended attributes aren't available on this
+// platform.
+func getXattr(path, name string, link bool) (string, error) {
+	return "", nil
+}